@@ -2,22 +2,97 @@
 package beads
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/vibes-project/vibes/internal/runner"
 )
 
+// DefaultMaxTasks is the default number of tasks kept from task listings
+// embedded in prompts.
+const DefaultMaxTasks = 10
+
+// TriageTimeout bounds how long bv and bd may take to list tasks.
+const TriageTimeout = 10 * time.Second
+
+// maxListingBytes is a hard cap on task listing size, applied after task limiting.
+const maxListingBytes = 32 * 1024
+
+// IDPattern matches bead IDs such as bd-42; the second group is the number.
+var IDPattern = regexp.MustCompile(`\b(bd|BEAD|bead)-(\d+)\b`)
+
+// taskLinePattern matches the first line of a task in text listings such as
+// `bd ready`: an unindented line with an optional list number and bracketed
+// tags ([P1], [bug]) before the bead ID. Indented detail lines and lines that
+// merely mention an ID ("Blocked by: bd-3") don't match.
+var taskLinePattern = regexp.MustCompile(`^(?:\d+\.\s+)?(?:\[[^\]]*\]\s+)*` + IDPattern.String())
+
+// ErrNotInitialized is returned by strict-mode commands that need a task graph.
+var ErrNotInitialized = errors.New("no beads task graph found (run `bd init`)")
+
+// ErrNoTasks is returned by strict-mode commands when no task is ready to work on.
+var ErrNoTasks = errors.New("no ready tasks (create one with `bd create`)")
+
 // TaskInfo holds information about a bead task.
 type TaskInfo struct {
 	ID          string
 	Title       string
 	Status      string
 	Branch      string
-	ProjectName string
+	ProjectName string    // Agent Mail project key: the directory name unless overridden
+	URL         string    // Issue page for tasks from an external tracker
+	Updated     time.Time // Last update reported by bd show, zero if unknown
+}
+
+// Ref returns the task ID, linked to its issue page when known.
+func (t TaskInfo) Ref() string {
+	if t.URL != "" {
+		return fmt.Sprintf("[%s](%s)", t.ID, t.URL)
+	}
+	return t.ID
+}
+
+// IssueTracker matches task IDs from an external tracker such as Jira or Linear.
+type IssueTracker struct {
+	Pattern     *regexp.Regexp // Issue IDs in branch names, e.g. [A-Z]+-\d+
+	URLTemplate string         // Issue link with an {id} placeholder (optional)
+}
+
+// NewIssueTracker builds a tracker from the issue_pattern and
+// issue_url_template settings. Returns nil if pattern is empty.
+func NewIssueTracker(pattern, urlTemplate string) (*IssueTracker, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issue_pattern %q: %w", pattern, err)
+	}
+	return &IssueTracker{Pattern: re, URLTemplate: urlTemplate}, nil
+}
+
+// Match returns the first issue ID in s, or empty string if there is none.
+func (t *IssueTracker) Match(s string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Pattern.FindString(s)
+}
+
+// URL returns the issue page for id, or empty string without a URL template.
+func (t *IssueTracker) URL(id string) string {
+	if t == nil || t.URLTemplate == "" {
+		return ""
+	}
+	return strings.ReplaceAll(t.URLTemplate, "{id}", id)
 }
 
 // IsInitialized checks if beads is initialized in the given directory.
@@ -45,6 +120,54 @@ func ExtractIDFromBranch(branch string) string {
 	return ""
 }
 
+// ParseCreateOutput reads the new bead's ID from `bd create --json` output.
+// Returns empty string if the output isn't JSON or has no id.
+func ParseCreateOutput(output string) string {
+	type created struct {
+		ID string `json:"id"`
+	}
+	// Like bd show --json, newer versions may print an array
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "[") {
+		var all []created
+		if err := json.Unmarshal([]byte(trimmed), &all); err != nil || len(all) == 0 {
+			return ""
+		}
+		return all[0].ID
+	}
+	var bead created
+	if err := json.Unmarshal([]byte(trimmed), &bead); err != nil {
+		return ""
+	}
+	return bead.ID
+}
+
+// maxSlugLength bounds the title part of suggested branch names
+const maxSlugLength = 40
+
+// SuggestBranchName returns a feature branch name for a task, e.g.
+// "feature/bd-42-add-login-page" for bd-42 "Add login page".
+func SuggestBranchName(id, title string) string {
+	var slug strings.Builder
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if slug.Len() > 0 && slug.Len()+1+len(word) > maxSlugLength {
+			break
+		}
+		if slug.Len() > 0 {
+			slug.WriteString("-")
+		}
+		slug.WriteString(word)
+	}
+
+	name := "feature/" + id
+	if slug.Len() > 0 {
+		name += "-" + slug.String()
+	}
+	return name
+}
+
 // ParseListLine parses a line from `bd list` output.
 // Format: "bd-123  Some task title  [status]"
 func ParseListLine(line string) (id, title string) {
@@ -90,13 +213,85 @@ func ExtractStatusFromShow(output string) string {
 	return ""
 }
 
+// IsClosedStatus reports whether a bd status means the task is finished.
+func IsClosedStatus(status string) bool {
+	return strings.EqualFold(strings.TrimSpace(status), "closed")
+}
+
+// showFieldHeader matches the field lines of `bd show` output, e.g. "Status: open"
+// or "Dependencies (2):". Only known fields count, so a description line such as
+// "Note: retry on failure" isn't mistaken for the end of the description.
+var showFieldHeader = regexp.MustCompile(`^(ID|Title|Status|Priority|Type|Assignee|Owner|Labels|Created|Updated|Closed|Close reason|Description|Design|Notes|Acceptance Criteria|External Ref|Dependencies|Dependents|Blocks|Blocked by|Depends on)(\s*\(\d+\))?:`)
+
+// ExtractDescriptionFromShow extracts the description from `bd show` output.
+// The description may start on the "Description:" line and continues until
+// the next field header.
+func ExtractDescriptionFromShow(output string) string {
+	var desc []string
+	inDescription := false
+	for _, line := range strings.Split(output, "\n") {
+		if m := showFieldHeader.FindStringSubmatch(line); m != nil {
+			if inDescription {
+				break
+			}
+			if m[1] == "Description" {
+				inDescription = true
+				if rest := strings.TrimSpace(line[len(m[0]):]); rest != "" {
+					desc = append(desc, rest)
+				}
+			}
+			continue
+		}
+		if inDescription {
+			desc = append(desc, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.Trim(strings.Join(desc, "\n"), "\n")
+}
+
+// GetTask looks up a specific bead with bd show, for commands told which
+// task to use instead of detecting it. Returns an error if the bead can't be shown.
+func GetTask(dir string, id string, branch string, r runner.CommandRunner) (TaskInfo, error) {
+	output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "show", id)
+	if err != nil {
+		if output := runner.ErrorOutput(err); output != "" {
+			return TaskInfo{}, fmt.Errorf("task %s not found: %w\n%s", id, err, output)
+		}
+		return TaskInfo{}, fmt.Errorf("task %s not found: %w", id, err)
+	}
+	if strings.TrimSpace(output) == "" {
+		return TaskInfo{}, fmt.Errorf("task %s not found", id)
+	}
+
+	slog.Info("using task", "id", id, "via", "--task")
+	updated, _ := ExtractUpdatedFromShow(output)
+	return TaskInfo{
+		ID:      id,
+		Title:   ExtractTitleFromShow(output),
+		Status:  ExtractStatusFromShow(output),
+		Branch:  branch,
+		Updated: updated,
+	}, nil
+}
+
 // DetectCurrentTask attempts to detect the current task from beads or branch name.
-func DetectCurrentTask(dir string, branch string, r runner.CommandRunner) TaskInfo {
+// tracker is consulted for branch names without a bead ID; nil means Beads only.
+func DetectCurrentTask(dir string, branch string, tracker *IssueTracker, r runner.CommandRunner) TaskInfo {
 	task := TaskInfo{Branch: branch}
 
 	if !IsInitialized(dir) {
 		// Try to extract from branch name as fallback
 		task.ID = ExtractIDFromBranch(branch)
+		if task.ID == "" {
+			if id := tracker.Match(branch); id != "" {
+				task.ID, task.URL = id, tracker.URL(id)
+			}
+		}
+		if task.ID != "" {
+			slog.Info("detected task", "id", task.ID, "via", "branch name (beads not initialized)", "branch", branch)
+		} else {
+			slog.Info("no current task detected", "branch", branch, "beads", "not initialized")
+		}
 		return task
 	}
 
@@ -110,6 +305,7 @@ func DetectCurrentTask(dir string, branch string, r runner.CommandRunner) TaskIn
 				task.ID = id
 				task.Title = title
 				task.Status = "in_progress"
+				slog.Info("detected task", "id", id, "via", "bd list --status in_progress")
 				return task
 			}
 		}
@@ -122,8 +318,255 @@ func DetectCurrentTask(dir string, branch string, r runner.CommandRunner) TaskIn
 		if output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "show", beadID); err == nil {
 			task.Title = ExtractTitleFromShow(output)
 			task.Status = ExtractStatusFromShow(output)
+			task.Updated, _ = ExtractUpdatedFromShow(output)
 		}
+		slog.Info("detected task", "id", beadID, "via", "branch name", "branch", branch)
+		return task
+	}
+
+	// External tracker IDs aren't beads, so there's nothing for bd show to find
+	if id := tracker.Match(branch); id != "" {
+		task.ID, task.URL = id, tracker.URL(id)
+		slog.Info("detected task", "id", id, "via", "issue_pattern", "branch", branch)
+		return task
 	}
 
+	slog.Info("no current task detected", "branch", branch)
 	return task
 }
+
+// Triage holds the task listing used to recommend work.
+type Triage struct {
+	Output  string // Limited task listing, empty if none was available
+	Source  string // Tool that produced Output: "bv" or "bd"
+	Warning string // Explains any tool that timed out
+
+	listed   bool     // A tool ran successfully, even if it listed no tasks
+	failures []string // Why each tool failed
+	missing  int      // How many tools failed because they aren't installed
+}
+
+// Err reports why no tool could list tasks, for callers that must not
+// proceed without a task listing. Returns nil if any tool succeeded.
+func (t Triage) Err() error {
+	if t.listed || len(t.failures) == 0 {
+		return nil
+	}
+	if t.missing == len(t.failures) {
+		return fmt.Errorf("could not list tasks: %s: %w", strings.Join(t.failures, "; "), runner.ErrToolMissing)
+	}
+	return fmt.Errorf("could not list tasks: %s", strings.Join(t.failures, "; "))
+}
+
+// Require is Err for strict-mode commands, which also can't proceed when
+// the tools ran but listed no tasks.
+func (t Triage) Require() error {
+	if err := t.Err(); err != nil {
+		return err
+	}
+	if t.Output == "" && t.Warning == "" {
+		return ErrNoTasks
+	}
+	return nil
+}
+
+// GetTriage lists recommended tasks, preferring bv --robot-triage and falling
+// back to bd ready, re-sorted by priority. Timeouts are reported in Warning
+// rather than hidden.
+func GetTriage(dir string, r runner.CommandRunner, maxTasks int) Triage {
+	var triage Triage
+
+	sources := []struct {
+		tool string
+		args []string
+	}{
+		{"bv", []string{"--robot-triage"}},
+		{"bd", []string{"ready"}},
+	}
+	for _, s := range sources {
+		output, err := r.RunWithTimeout(dir, TriageTimeout, s.tool, s.args...)
+		if err == nil && output != "" {
+			// bv's ranking is deliberate; bd ready's order isn't guaranteed
+			if s.tool == "bd" {
+				output = SortByPriority(output)
+			}
+			triage.Output = LimitTasks(output, maxTasks)
+			triage.Source = s.tool
+			triage.listed = true
+			slog.Info("task triage", "source", s.tool)
+			return triage
+		}
+		if err == nil {
+			triage.listed = true
+		} else {
+			triage.failures = append(triage.failures, fmt.Sprintf("%s %s: %v", s.tool, strings.Join(s.args, " "), err))
+			if runner.IsNotFound(err) {
+				triage.missing++
+			}
+		}
+		if runner.IsTimeout(err) {
+			slog.Warn("triage command timed out", "tool", s.tool, "timeout", TriageTimeout)
+			triage.Warning += TimeoutWarning(s.tool, TriageTimeout)
+		}
+	}
+
+	return triage
+}
+
+// Refresh re-imports bd's database from the git-tracked JSONL, so triage
+// doesn't reflect a stale cache. Failures are only logged, since not every
+// bd version supports it.
+func Refresh(dir string, r runner.CommandRunner) {
+	if _, err := r.RunWithTimeout(dir, TriageTimeout, "bd", "sync", "--import-only"); err != nil {
+		slog.Warn("could not refresh beads database", "err", err)
+		return
+	}
+	slog.Info("refreshed beads database", "via", "bd sync --import-only")
+}
+
+// TimeoutWarning describes a beads tool that timed out.
+func TimeoutWarning(tool string, timeout time.Duration) string {
+	return fmt.Sprintf("⚠️ `%s` timed out after %s — backlog may be large or %s is slow\n", tool, timeout, tool)
+}
+
+// LimitTasks trims `bv --robot-triage` or `bd ready` output to at most maxTasks
+// tasks so large backlogs don't blow the prompt budget. JSON output has its
+// task lists trimmed; plain text output is cut before the task after the
+// maxTasks-th.
+// A maxTasks of 0 or less disables task limiting, but the byte cap still applies.
+func LimitTasks(output string, maxTasks int) string {
+	if maxTasks > 0 {
+		if limited, ok := limitJSONTasks(output, maxTasks); ok {
+			output = limited
+		} else {
+			output = limitTextTasks(output, maxTasks)
+		}
+	}
+
+	if len(output) > maxListingBytes {
+		cut := strings.LastIndex(output[:maxListingBytes], "\n")
+		if cut <= 0 {
+			cut = maxListingBytes
+		}
+		output = output[:cut] + fmt.Sprintf("\n... (output truncated at %d bytes)", maxListingBytes)
+	}
+
+	return output
+}
+
+// taskListKeys name the task lists trimmed in JSON triage output, either at
+// the top level or under "triage". Other arrays are left alone.
+var taskListKeys = map[string]bool{
+	"recommendations":   true,
+	"quick_wins":        true,
+	"blockers_to_clear": true,
+	"ready":             true,
+	"issues":            true,
+	"tasks":             true,
+}
+
+// limitJSONTasks trims the known task lists in JSON output to maxTasks
+// entries, keeping everything else byte for byte. A top-level array (as from
+// `bd ready --json`) is itself a task list. Returns false if the output is not JSON.
+func limitJSONTasks(output string, maxTasks int) (string, bool) {
+	if !json.Valid([]byte(output)) {
+		return "", false
+	}
+
+	omitted := 0
+	limited := output
+	switch trimmed := strings.TrimSpace(output); {
+	case strings.HasPrefix(trimmed, "["):
+		limited = trimTaskList(output, maxTasks, &omitted)
+	case strings.HasPrefix(trimmed, "{"):
+		limited = trimTaskLists(output, maxTasks, &omitted)
+	}
+	if omitted == 0 {
+		return output, true
+	}
+	return limited + fmt.Sprintf("\n\n... (%d more entries omitted, showing top %d per list)", omitted, maxTasks), true
+}
+
+// trimTaskLists trims the task lists among an object's members, and those of
+// its "triage" member, splicing the results into the original text.
+func trimTaskLists(object string, maxTasks int, omitted *int) string {
+	type splice struct {
+		start, end int
+		value      string
+	}
+	var splices []splice
+
+	dec := json.NewDecoder(strings.NewReader(object))
+	if _, err := dec.Token(); err != nil {
+		return object
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return object
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return object
+		}
+		end := int(dec.InputOffset())
+		start := end - len(value)
+
+		switch {
+		case taskListKeys[key.(string)] && strings.HasPrefix(string(value), "["):
+			splices = append(splices, splice{start, end, trimTaskList(string(value), maxTasks, omitted)})
+		case key == "triage" && strings.HasPrefix(string(value), "{"):
+			splices = append(splices, splice{start, end, trimTaskLists(string(value), maxTasks, omitted)})
+		}
+	}
+
+	for i := len(splices) - 1; i >= 0; i-- {
+		sp := splices[i]
+		object = object[:sp.start] + sp.value + object[sp.end:]
+	}
+	return object
+}
+
+// trimTaskList cuts a JSON array after its maxTasks-th element, keeping the
+// elements and the array's closing indentation as they were.
+func trimTaskList(array string, maxTasks int, omitted *int) string {
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(array), &items); err != nil || len(items) <= maxTasks {
+		return array
+	}
+
+	dec := json.NewDecoder(strings.NewReader(array))
+	if _, err := dec.Token(); err != nil {
+		return array
+	}
+	for i := 0; i < maxTasks; i++ {
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return array
+		}
+	}
+	*omitted += len(items) - maxTasks
+
+	body := strings.TrimRightFunc(array, unicode.IsSpace)
+	closing := strings.TrimRightFunc(body[:len(body)-1], unicode.IsSpace)
+	return array[:dec.InputOffset()] + body[len(closing):]
+}
+
+// limitTextTasks keeps the first maxTasks tasks of text output, each with
+// the detail lines that follow it.
+func limitTextTasks(output string, maxTasks int) string {
+	lines := strings.Split(output, "\n")
+
+	var starts []int
+	for i, line := range lines {
+		if taskLinePattern.MatchString(line) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) <= maxTasks {
+		return output
+	}
+
+	kept := strings.TrimRight(strings.Join(lines[:starts[maxTasks]], "\n"), "\n")
+	return kept + fmt.Sprintf("\n... (%d more tasks omitted, showing top %d)", len(starts)-maxTasks, maxTasks)
+}
@@ -1,11 +1,16 @@
 package beads
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
 )
 
 // MockRunner is a mock implementation of runner.CommandRunner for testing
@@ -73,6 +78,49 @@ func TestExtractIDFromBranch(t *testing.T) {
 	}
 }
 
+func TestParseCreateOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"object", `{"id":"bd-42","title":"Fix bd-7 regression"}`, "bd-42"},
+		{"array", `[{"id":"bd-42","title":"Add login page"}]`, "bd-42"},
+		{"text output", "✓ Created issue: bd-42\n", ""},
+		{"error text mentioning an ID", "Error: bd-7 is locked", ""},
+		{"no id", `{"title":"Add login page"}`, ""},
+		{"empty array", `[]`, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if id := ParseCreateOutput(tc.output); id != tc.expected {
+				t.Errorf("ParseCreateOutput(%q) = %q, want %q", tc.output, id, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSuggestBranchName(t *testing.T) {
+	testCases := []struct {
+		title    string
+		expected string
+	}{
+		{"Add login page", "feature/bd-42-add-login-page"},
+		{"Fix: crash on `vibes next` (again!)", "feature/bd-42-fix-crash-on-vibes-next-again"},
+		{"Refactor the configuration loader to support nested profiles and overrides", "feature/bd-42-refactor-the-configuration-loader-to"},
+		{"", "feature/bd-42"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			if name := SuggestBranchName("bd-42", tc.title); name != tc.expected {
+				t.Errorf("SuggestBranchName(%q) = %q, want %q", tc.title, name, tc.expected)
+			}
+		})
+	}
+}
+
 func TestParseListLine(t *testing.T) {
 	testCases := []struct {
 		line          string
@@ -141,12 +189,43 @@ func TestExtractStatusFromShow(t *testing.T) {
 	}
 }
 
+func TestExtractDescriptionFromShow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{
+			"multi-line with trailing fields",
+			"Title: Add login\nStatus: open\n\nDescription:\nUsers need to sign in.\n\nNote: keep sessions short\n- accept email\n\nDependencies (1):\n  bd-2 Set up db\n",
+			"Users need to sign in.\n\nNote: keep sessions short\n- accept email",
+		},
+		{
+			"multi-line at end of output",
+			"Title: Add login\nStatus: open\nDescription: Users need to sign in.\nAcceptance: tests pass\n",
+			"Users need to sign in.\nAcceptance: tests pass",
+		},
+		{"stops at known field", "Description: Short\nPriority: 1", "Short"},
+		{"no description", "Title: Some task\nStatus: open", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ExtractDescriptionFromShow(tc.output)
+			if result != tc.expected {
+				t.Errorf("ExtractDescriptionFromShow() = %q, want %q", result, tc.expected)
+			}
+		})
+	}
+}
+
 func TestDetectCurrentTask(t *testing.T) {
 	t.Run("no beads directory uses branch", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		mock := &MockRunner{}
 
-		task := DetectCurrentTask(tmpDir, "feature/bd-123-test", mock)
+		task := DetectCurrentTask(tmpDir, "feature/bd-123-test", nil, mock)
 
 		if task.ID != "bd-123" {
 			t.Errorf("expected ID 'bd-123', got %q", task.ID)
@@ -169,7 +248,7 @@ func TestDetectCurrentTask(t *testing.T) {
 			},
 		}
 
-		task := DetectCurrentTask(tmpDir, "feature/test", mock)
+		task := DetectCurrentTask(tmpDir, "feature/test", nil, mock)
 
 		if task.ID != "bd-456" {
 			t.Errorf("expected ID 'bd-456', got %q", task.ID)
@@ -195,7 +274,7 @@ func TestDetectCurrentTask(t *testing.T) {
 			},
 		}
 
-		task := DetectCurrentTask(tmpDir, "feature/bd-789-fallback", mock)
+		task := DetectCurrentTask(tmpDir, "feature/bd-789-fallback", nil, mock)
 
 		if task.ID != "bd-789" {
 			t.Errorf("expected ID 'bd-789', got %q", task.ID)
@@ -221,7 +300,7 @@ func TestDetectCurrentTask(t *testing.T) {
 			},
 		}
 
-		task := DetectCurrentTask(tmpDir, "feature/bd-999-from-branch", mock)
+		task := DetectCurrentTask(tmpDir, "feature/bd-999-from-branch", nil, mock)
 
 		if task.ID != "bd-999" {
 			t.Errorf("expected ID 'bd-999', got %q", task.ID)
@@ -234,3 +313,271 @@ func TestDetectCurrentTask(t *testing.T) {
 		}
 	})
 }
+
+func TestDetectCurrentTaskIssueTracker(t *testing.T) {
+	tracker, err := NewIssueTracker(`(?i)\b[a-z]+-\d+\b`, "https://tracker.example.com/browse/{id}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		branch   string
+		beads    bool
+		expected string
+	}{
+		{"jira", "feature/JIRA-123-login", false, "JIRA-123"},
+		{"linear", "alice/eng-456-fix-crash", false, "eng-456"},
+		{"linear with beads initialized", "eng-456-fix-crash", true, "eng-456"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.beads {
+				if err := os.MkdirAll(filepath.Join(dir, ".beads"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mock := &MockRunner{
+				RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+					if args[0] == "show" {
+						t.Error("bd show should not run for external issues")
+					}
+					return "", nil
+				},
+			}
+
+			task := DetectCurrentTask(dir, tc.branch, tracker, mock)
+			if task.ID != tc.expected {
+				t.Errorf("expected ID %q, got %q", tc.expected, task.ID)
+			}
+			want := fmt.Sprintf("[%s](https://tracker.example.com/browse/%s)", tc.expected, tc.expected)
+			if task.Ref() != want {
+				t.Errorf("expected %q, got %q", want, task.Ref())
+			}
+		})
+	}
+
+	t.Run("bead IDs take precedence", func(t *testing.T) {
+		task := DetectCurrentTask(t.TempDir(), "feature/bd-12-login", tracker, &MockRunner{})
+		if task.ID != "bd-12" || task.URL != "" {
+			t.Errorf("expected bead bd-12 without a link, got %+v", task)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		if _, err := NewIssueTracker("[", ""); err == nil {
+			t.Error("expected error for invalid pattern")
+		}
+	})
+}
+
+func TestLimitTasks(t *testing.T) {
+	t.Run("trims JSON task lists", func(t *testing.T) {
+		output := `{"triage":{"recommendations":[{"id":"bd-1"},{"id":"bd-2"},{"id":"bd-3"},{"id":"bd-4"}],"quick_wins":[{"id":"bd-5"}]}}`
+
+		result := LimitTasks(output, 2)
+
+		if !strings.Contains(result, "bd-1") || !strings.Contains(result, "bd-2") {
+			t.Errorf("expected top tasks to be kept, got: %s", result)
+		}
+		if strings.Contains(result, "bd-3") || strings.Contains(result, "bd-4") {
+			t.Errorf("expected lower tasks to be trimmed, got: %s", result)
+		}
+		if !strings.Contains(result, "bd-5") {
+			t.Errorf("expected short lists to be kept, got: %s", result)
+		}
+		if !strings.Contains(result, "2 more entries omitted") {
+			t.Errorf("expected omission note, got: %s", result)
+		}
+	})
+
+	t.Run("leaves small JSON untouched", func(t *testing.T) {
+		output := `{"recommendations":[{"id":"bd-1"}]}`
+		if result := LimitTasks(output, 5); result != output {
+			t.Errorf("expected unchanged output, got: %s", result)
+		}
+	})
+
+	t.Run("trims text task lines", func(t *testing.T) {
+		output := "Ready work:\n1. bd-1 First\n2. bd-2 Second\n3. bd-3 Third\n4. bd-4 Fourth"
+
+		result := LimitTasks(output, 2)
+
+		if !strings.Contains(result, "bd-2 Second") {
+			t.Errorf("expected second task to be kept, got: %s", result)
+		}
+		if strings.Contains(result, "bd-3") {
+			t.Errorf("expected third task to be trimmed, got: %s", result)
+		}
+		if !strings.Contains(result, "2 more tasks omitted") {
+			t.Errorf("expected omission note, got: %s", result)
+		}
+	})
+
+	t.Run("keeps JSON key order and other arrays", func(t *testing.T) {
+		output := "{\n  \"zeta\": [1, 2, 3],\n  \"recommendations\": [\n    {\"id\": \"bd-1\", \"labels\": [\"a\", \"b\", \"c\"]},\n    {\"id\": \"bd-2\"},\n    {\"id\": \"bd-3\"}\n  ],\n  \"alpha\": true\n}"
+
+		result := LimitTasks(output, 1)
+
+		expected := "{\n  \"zeta\": [1, 2, 3],\n  \"recommendations\": [\n    {\"id\": \"bd-1\", \"labels\": [\"a\", \"b\", \"c\"]}\n  ],\n  \"alpha\": true\n}" +
+			"\n\n... (2 more entries omitted, showing top 1 per list)"
+		if result != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+		}
+	})
+
+	t.Run("trims a top-level JSON array", func(t *testing.T) {
+		output := `[{"id":"bd-1"},{"id":"bd-2"},{"id":"bd-3"}]`
+
+		result := LimitTasks(output, 2)
+
+		if !strings.HasPrefix(result, `[{"id":"bd-1"},{"id":"bd-2"}]`) || strings.Contains(result, "bd-3") {
+			t.Errorf("expected first two tasks, got: %s", result)
+		}
+	})
+
+	t.Run("keeps task details and ignores ID mentions", func(t *testing.T) {
+		output := strings.Join([]string{
+			"Ready work:",
+			"1. [P1] bd-1: First",
+			"   Blocked by: bd-3",
+			"   Assignee: alice",
+			"2. [P2] bd-2: Second",
+			"Blocked by: bd-1",
+			"3. [P3] bd-3: Third",
+			"   Assignee: bob",
+		}, "\n")
+
+		result := LimitTasks(output, 2)
+
+		expected := strings.Join([]string{
+			"Ready work:",
+			"1. [P1] bd-1: First",
+			"   Blocked by: bd-3",
+			"   Assignee: alice",
+			"2. [P2] bd-2: Second",
+			"Blocked by: bd-1",
+			"... (1 more tasks omitted, showing top 2)",
+		}, "\n")
+		if result != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+		}
+	})
+
+	t.Run("zero disables task limiting", func(t *testing.T) {
+		output := "bd-1 a\nbd-2 b\nbd-3 c"
+		if result := LimitTasks(output, 0); result != output {
+			t.Errorf("expected unchanged output, got: %s", result)
+		}
+	})
+
+	t.Run("caps oversized output", func(t *testing.T) {
+		output := strings.Repeat("some very long triage line without ids\n", 2000)
+
+		result := LimitTasks(output, 0)
+
+		if len(result) > maxListingBytes+100 {
+			t.Errorf("expected output to be capped, got %d bytes", len(result))
+		}
+		if !strings.Contains(result, "output truncated") {
+			t.Error("expected truncation note")
+		}
+	})
+}
+
+func TestGetTriage(t *testing.T) {
+	t.Run("prefers bv", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return command + " output", nil
+			},
+		}
+
+		triage := GetTriage("/repo", mock, 0)
+
+		if triage.Source != "bv" || triage.Output != "bv output" || triage.Warning != "" {
+			t.Errorf("unexpected triage: %+v", triage)
+		}
+	})
+
+	t.Run("falls back to bd ready", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bv" {
+					return "", errors.New("not found")
+				}
+				return "bd-1 task", nil
+			},
+		}
+
+		triage := GetTriage("/repo", mock, 0)
+
+		if triage.Source != "bd" || triage.Warning != "" {
+			t.Errorf("unexpected triage: %+v", triage)
+		}
+	})
+
+	t.Run("timeouts are distinguished from empty results", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bv" {
+					return "", context.DeadlineExceeded
+				}
+				return "", &runner.CommandError{Err: fmt.Errorf("bd: %w after 10s", runner.ErrTimeout)}
+			},
+		}
+
+		triage := GetTriage("/repo", mock, 0)
+
+		if triage.Output != "" {
+			t.Errorf("expected no output, got %q", triage.Output)
+		}
+		for _, tool := range []string{"bv", "bd"} {
+			if !strings.Contains(triage.Warning, "`"+tool+"` timed out after 10s") {
+				t.Errorf("expected %s timeout warning, got %q", tool, triage.Warning)
+			}
+		}
+	})
+
+	t.Run("Err explains when every tool failed", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("executable file not found")
+			},
+		}
+
+		err := GetTriage("/repo", mock, 0).Err()
+		if err == nil || !strings.Contains(err.Error(), "bv --robot-triage") || !strings.Contains(err.Error(), "bd ready") {
+			t.Errorf("expected both failures in error, got %v", err)
+		}
+	})
+
+	t.Run("Err is nil when a tool ran but listed nothing", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bv" {
+					return "", errors.New("executable file not found")
+				}
+				return "", nil
+			},
+		}
+
+		if err := GetTriage("/repo", mock, 0).Err(); err != nil {
+			t.Errorf("expected no error for an empty listing, got %v", err)
+		}
+	})
+
+	t.Run("ordinary failures produce no warning", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("exit status 1")
+			},
+		}
+
+		if triage := GetTriage("/repo", mock, 0); triage.Warning != "" {
+			t.Errorf("expected no warning, got %q", triage.Warning)
+		}
+	})
+}
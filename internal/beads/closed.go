@@ -0,0 +1,99 @@
+package beads
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// MaxRecentClosed caps how many closed tasks RecentlyClosed returns
+const MaxRecentClosed = 5
+
+// ClosedTask is a closed bead. ClosedAt is nil when bd doesn't record
+// a close time or records one in a format we can't parse.
+type ClosedTask struct {
+	ID       string
+	Title    string
+	ClosedAt *time.Time
+}
+
+// RecentlyClosed returns up to limit closed tasks, most recently closed
+// first. Tasks without a close time follow the dated ones in bd's order.
+func RecentlyClosed(dir string, limit int, r runner.CommandRunner) ([]ClosedTask, error) {
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "list", "--status", "closed", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("bd list: %w", err)
+	}
+	if limit <= 0 || limit > MaxRecentClosed {
+		limit = MaxRecentClosed
+	}
+	return parseClosed(output, limit)
+}
+
+// parseClosed reads `bd list --json` output and keeps the newest tasks.
+func parseClosed(output string, limit int) ([]ClosedTask, error) {
+	var all []struct {
+		ID       string `json:"id"`
+		Title    string `json:"title"`
+		ClosedAt string `json:"closed_at"`
+	}
+	if output != "" {
+		if err := json.Unmarshal([]byte(output), &all); err != nil {
+			return nil, fmt.Errorf("parsing bd list: %w", err)
+		}
+	}
+
+	tasks := make([]ClosedTask, 0, len(all))
+	for _, t := range all {
+		task := ClosedTask{ID: t.ID, Title: t.Title}
+		if closedAt, err := time.Parse(time.RFC3339, t.ClosedAt); err == nil {
+			task.ClosedAt = &closedAt
+		}
+		tasks = append(tasks, task)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i].ClosedAt, tasks[j].ClosedAt
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.After(*b)
+	})
+
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks, nil
+}
+
+// maxClosedChecks caps how many listed tasks are checked with bd show
+// when looking for the first open one.
+const maxClosedChecks = 5
+
+// FirstOpenTask returns the first task in the listing that bd show doesn't
+// report as closed, guarding against a stale triage cache, along with the
+// closed tasks it skipped. Tasks bd show can't find are assumed open.
+func FirstOpenTask(dir string, listing string, r runner.CommandRunner) (string, []string) {
+	var skipped []string
+	seen := map[string]bool{}
+	for _, id := range IDPattern.FindAllString(listing, -1) {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if len(seen) > maxClosedChecks {
+			return id, skipped
+		}
+		output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "show", id)
+		if err != nil || !IsClosedStatus(ExtractStatusFromShow(output)) {
+			return id, skipped
+		}
+		slog.Warn("recommended task is closed", "id", id)
+		skipped = append(skipped, id)
+	}
+	return "", skipped
+}
@@ -0,0 +1,102 @@
+package beads
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecentlyClosed(t *testing.T) {
+	t.Run("newest first, undated last, capped", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return `[
+					{"id":"bd-1","title":"Old","closed_at":"2026-10-01T09:00:00Z"},
+					{"id":"bd-2","title":"Undated"},
+					{"id":"bd-3","title":"Newest","closed_at":"2026-10-15T09:00:00Z"},
+					{"id":"bd-4","title":"Odd format","closed_at":"last tuesday"},
+					{"id":"bd-5","title":"Middle","closed_at":"2026-10-10T09:00:00+02:00"},
+					{"id":"bd-6","title":"Older","closed_at":"2026-09-01T09:00:00Z"}
+				]`, nil
+			},
+		}
+
+		tasks, err := RecentlyClosed("/repo", 10, mock)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []string{"bd-3", "bd-5", "bd-1", "bd-6", "bd-2"}
+		if len(tasks) != len(expected) {
+			t.Fatalf("expected %d tasks, got %+v", len(expected), tasks)
+		}
+		for i, id := range expected {
+			if tasks[i].ID != id {
+				t.Errorf("position %d: expected %s, got %s", i, id, tasks[i].ID)
+			}
+		}
+		if tasks[4].ClosedAt != nil {
+			t.Error("expected undated task to have no close time")
+		}
+	})
+
+	t.Run("no closed tasks", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "[]", nil
+			},
+		}
+		tasks, err := RecentlyClosed("/repo", 3, mock)
+		if err != nil || len(tasks) != 0 {
+			t.Errorf("expected no tasks, got %+v (err %v)", tasks, err)
+		}
+	})
+
+	t.Run("bd failure", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("exit status 1")
+			},
+		}
+		if _, err := RecentlyClosed("/repo", 3, mock); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestFirstOpenTask(t *testing.T) {
+	// showMock reports the given tasks as closed and everything else as open
+	showMock := func(closed ...string) *MockRunner {
+		return &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				for _, id := range closed {
+					if args[1] == id {
+						return "Status: closed", nil
+					}
+				}
+				return "Status: open", nil
+			},
+		}
+	}
+	listing := "1. [P1] bd-12 Done already\n2. [P2] bd-13 Still open\n"
+
+	t.Run("skips closed tasks", func(t *testing.T) {
+		id, skipped := FirstOpenTask("/repo", listing, showMock("bd-12"))
+		if id != "bd-13" || len(skipped) != 1 || skipped[0] != "bd-12" {
+			t.Errorf("expected bd-13 after skipping bd-12, got %q %v", id, skipped)
+		}
+	})
+
+	t.Run("all closed", func(t *testing.T) {
+		id, skipped := FirstOpenTask("/repo", listing, showMock("bd-12", "bd-13"))
+		if id != "" || len(skipped) != 2 {
+			t.Errorf("expected no open task, got %q %v", id, skipped)
+		}
+	})
+
+	t.Run("empty listing", func(t *testing.T) {
+		if id, _ := FirstOpenTask("/repo", "", showMock()); id != "" {
+			t.Errorf("expected no task, got %q", id)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package beads
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// ErrCommentsUnsupported is returned when the installed bd has no comment command
+var ErrCommentsUnsupported = errors.New("this bd version does not support comments (try upgrading bd)")
+
+// AddComment adds a comment to a bead with `bd comment add`.
+func AddComment(dir string, id string, text string, r runner.CommandRunner) error {
+	_, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "comment", "add", id, text)
+	if err == nil {
+		return nil
+	}
+
+	output := runner.ErrorOutput(err)
+	if strings.Contains(strings.ToLower(output), "unknown command") {
+		return ErrCommentsUnsupported
+	}
+	if output != "" {
+		return fmt.Errorf("commenting on %s: %w\n%s", id, err, output)
+	}
+	return fmt.Errorf("commenting on %s: %w", id, err)
+}
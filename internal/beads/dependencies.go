@@ -0,0 +1,104 @@
+package beads
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// Dependency is a task linked to another by a dependency edge.
+type Dependency struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Type   string `json:"dependency_type"`
+}
+
+// Dependencies holds the tasks a task depends on and the tasks that depend on it.
+type Dependencies struct {
+	Status     string       `json:"status"` // Status of the task itself
+	DependsOn  []Dependency `json:"dependencies"`
+	Dependents []Dependency `json:"dependents"`
+}
+
+// GetDependencies reads the dependency edges of a task from `bd show --json`.
+func GetDependencies(dir string, id string, r runner.CommandRunner) (Dependencies, error) {
+	output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "show", id, "--json")
+	if err != nil {
+		return Dependencies{}, fmt.Errorf("bd show %s: %w", id, err)
+	}
+
+	// bd show --json prints an array when given IDs, an object in older versions
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "[") {
+		var all []Dependencies
+		if err := json.Unmarshal([]byte(trimmed), &all); err != nil {
+			return Dependencies{}, fmt.Errorf("parsing bd show %s: %w", id, err)
+		}
+		if len(all) == 0 {
+			return Dependencies{}, fmt.Errorf("bd show %s: no such task", id)
+		}
+		return all[0], nil
+	}
+
+	var deps Dependencies
+	if err := json.Unmarshal([]byte(trimmed), &deps); err != nil {
+		return Dependencies{}, fmt.Errorf("parsing bd show %s: %w", id, err)
+	}
+	return deps, nil
+}
+
+// Unblocks returns the open tasks blocked by id whose other blockers are all
+// closed, i.e. the tasks that become ready once id is closed.
+func Unblocks(dir string, id string, r runner.CommandRunner) ([]Dependency, error) {
+	deps, err := GetDependencies(dir, id, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var ready []Dependency
+	for _, dependent := range deps.Dependents {
+		if !isBlocking(dependent.Type) || dependent.Status == "closed" {
+			continue
+		}
+
+		theirs, err := GetDependencies(dir, dependent.ID, r)
+		if err != nil {
+			continue
+		}
+		if blockedOnlyBy(theirs.DependsOn, id) {
+			ready = append(ready, dependent)
+		}
+	}
+	return ready, nil
+}
+
+// OpenBlockers returns the tasks that block this one and are not yet closed.
+func (d Dependencies) OpenBlockers() []Dependency {
+	var open []Dependency
+	for _, dep := range d.DependsOn {
+		if isBlocking(dep.Type) && dep.Status != "closed" {
+			open = append(open, dep)
+		}
+	}
+	return open
+}
+
+// blockedOnlyBy reports whether id is the only open blocker in deps.
+func blockedOnlyBy(deps []Dependency, id string) bool {
+	for _, d := range deps {
+		if d.ID != id && isBlocking(d.Type) && d.Status != "closed" {
+			return false
+		}
+	}
+	return true
+}
+
+// isBlocking reports whether a dependency type prevents work from starting.
+// Older bd versions omit the type; those edges are treated as blocking.
+func isBlocking(depType string) bool {
+	return depType == "" || depType == "blocks"
+}
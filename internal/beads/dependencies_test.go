@@ -0,0 +1,91 @@
+package beads
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// showRunner answers `bd show <id> --json` from a map of canned outputs.
+func showRunner(outputs map[string]string) *MockRunner {
+	return &MockRunner{
+		RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			if command == "bd" && args[0] == "show" {
+				if out, ok := outputs[args[1]]; ok {
+					return out, nil
+				}
+			}
+			return "", errors.New("exit status 1")
+		},
+	}
+}
+
+func TestGetDependencies(t *testing.T) {
+	t.Run("array output", func(t *testing.T) {
+		mock := showRunner(map[string]string{
+			"bd-12": `[{"id":"bd-12","dependents":[{"id":"bd-15","title":"Use API","status":"open","dependency_type":"blocks"}]}]`,
+		})
+
+		deps, err := GetDependencies("/repo", "bd-12", mock)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.Dependents) != 1 || deps.Dependents[0].ID != "bd-15" || deps.Dependents[0].Title != "Use API" {
+			t.Errorf("unexpected dependents: %+v", deps.Dependents)
+		}
+	})
+
+	t.Run("object output", func(t *testing.T) {
+		mock := showRunner(map[string]string{
+			"bd-12": `{"id":"bd-12","dependencies":[{"id":"bd-3","status":"closed"}]}`,
+		})
+
+		deps, err := GetDependencies("/repo", "bd-12", mock)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deps.DependsOn) != 1 || deps.DependsOn[0].ID != "bd-3" {
+			t.Errorf("unexpected dependencies: %+v", deps.DependsOn)
+		}
+	})
+
+	t.Run("missing data is an error", func(t *testing.T) {
+		for name, output := range map[string]string{"empty array": "[]", "not JSON": "Title: x"} {
+			mock := showRunner(map[string]string{"bd-12": output})
+			if _, err := GetDependencies("/repo", "bd-12", mock); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+		if _, err := GetDependencies("/repo", "bd-99", showRunner(nil)); err == nil {
+			t.Error("expected error when bd fails")
+		}
+	})
+}
+
+func TestUnblocks(t *testing.T) {
+	mock := showRunner(map[string]string{
+		"bd-12": `[{"id":"bd-12","dependents":[
+			{"id":"bd-15","title":"Only waits on bd-12","status":"open","dependency_type":"blocks"},
+			{"id":"bd-18","title":"Other blocker closed","status":"open","dependency_type":"blocks"},
+			{"id":"bd-20","title":"Still waits on bd-13","status":"open","dependency_type":"blocks"},
+			{"id":"bd-21","title":"Already done","status":"closed","dependency_type":"blocks"},
+			{"id":"bd-22","title":"Just related","status":"open","dependency_type":"related"}
+		]}]`,
+		"bd-15": `[{"id":"bd-15","dependencies":[{"id":"bd-12","status":"in_progress","dependency_type":"blocks"}]}]`,
+		"bd-18": `[{"id":"bd-18","dependencies":[{"id":"bd-12","status":"in_progress"},{"id":"bd-9","status":"closed"}]}]`,
+		"bd-20": `[{"id":"bd-20","dependencies":[{"id":"bd-12","status":"in_progress"},{"id":"bd-13","status":"open","dependency_type":"blocks"}]}]`,
+	})
+
+	ready, err := Unblocks("/repo", "bd-12", mock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, d := range ready {
+		ids = append(ids, d.ID)
+	}
+	if len(ids) != 2 || ids[0] != "bd-15" || ids[1] != "bd-18" {
+		t.Errorf("expected [bd-15 bd-18], got %v", ids)
+	}
+}
@@ -0,0 +1,92 @@
+package beads
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	priorityPattern  = regexp.MustCompile(`\[P(\d)\]`)
+	listNumberPrefix = regexp.MustCompile(`^(\s*)\d+\.(\s)`)
+)
+
+// readyTask is one task from `bd ready` text output: its task line and the
+// detail lines that follow it.
+type readyTask struct {
+	lines    []string
+	id       int // Numeric part of the bead ID
+	priority int // 0 is highest; -1 when the line has none
+}
+
+// SortByPriority reorders the tasks in `bd ready` text output by priority,
+// highest (P0) first, then by bead ID, so that LimitTasks keeps the most
+// important ones. Tasks without a priority keep their order after the rest.
+// Only task lines (see taskLinePattern) start a task, so detail lines that
+// mention other beads stay with their task. Lines before the first task are
+// left in place and numbered lists are renumbered. Output with no task lines
+// is returned unchanged.
+func SortByPriority(output string) string {
+	lines := strings.Split(output, "\n")
+
+	var header []string
+	var tasks []readyTask
+	for _, line := range lines {
+		match := taskLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			if len(tasks) == 0 {
+				header = append(header, line)
+			} else {
+				tasks[len(tasks)-1].lines = append(tasks[len(tasks)-1].lines, line)
+			}
+			continue
+		}
+		id, _ := strconv.Atoi(match[2])
+		tasks = append(tasks, readyTask{lines: []string{line}, id: id, priority: parsePriority(line)})
+	}
+	if len(tasks) < 2 {
+		return output
+	}
+
+	// A trailing blank line belongs to the output, not the last task
+	last := &tasks[len(tasks)-1]
+	var footer []string
+	for len(last.lines) > 1 && strings.TrimSpace(last.lines[len(last.lines)-1]) == "" {
+		footer = append([]string{last.lines[len(last.lines)-1]}, footer...)
+		last.lines = last.lines[:len(last.lines)-1]
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if (a.priority < 0) != (b.priority < 0) {
+			return b.priority < 0
+		}
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		if a.priority < 0 {
+			return false
+		}
+		return a.id < b.id
+	})
+
+	sorted := append([]string{}, header...)
+	for i, task := range tasks {
+		first := listNumberPrefix.ReplaceAllString(task.lines[0], fmt.Sprintf("${1}%d.${2}", i+1))
+		sorted = append(sorted, first)
+		sorted = append(sorted, task.lines[1:]...)
+	}
+	return strings.Join(append(sorted, footer...), "\n")
+}
+
+// parsePriority reads a [P0]-[P4] priority from a task line, or -1 if there is none.
+func parsePriority(line string) int {
+	match := priorityPattern.FindStringSubmatch(line)
+	if match == nil {
+		return -1
+	}
+	p, _ := strconv.Atoi(match[1])
+	return p
+}
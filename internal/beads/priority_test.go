@@ -0,0 +1,103 @@
+package beads
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortByPriority(t *testing.T) {
+	t.Run("out-of-order priorities", func(t *testing.T) {
+		input := strings.Join([]string{
+			"📋 Ready work (4 issues with no blockers):",
+			"",
+			"1. [P2] bd-7: Polish docs",
+			"   Assignee: alice",
+			"2. [P0] bd-9: Fix crash",
+			"3. [P1] bd-12: Add login",
+			"4. [P0] bd-3: Patch CVE",
+			"",
+		}, "\n")
+
+		expected := strings.Join([]string{
+			"📋 Ready work (4 issues with no blockers):",
+			"",
+			"1. [P0] bd-3: Patch CVE",
+			"2. [P0] bd-9: Fix crash",
+			"3. [P1] bd-12: Add login",
+			"4. [P2] bd-7: Polish docs",
+			"   Assignee: alice",
+			"",
+		}, "\n")
+
+		if got := SortByPriority(input); got != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("tasks without priority keep their order last", func(t *testing.T) {
+		input := "bd-5 Unranked\nbd-2 [P3] Low\nbd-4 Also unranked\nbd-8 [P1] High"
+		expected := "bd-8 [P1] High\nbd-2 [P3] Low\nbd-5 Unranked\nbd-4 Also unranked"
+		if got := SortByPriority(input); got != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("detail lines mentioning beads stay with their task", func(t *testing.T) {
+		input := strings.Join([]string{
+			"1. [P2] bd-7: Polish docs",
+			"   Blocked by: bd-1",
+			"Depends on bd-4",
+			"2. [P0] bd-9: Fix crash",
+		}, "\n")
+
+		expected := strings.Join([]string{
+			"1. [P0] bd-9: Fix crash",
+			"2. [P2] bd-7: Polish docs",
+			"   Blocked by: bd-1",
+			"Depends on bd-4",
+		}, "\n")
+
+		if got := SortByPriority(input); got != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("no tasks", func(t *testing.T) {
+		input := "No ready work found"
+		if got := SortByPriority(input); got != input {
+			t.Errorf("expected unchanged output, got %q", got)
+		}
+	})
+}
+
+func TestGetTriageSortsReady(t *testing.T) {
+	t.Run("limit keeps the highest priorities from bd ready", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" {
+					return "1. [P3] bd-1: Later\n2. [P1] bd-2: Soon\n3. [P0] bd-3: Now", nil
+				}
+				return "", nil
+			},
+		}
+
+		triage := GetTriage("/repo", mock, 2)
+		if !strings.HasPrefix(triage.Output, "1. [P0] bd-3: Now\n2. [P1] bd-2: Soon\n") || strings.Contains(triage.Output, "bd-1") {
+			t.Errorf("expected top two priorities, got:\n%s", triage.Output)
+		}
+	})
+
+	t.Run("bv ranking is respected", func(t *testing.T) {
+		listing := "[P3] bd-1 Ranked first by bv\n[P0] bd-2 Second"
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return listing, nil
+			},
+		}
+
+		if triage := GetTriage("/repo", mock, 0); triage.Output != listing {
+			t.Errorf("expected bv order unchanged, got:\n%s", triage.Output)
+		}
+	})
+}
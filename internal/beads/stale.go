@@ -0,0 +1,111 @@
+package beads
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// DefaultStaleAfter is how long a task may stay in progress before it's flagged
+const DefaultStaleAfter = 72 * time.Hour
+
+// maxStaleChecks caps how many in-progress tasks are looked up with bd show
+const maxStaleChecks = 5
+
+// showTimeLayouts are the timestamp formats accepted from `bd show`
+var showTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ExtractUpdatedFromShow returns when a bead was last updated according to
+// `bd show` output, falling back to its creation time. Returns false if
+// neither is present or parseable.
+func ExtractUpdatedFromShow(output string) (time.Time, bool) {
+	for _, field := range []string{"Updated:", "Created:"} {
+		for _, line := range strings.Split(output, "\n") {
+			if !strings.HasPrefix(line, field) {
+				continue
+			}
+			value := strings.TrimSpace(strings.TrimPrefix(line, field))
+			for _, layout := range showTimeLayouts {
+				if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// StaleWarning flags a task that has been in progress since since for at
+// least threshold. Returns empty string for fresher tasks.
+func StaleWarning(id string, since, now time.Time, threshold time.Duration) string {
+	if threshold <= 0 {
+		threshold = DefaultStaleAfter
+	}
+	elapsed := now.Sub(since)
+	if elapsed < threshold {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ %s has been in progress for %s — consider whether it's stalled", id, formatAge(elapsed))
+}
+
+// formatAge describes a duration in whole days, or hours when under a day.
+func formatAge(d time.Duration) string {
+	if days := int(d / (24 * time.Hour)); days >= 1 {
+		if days == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", days)
+	}
+	hours := int(d / time.Hour)
+	if hours == 1 {
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d hours", hours)
+}
+
+// LastUpdated looks up when a task was last updated with bd show.
+// Returns false when bd fails or reports no usable timestamp.
+func LastUpdated(dir string, id string, r runner.CommandRunner) (time.Time, bool) {
+	output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "show", id)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ExtractUpdatedFromShow(output)
+}
+
+// StaleInProgress returns warnings for in-progress tasks that haven't been
+// updated within threshold. Returns nil if bd fails.
+func StaleInProgress(dir string, threshold time.Duration, r runner.CommandRunner) []string {
+	output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "list", "--status", "in_progress")
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	checked := 0
+	for _, line := range strings.Split(output, "\n") {
+		id, _ := ParseListLine(line)
+		if id == "" {
+			continue
+		}
+		if checked == maxStaleChecks {
+			break
+		}
+		checked++
+		since, ok := LastUpdated(dir, id, r)
+		if !ok {
+			continue
+		}
+		if warning := StaleWarning(id, since, time.Now(), threshold); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,86 @@
+package beads
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractUpdatedFromShow(t *testing.T) {
+	t.Run("prefers updated over created", func(t *testing.T) {
+		output := "Title: Fix login\nStatus: in_progress\nCreated: 2026-10-01 09:00\nUpdated: 2026-10-10T14:30:00Z\n"
+		got, ok := ExtractUpdatedFromShow(output)
+		if !ok || !got.Equal(time.Date(2026, 10, 10, 14, 30, 0, 0, time.UTC)) {
+			t.Errorf("expected update time, got %v (ok %v)", got, ok)
+		}
+	})
+
+	t.Run("falls back to created", func(t *testing.T) {
+		got, ok := ExtractUpdatedFromShow("Title: Fix login\nCreated: 2026-10-01\n")
+		if !ok || got.Day() != 1 || got.Month() != time.October {
+			t.Errorf("expected creation date, got %v (ok %v)", got, ok)
+		}
+	})
+
+	t.Run("no usable timestamp", func(t *testing.T) {
+		if _, ok := ExtractUpdatedFromShow("Title: Fix login\nUpdated: last tuesday\n"); ok {
+			t.Error("expected no timestamp")
+		}
+	})
+}
+
+func TestStaleWarning(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		since     time.Time
+		threshold time.Duration
+		expected  string
+	}{
+		{"fresh task", now.Add(-2 * 24 * time.Hour), 0, ""},
+		{"past default threshold", now.Add(-6*24*time.Hour - time.Hour), 0, "bd-12 has been in progress for 6 days"},
+		{"custom threshold in hours", now.Add(-5 * time.Hour), 4 * time.Hour, "bd-12 has been in progress for 5 hours"},
+		{"exactly one day", now.Add(-24 * time.Hour), 24 * time.Hour, "in progress for 1 day "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StaleWarning("bd-12", tt.since, now, tt.threshold)
+			if tt.expected == "" {
+				if got != "" {
+					t.Errorf("expected no warning, got %q", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.expected) {
+				t.Errorf("expected %q in %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStaleInProgress(t *testing.T) {
+	old := time.Now().Add(-10 * 24 * time.Hour).Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	mock := &MockRunner{
+		RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			if args[0] == "list" {
+				return "bd-1  Old work  [in_progress]\nbd-2  Recent work  [in_progress]\nbd-3  Undated  [in_progress]", nil
+			}
+			switch args[1] {
+			case "bd-1":
+				return "Title: Old work\nUpdated: " + old, nil
+			case "bd-2":
+				return "Title: Recent work\nUpdated: " + recent, nil
+			}
+			return "Title: Undated", nil
+		},
+	}
+
+	warnings := StaleInProgress("/repo", 0, mock)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "bd-1 has been in progress for 10 days") {
+		t.Errorf("expected only bd-1 flagged, got %v", warnings)
+	}
+}
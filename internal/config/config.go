@@ -0,0 +1,121 @@
+// Package config loads per-project vibes configuration from .vibes.yaml.
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the project configuration file.
+const FileName = ".vibes.yaml"
+
+// Config holds project-level settings shared by vibes commands.
+type Config struct {
+	TemplateVars   map[string]string       `yaml:"template_vars"`
+	MaxTasks       *int                    `yaml:"max_tasks"` // nil when unset
+	TestCommand    string                  `yaml:"test_command"`
+	Ecosystem      string                  `yaml:"primary_ecosystem"`
+	SectionOrder   string                  `yaml:"section_order"`
+	WIPPatterns    []string                `yaml:"wip_patterns"`
+	Record         bool                    `yaml:"record"`             // Record next/ralph targets in .vibes/history.jsonl
+	Model          string                  `yaml:"model"`              // Default model profile
+	Models         map[string]ModelProfile `yaml:"models"`             // User-defined profiles, overriding built-ins
+	IssuePattern   string                  `yaml:"issue_pattern"`      // External tracker IDs in branch names, e.g. [A-Z]+-\d+
+	IssueURL       string                  `yaml:"issue_url_template"` // Issue link with an {id} placeholder
+	NotifyCommand  string                  `yaml:"notify_command"`     // Shell command run by --notify, with title and message as $1 and $2
+	NotifyWebhook  string                  `yaml:"notify_webhook"`     // URL that --notify POSTs a JSON message to
+	ProjectKey     string                  `yaml:"project_key"`        // Agent Mail project key (defaults to the directory name)
+	StaleDays      int                     `yaml:"stale_days"`         // Days in progress before a task is flagged as possibly stalled (0 = default)
+	ReservationTTL int                     `yaml:"reservation_ttl"`    // Seconds in file reservation snippets (0 = default)
+}
+
+// ModelProfile tunes prompt defaults for a particular model.
+// Unset fields leave the command's defaults alone.
+type ModelProfile struct {
+	Verbose      *bool  `yaml:"verbose"`
+	MaxTasks     *int   `yaml:"max_tasks"`
+	SectionOrder string `yaml:"section_order"`
+}
+
+// BuiltinModels are the profiles available without configuration.
+var BuiltinModels = map[string]ModelProfile{
+	"haiku":  {Verbose: boolPtr(false), MaxTasks: intPtr(3)},
+	"sonnet": {},
+	"opus":   {Verbose: boolPtr(true)},
+	"gpt-4":  {MaxTasks: intPtr(5), SectionOrder: "protocol-first"},
+}
+
+// Profile returns the named model profile, preferring user-defined profiles over built-ins.
+func (c *Config) Profile(name string) (ModelProfile, error) {
+	if p, ok := c.Models[name]; ok {
+		return p, nil
+	}
+	if p, ok := BuiltinModels[strings.ToLower(name)]; ok {
+		return p, nil
+	}
+
+	var known []string
+	for n := range BuiltinModels {
+		known = append(known, n)
+	}
+	for n := range c.Models {
+		known = append(known, n)
+	}
+	sort.Strings(known)
+	return ModelProfile{}, fmt.Errorf("unknown model %q (expected one of: %s)", name, strings.Join(known, ", "))
+}
+
+// ApplyModel layers a model profile's settings over the project config.
+func (c *Config) ApplyModel(p ModelProfile) {
+	if p.MaxTasks != nil {
+		c.MaxTasks = p.MaxTasks
+	}
+	if p.SectionOrder != "" {
+		c.SectionOrder = p.SectionOrder
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+func intPtr(n int) *int    { return &n }
+
+// Load reads .vibes.yaml from the given directory.
+// Returns an empty config if the file does not exist.
+func Load(dir string) (*Config, error) {
+	path := filepath.Join(dir, FileName)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return parse(path, content)
+}
+
+// LoadFile reads configuration from an explicit path. Unlike Load, a missing
+// file is an error.
+func LoadFile(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	return parse(path, content)
+}
+
+// parse decodes the YAML content of the config file at path.
+func parse(path string, content []byte) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	slog.Debug("loaded config", "path", path)
+
+	return cfg, nil
+}
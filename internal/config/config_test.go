@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("missing file returns empty config", func(t *testing.T) {
+		cfg, err := Load(t.TempDir())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cfg.TemplateVars) != 0 {
+			t.Errorf("expected no template vars, got %v", cfg.TemplateVars)
+		}
+	})
+
+	t.Run("reads template vars", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		content := "template_vars:\n  project_key: acme-api\n  docs_url: https://wiki.example.com\n"
+		if err := os.WriteFile(filepath.Join(tmpDir, FileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := Load(tmpDir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.TemplateVars["project_key"] != "acme-api" {
+			t.Errorf("expected project_key 'acme-api', got %q", cfg.TemplateVars["project_key"])
+		}
+		if cfg.TemplateVars["docs_url"] != "https://wiki.example.com" {
+			t.Errorf("expected docs_url, got %q", cfg.TemplateVars["docs_url"])
+		}
+	})
+
+	t.Run("invalid yaml returns error", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tmpDir, FileName), []byte("template_vars: [unclosed"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Load(tmpDir); err == nil {
+			t.Error("expected error for invalid yaml")
+		}
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("reads any path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ci.yaml")
+		if err := os.WriteFile(path, []byte("test_command: make ci\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.TestCommand != "make ci" {
+			t.Errorf("expected test command from file, got %q", cfg.TestCommand)
+		}
+	})
+
+	t.Run("missing file is an error", func(t *testing.T) {
+		_, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+		if err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+			t.Errorf("expected error naming the file, got %v", err)
+		}
+	})
+
+	t.Run("invalid yaml names the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bad.yaml")
+		if err := os.WriteFile(path, []byte("max_tasks: [unclosed"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFile(path)
+		if err == nil || !strings.Contains(err.Error(), "parsing "+path) {
+			t.Errorf("expected parse error naming the file, got %v", err)
+		}
+	})
+}
+
+func TestProfile(t *testing.T) {
+	t.Run("built-in profile", func(t *testing.T) {
+		cfg := &Config{}
+		p, err := cfg.Profile("Haiku")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Verbose == nil || *p.Verbose || p.MaxTasks == nil || *p.MaxTasks != 3 {
+			t.Errorf("unexpected haiku profile: %+v", p)
+		}
+	})
+
+	t.Run("user profile from config overrides built-in", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		content := "models:\n  haiku:\n    max_tasks: 1\n  mymodel:\n    verbose: true\n    section_order: protocol-first\n"
+		if err := os.WriteFile(filepath.Join(tmpDir, FileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(tmpDir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		haiku, err := cfg.Profile("haiku")
+		if err != nil || haiku.MaxTasks == nil || *haiku.MaxTasks != 1 || haiku.Verbose != nil {
+			t.Errorf("expected user haiku profile, got %+v (err %v)", haiku, err)
+		}
+
+		mine, err := cfg.Profile("mymodel")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cfg.ApplyModel(mine)
+		if cfg.SectionOrder != "protocol-first" {
+			t.Errorf("expected section order from profile, got %q", cfg.SectionOrder)
+		}
+		if mine.Verbose == nil || !*mine.Verbose {
+			t.Error("expected verbose profile")
+		}
+	})
+
+	t.Run("unknown model lists choices", func(t *testing.T) {
+		cfg := &Config{}
+		_, err := cfg.Profile("llama")
+		if err == nil || !strings.Contains(err.Error(), "haiku") {
+			t.Errorf("expected error listing known models, got %v", err)
+		}
+	})
+}
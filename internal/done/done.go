@@ -2,22 +2,46 @@ package done
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/project"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/runner"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 // Options configures the done command behavior
 type Options struct {
-	Dir     string               // Target directory (defaults to cwd)
-	Verbose bool                 // Include full protocol details
-	Runner  runner.CommandRunner // Command runner (defaults to runner.Default)
+	Dir          string               // Target directory (defaults to cwd)
+	Verbose      bool                 // Include full protocol details
+	Fetch        bool                 // Fetch from remote before computing ahead/behind
+	Task         string               // Use this bead instead of detecting the current task
+	Verify       bool                 // Run the test command before suggesting completion
+	CheckID      bool                 // Warn if the commit email doesn't belong to the gh account
+	Dependencies bool                 // Show which tasks closing this one unblocks (always on with Verbose)
+	CheckWIP     bool                 // Scan the branch diff for TODO/debug markers (always on with Verbose)
+	WIPPatterns  []string             // Regexps for --check-wip (defaults to DefaultWIPPatterns)
+	Paths        git.PathMode         // How --check-wip file paths are displayed (defaults to repo-root)
+	Close        bool                 // Close the task in beads after checks pass
+	Force        bool                 // Close even if open tasks block it
+	TestCommand  string               // Test command override (defaults to auto-detection)
+	Ecosystem    project.Ecosystem    // Only verify this ecosystem (defaults to all detected)
+	ProjectKey   string               // Agent Mail project key for MCP snippets (defaults to the directory name)
+	TemplateVars map[string]string    // Custom variables for protocol templates
+	Output       prompt.Output        // Render or post as a PR comment
+	Tracker      *beads.IssueTracker  // External issue tracker for branches without a bead ID (nil = Beads only)
+	Runner       runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
+// Sections lists every section this command can produce, in output order.
+var Sections = []string{"Work Summary", "Recent Commits", "Unblocks", "Unfinished Work", "Verification", "Close Task", "Completion Protocol"}
+
 // Run executes the done command and returns the prompt to stdout
 func Run(opts Options) error {
 	dir := opts.Dir
@@ -33,59 +57,256 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
-
-	var out strings.Builder
+	r = git.NewCache(r)
 
 	// Header
 	projectName := filepath.Base(dir)
-	out.WriteString(fmt.Sprintf("# Complete Current Work in %s\n\n", projectName))
+	doc := prompt.New(fmt.Sprintf("Complete Current Work in %s", projectName), Sections...)
 
 	// Get current branch and work summary
 	branch := git.GetCurrentBranch(dir, r)
-	task := beads.DetectCurrentTask(dir, branch, r)
+	var task beads.TaskInfo
+	if opts.Task != "" {
+		var err error
+		if task, err = beads.GetTask(dir, opts.Task, branch, r); err != nil {
+			return err
+		}
+	} else {
+		task = beads.DetectCurrentTask(dir, branch, opts.Tracker, r)
+	}
 	task.ProjectName = projectName
+	if opts.ProjectKey != "" {
+		task.ProjectName = opts.ProjectKey
+	}
+	doc.Task = task.ID
 
-	out.WriteString("## Work Summary\n")
+	var summary strings.Builder
 	if branch != "" {
-		out.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
+		summary.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
 	}
 	if task.ID != "" {
 		if task.Title != "" {
-			out.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"\n", task.ID, task.Title))
+			summary.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"\n", task.ID, task.Title))
 		} else {
-			out.WriteString(fmt.Sprintf("- **Task**: %s\n", task.ID))
+			summary.WriteString(fmt.Sprintf("- **Task**: %s\n", task.Ref()))
 		}
 	}
 
 	// Commits on this branch
 	commits := git.GetBranchCommits(dir, branch, r)
 	if commits != "" {
-		out.WriteString(fmt.Sprintf("- **Commits on branch**: %d commits\n", git.CountLines(commits)))
+		summary.WriteString(fmt.Sprintf("- **Commits on branch**: %d commits\n", git.CountLines(commits)))
 	}
 
 	// Working tree status
 	status := git.GetWorkingTreeStatus(dir, r)
 	if status != "" {
-		out.WriteString(fmt.Sprintf("- **Working tree**: %s\n", status))
+		summary.WriteString(fmt.Sprintf("- **Working tree**: %s\n", status))
 	} else {
-		out.WriteString("- **Working tree**: Clean\n")
+		summary.WriteString("- **Working tree**: Clean\n")
+	}
+	if warning := git.InProgressWarning(git.GetInProgressOperation(dir, r)); warning != "" {
+		summary.WriteString(fmt.Sprintf("- %s\n", warning))
+	}
+
+	// Remote sync status
+	if remote := formatRemoteStatus(git.CheckRemoteStatus(dir, r, opts.Fetch), opts.Fetch); remote != "" {
+		summary.WriteString(fmt.Sprintf("- **Remote**: %s\n", remote))
 	}
-	out.WriteString("\n")
+
+	// Commit identity
+	if opts.CheckID {
+		if warning := git.CheckIdentity(dir, r); warning != "" {
+			summary.WriteString(fmt.Sprintf("- %s\n", warning))
+		}
+	}
+	summary.WriteString("\n")
+	doc.Add(prompt.Summary, "Work Summary", summary.String())
 
 	// Recent commits section
 	if commits != "" {
-		out.WriteString("## Recent Commits\n")
-		out.WriteString("```\n")
-		out.WriteString(commits)
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Recent Commits", "```\n"+commits+"\n```\n\n")
+	}
+
+	// Tasks this one unblocks
+	if (opts.Dependencies || opts.Verbose) && task.ID != "" && beads.IsInitialized(dir) {
+		if ready, err := beads.Unblocks(dir, task.ID, r); err == nil {
+			doc.Add(prompt.Summary, "Unblocks", formatUnblocks(task.ID, ready))
+		} else {
+			slog.Info("skipping dependencies", "task", task.ID, "err", err)
+		}
+	}
+
+	// Leftover TODO/debug markers
+	if opts.CheckWIP || opts.Verbose {
+		if base := git.BaseBranch(dir, r); git.IsWorkBranch(branch, base) {
+			patterns, err := compileWIPPatterns(opts.WIPPatterns)
+			if err != nil {
+				return err
+			}
+			if findings := scanWIP(getBranchDiff(dir, base, r), patterns); len(findings) > 0 {
+				doc.Add(prompt.Summary, "Unfinished Work", formatWIP(findings, git.NewPathRewriter(dir, opts.Paths, r)))
+			}
+		}
+	}
+
+	// Verification
+	var verification *project.VerifyResult
+	if opts.Verify {
+		testCmd := project.ResolveTestCommand(dir, opts.TestCommand, opts.Ecosystem)
+
+		if testCmd == "" {
+			doc.Add(prompt.Summary, "Verification", "⚠️ No test command detected - verify manually\n\n")
+		} else {
+			result := project.Verify(dir, testCmd, r)
+			if err := project.SaveResult(dir, result, "vibes done --verify", r); err != nil {
+				slog.Warn("could not cache test result", "err", err)
+			}
+			verification = &result
+			doc.Add(prompt.Summary, "Verification", formatVerification(result))
+		}
 	}
 
 	// Protocol
-	out.WriteString("## Completion Protocol\n")
-	out.WriteString(getProtocol(task, opts.Verbose))
+	if verification != nil && !verification.Passed {
+		doc.Add(prompt.Protocol, "Completion Protocol", getFailedVerificationProtocol(task, verification.Command))
+		return prompt.Emit(dir, doc, opts.Output, r)
+	}
+
+	// Close the task directly
+	if opts.Close {
+		status, err := closeTask(dir, task.ID, opts.Force, r)
+		if err != nil {
+			return err
+		}
+		doc.Add(prompt.Summary, "Close Task", status)
+	}
+
+	vars := templates.Merge(templates.Builtins(task.ID, projectName, branch), opts.TemplateVars)
+	protocol, err := templates.Protocol(dir, "done", getProtocol(task, opts.Verbose), vars)
+	if err != nil {
+		return err
+	}
+	doc.Add(prompt.Protocol, "Completion Protocol", protocol)
+
+	return prompt.Emit(dir, doc, opts.Output, r)
+}
+
+// formatRemoteStatus describes how the branch relates to its upstream.
+// Without a fetch the counts reflect the last-known remote state, which is noted.
+func formatRemoteStatus(status git.RemoteStatus, fetched bool) string {
+	if status.Info == "" {
+		return ""
+	}
+
+	var desc string
+	switch {
+	case status.Ahead > 0 && status.Behind > 0:
+		desc = fmt.Sprintf("%s - pull and push before closing", status.Info)
+	case status.Behind > 0:
+		desc = fmt.Sprintf("%s - consider pulling", status.Info)
+	case status.Ahead > 0:
+		desc = fmt.Sprintf("%s - push before closing", status.Info)
+	default:
+		desc = status.Info
+	}
+
+	if !fetched {
+		desc += " (as of last fetch)"
+	}
+	return desc
+}
+
+// closeTask marks the task closed in beads. It refuses when open tasks still
+// block it, unless force is set, so tasks aren't closed out of order.
+// Returns a status line for the prompt.
+func closeTask(dir string, taskID string, force bool, r runner.CommandRunner) (string, error) {
+	if taskID == "" {
+		return "", fmt.Errorf("no current task detected to close")
+	}
+
+	deps, err := beads.GetDependencies(dir, taskID, r)
+	switch {
+	case err != nil && !force:
+		return "", fmt.Errorf("⚠️ could not check dependencies of %s (%v) — use --force to close anyway", taskID, err)
+	case err == nil && deps.Status == "closed":
+		return fmt.Sprintf("⚠️ %s is already closed\n\n", taskID), nil
+	case err == nil && !force:
+		if open := deps.OpenBlockers(); len(open) > 0 {
+			ids := make([]string, len(open))
+			for i, d := range open {
+				ids[i] = d.ID
+			}
+			return "", fmt.Errorf("⚠️ %s is blocked by open tasks %s — cannot close (use --force to override)", taskID, strings.Join(ids, ", "))
+		}
+	}
+
+	if _, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "update", taskID, "--status", "closed"); err != nil {
+		if output := runner.ErrorOutput(err); output != "" {
+			return "", fmt.Errorf("closing %s: %w\n%s", taskID, err, output)
+		}
+		return "", fmt.Errorf("closing %s: %w", taskID, err)
+	}
+	return fmt.Sprintf("✅ Closed %s\n\n", taskID), nil
+}
+
+// formatUnblocks describes the tasks that become ready once taskID is closed.
+func formatUnblocks(taskID string, ready []beads.Dependency) string {
+	if len(ready) == 0 {
+		return fmt.Sprintf("Closing %s will not unblock any tasks yet.\n\n", taskID)
+	}
+
+	ids := make([]string, len(ready))
+	for i, d := range ready {
+		ids[i] = d.ID
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Closing %s will unblock: %s.\n", taskID, strings.Join(ids, ", ")))
+	for _, d := range ready {
+		if d.Title != "" {
+			out.WriteString(fmt.Sprintf("- %s \"%s\"\n", d.ID, d.Title))
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// formatVerification renders the outcome of running the test command.
+// Failing output is limited to its tail, where test runners report failures.
+func formatVerification(result project.VerifyResult) string {
+	if result.Passed {
+		return fmt.Sprintf("✅ Tests pass: `%s`\n\n", result.Command)
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("❌ Tests failed: `%s`\n", result.Command))
+	if result.Output != "" {
+		out.WriteString("```\n")
+		out.WriteString(prompt.TailLines(result.Output, 50))
+		out.WriteString("\n```\n")
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// getFailedVerificationProtocol replaces the completion protocol when tests fail,
+// so the agent fixes the failures instead of closing the task.
+func getFailedVerificationProtocol(task beads.TaskInfo, testCmd string) string {
+	taskID := task.ID
+	if taskID == "" {
+		taskID = "<task-id>"
+	}
+
+	return fmt.Sprintf(`⚠️ Do not mark %s closed yet - verification failed.
+
+1. Investigate the failures shown above
+2. Fix the code (or the tests, if they are wrong)
+3. Re-run: `+"`%s`"+`
+4. Re-check: `+"`vibes done --verify`"+`
 
-	fmt.Print(out.String())
-	return nil
+Please fix the failing tests before completing the task.
+`, taskID, testCmd)
 }
 
 func getProtocol(task beads.TaskInfo, verbose bool) string {
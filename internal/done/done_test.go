@@ -1,11 +1,18 @@
 package done
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/project"
+	"github.com/vibes-project/vibes/internal/prompt"
+	"github.com/vibes-project/vibes/internal/runner"
 )
 
 // MockRunner is a mock implementation of runner.CommandRunner for testing
@@ -79,6 +86,39 @@ func TestGetProtocol(t *testing.T) {
 }
 
 func TestRun(t *testing.T) {
+	t.Run("task override", func(t *testing.T) {
+		var shown []string
+		listed := false
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" && args[0] == "list" {
+					listed = true
+					return "bd-1  Other task  [in_progress]", nil
+				}
+				if command == "bd" && args[0] == "show" {
+					shown = append(shown, args[1])
+					if args[1] == "bd-7" {
+						return "Title: Chosen task\nStatus: in_progress", nil
+					}
+					return "", errors.New("no issue found")
+				}
+				return "", nil
+			},
+		}
+
+		if err := Run(Options{Dir: t.TempDir(), Task: "bd-7", Runner: mock}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if listed || len(shown) != 1 || shown[0] != "bd-7" {
+			t.Errorf("expected only bd show bd-7, got listed=%v shown=%v", listed, shown)
+		}
+
+		err := Run(Options{Dir: t.TempDir(), Task: "bd-404", Runner: mock})
+		if err == nil || !strings.Contains(err.Error(), "bd-404 not found") {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
 	t.Run("with specified directory", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -144,3 +184,287 @@ func TestRun(t *testing.T) {
 		_ = Run(opts)
 	})
 }
+
+func TestFormatVerification(t *testing.T) {
+	t.Run("passing", func(t *testing.T) {
+		result := formatVerification(project.VerifyResult{Command: "go test ./...", Passed: true})
+
+		if !strings.Contains(result, "✅ Tests pass") || !strings.Contains(result, "go test ./...") {
+			t.Errorf("expected pass message with command, got: %s", result)
+		}
+	})
+
+	t.Run("failing shows output tail", func(t *testing.T) {
+		lines := make([]string, 80)
+		for i := range lines {
+			lines[i] = "line"
+		}
+		lines[79] = "--- FAIL: TestThing"
+		result := formatVerification(project.VerifyResult{Command: "go test ./...", Output: strings.Join(lines, "\n")})
+
+		if !strings.Contains(result, "❌ Tests failed") {
+			t.Errorf("expected failure message, got: %s", result)
+		}
+		if !strings.Contains(result, "--- FAIL: TestThing") {
+			t.Errorf("expected last output line, got: %s", result)
+		}
+		if !strings.Contains(result, "30 earlier lines") {
+			t.Errorf("expected truncation note, got: %s", result)
+		}
+	})
+}
+
+func TestGetFailedVerificationProtocol(t *testing.T) {
+	task := beads.TaskInfo{ID: "bd-123"}
+	result := getFailedVerificationProtocol(task, "npm test")
+
+	if strings.Contains(result, "--status closed") {
+		t.Error("expected no close command when verification fails")
+	}
+	if !strings.Contains(result, "Do not mark bd-123 closed") {
+		t.Errorf("expected blocking message, got: %s", result)
+	}
+	if !strings.Contains(result, "npm test") {
+		t.Errorf("expected test command, got: %s", result)
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	t.Run("runs detected test command", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var ran string
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "sh" {
+					ran = args[len(args)-1]
+					return "", &runner.CommandError{Err: errors.New("exit status 1"), Output: "FAIL"}
+				}
+				return "", nil
+			},
+		}
+
+		if err := Run(Options{Dir: tmpDir, Verify: true, Runner: mock}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if ran != "go test ./... && go build ./..." {
+			t.Errorf("expected detected go test command to run, got %q", ran)
+		}
+	})
+
+	t.Run("respects test command override", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		var ran string
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "sh" {
+					ran = args[len(args)-1]
+				}
+				return "", nil
+			},
+		}
+
+		if err := Run(Options{Dir: tmpDir, Verify: true, TestCommand: "make check", Runner: mock}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if ran != "make check" {
+			t.Errorf("expected override to run, got %q", ran)
+		}
+	})
+}
+
+func TestFormatRemoteStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   git.RemoteStatus
+		fetched  bool
+		expected string
+	}{
+		{"no tracking branch", git.RemoteStatus{}, true, ""},
+		{"in sync", git.RemoteStatus{Info: "up to date"}, true, "up to date"},
+		{"ahead", git.RemoteStatus{Ahead: 2, Info: "ahead 2"}, true, "ahead 2 - push before closing"},
+		{"behind", git.RemoteStatus{Behind: 1, Info: "behind 1"}, true, "behind 1 - consider pulling"},
+		{"diverged", git.RemoteStatus{Ahead: 1, Behind: 3, Info: "ahead 1, behind 3"}, true, "ahead 1, behind 3 - pull and push before closing"},
+		{"not fetched", git.RemoteStatus{Ahead: 2, Info: "ahead 2"}, false, "ahead 2 - push before closing (as of last fetch)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := formatRemoteStatus(tc.status, tc.fetched); result != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestRunFetch(t *testing.T) {
+	for _, fetch := range []bool{false, true} {
+		tmpDir := t.TempDir()
+		fetched := false
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && args[0] == "remote" {
+					return "origin", nil
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "git" && args[0] == "fetch" {
+					fetched = true
+				}
+				return "", nil
+			},
+		}
+
+		if err := Run(Options{Dir: tmpDir, Fetch: fetch, Runner: mock}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if fetched != fetch {
+			t.Errorf("Fetch=%v: expected fetch to run %v, got %v", fetch, fetch, fetched)
+		}
+	}
+}
+
+func TestFormatUnblocks(t *testing.T) {
+	t.Run("lists unblocked tasks", func(t *testing.T) {
+		ready := []beads.Dependency{{ID: "bd-15", Title: "Use API"}, {ID: "bd-18"}}
+
+		result := formatUnblocks("bd-12", ready)
+
+		if !strings.HasPrefix(result, "Closing bd-12 will unblock: bd-15, bd-18.\n") {
+			t.Errorf("unexpected summary: %q", result)
+		}
+		if !strings.Contains(result, "- bd-15 \"Use API\"") {
+			t.Errorf("expected titled entry, got: %q", result)
+		}
+	})
+
+	t.Run("nothing unblocked", func(t *testing.T) {
+		result := formatUnblocks("bd-12", nil)
+		if !strings.Contains(result, "will not unblock any tasks") {
+			t.Errorf("unexpected result: %q", result)
+		}
+	})
+}
+
+func TestCloseTask(t *testing.T) {
+	newRunner := func(show string, closed *bool) *MockRunner {
+		return &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command != "bd" {
+					return "", nil
+				}
+				switch args[0] {
+				case "show":
+					if show == "" {
+						return "", errors.New("exit status 1")
+					}
+					return show, nil
+				case "update":
+					*closed = true
+				}
+				return "", nil
+			},
+		}
+	}
+
+	blocked := `[{"id":"bd-12","status":"in_progress","dependencies":[
+		{"id":"bd-5","status":"open","dependency_type":"blocks"},
+		{"id":"bd-6","status":"closed","dependency_type":"blocks"},
+		{"id":"bd-7","status":"in_progress","dependency_type":"blocks"},
+		{"id":"bd-8","status":"open","dependency_type":"related"}
+	]}]`
+
+	t.Run("refuses when blocked by open tasks", func(t *testing.T) {
+		closed := false
+		_, err := closeTask("/repo", "bd-12", false, newRunner(blocked, &closed))
+
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "bd-12 is blocked by open tasks bd-5, bd-7") {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if closed {
+			t.Error("expected task to stay open")
+		}
+	})
+
+	t.Run("force closes blocked task", func(t *testing.T) {
+		closed := false
+		status, err := closeTask("/repo", "bd-12", true, newRunner(blocked, &closed))
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !closed || !strings.Contains(status, "Closed bd-12") {
+			t.Errorf("expected task to be closed, got %q", status)
+		}
+	})
+
+	t.Run("closes unblocked task", func(t *testing.T) {
+		closed := false
+		show := `[{"id":"bd-12","status":"in_progress","dependencies":[{"id":"bd-5","status":"closed"}]}]`
+		if _, err := closeTask("/repo", "bd-12", false, newRunner(show, &closed)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !closed {
+			t.Error("expected task to be closed")
+		}
+	})
+
+	t.Run("warns when already closed", func(t *testing.T) {
+		closed := false
+		status, err := closeTask("/repo", "bd-12", false, newRunner(`[{"id":"bd-12","status":"closed"}]`, &closed))
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if closed || !strings.Contains(status, "already closed") {
+			t.Errorf("expected already-closed warning without update, got %q", status)
+		}
+	})
+
+	t.Run("refuses when dependencies are unknown", func(t *testing.T) {
+		closed := false
+		if _, err := closeTask("/repo", "bd-12", false, newRunner("", &closed)); err == nil || closed {
+			t.Errorf("expected refusal, got err=%v closed=%v", err, closed)
+		}
+	})
+
+	t.Run("requires a task", func(t *testing.T) {
+		closed := false
+		if _, err := closeTask("/repo", "", false, newRunner(blocked, &closed)); err == nil {
+			t.Error("expected error without task")
+		}
+	})
+}
+
+func TestRunProjectKey(t *testing.T) {
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			if command == "git" && len(args) >= 2 && args[0] == "rev-parse" && args[1] == "--abbrev-ref" {
+				return "feature/bd-123-test", nil
+			}
+			return "", nil
+		},
+	}
+
+	out := t.TempDir()
+	opts := Options{Dir: t.TempDir(), Verbose: true, ProjectKey: "acme-api", Output: prompt.Output{OutputDir: out}, Runner: mock}
+	if err := Run(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	system, err := os.ReadFile(filepath.Join(out, prompt.SystemFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(system), `project_key="acme-api"`) {
+		t.Errorf("expected project key override in MCP snippets, got:\n%s", system)
+	}
+}
@@ -0,0 +1,109 @@
+package done
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// DefaultWIPPatterns match markers that usually mean work was left unfinished.
+var DefaultWIPPatterns = []string{
+	`\bTODO\b`,
+	`\bFIXME\b`,
+	`\bXXX\b`,
+	`console\.log\(`,
+	`fmt\.Println\("debug`,
+}
+
+// maxWIPFindings caps how many markers are listed in the prompt
+const maxWIPFindings = 20
+
+// wipFinding is a marker found on a line added by the branch
+type wipFinding struct {
+	File string
+	Line int
+	Text string
+}
+
+// hunkHeader captures the starting line of the new side of a diff hunk
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// compileWIPPatterns compiles patterns, falling back to DefaultWIPPatterns when none are given
+func compileWIPPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultWIPPatterns
+	}
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wip pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// getBranchDiff returns the zero-context diff of the branch against base
+func getBranchDiff(dir string, base string, r runner.CommandRunner) string {
+	output, err := r.Run(dir, "git", "diff", "-U0", "--no-color", base+"...HEAD")
+	if err != nil {
+		return ""
+	}
+	return output
+}
+
+// scanWIP finds lines added in a unified diff that match any pattern
+func scanWIP(diff string, patterns []*regexp.Regexp) []wipFinding {
+	var findings []wipFinding
+	var file string
+	line := 0
+	inHunk := false
+
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			inHunk = false
+		case !inHunk && strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+		case strings.HasPrefix(text, "@@"):
+			if m := hunkHeader.FindStringSubmatch(text); m != nil {
+				line, _ = strconv.Atoi(m[1])
+				inHunk = true
+			}
+		case !inHunk:
+		case strings.HasPrefix(text, "+"):
+			added := text[1:]
+			for _, re := range patterns {
+				if re.MatchString(added) {
+					findings = append(findings, wipFinding{File: file, Line: line, Text: strings.TrimSpace(added)})
+					break
+				}
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// formatWIP lists unfinished-work markers for the prompt, with file paths
+// shown as paths displays them
+func formatWIP(findings []wipFinding, paths git.PathRewriter) string {
+	var out strings.Builder
+	out.WriteString("⚠️ Possible unfinished work - resolve or confirm these before closing:\n")
+	for i, f := range findings {
+		if i == maxWIPFindings {
+			out.WriteString(fmt.Sprintf("- ... and %d more\n", len(findings)-maxWIPFindings))
+			break
+		}
+		out.WriteString(fmt.Sprintf("- %s:%d `%s`\n", paths.Rewrite(f.File), f.Line, f.Text))
+	}
+	out.WriteString("\n")
+	return out.String()
+}
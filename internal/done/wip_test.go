@@ -0,0 +1,113 @@
+package done
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vibes-project/vibes/internal/git"
+)
+
+func TestScanWIP(t *testing.T) {
+	patterns, err := compileWIPPatterns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,0 +11,2 @@ func main() {
++	// TODO handle errors
++	run()
+@@ -20 +22 @@ func helper() {
+-	// TODO old note
++	fmt.Println("debug", x)
+diff --git a/web/app.js b/web/app.js
+--- a/web/app.js
++++ b/web/app.js
+@@ -1,0 +2 @@
++console.log(state)
+`
+
+	findings := scanWIP(diff, patterns)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+
+	expected := []wipFinding{
+		{File: "main.go", Line: 11, Text: "// TODO handle errors"},
+		{File: "main.go", Line: 22, Text: `fmt.Println("debug", x)`},
+		{File: "web/app.js", Line: 2, Text: "console.log(state)"},
+	}
+	for i, want := range expected {
+		if findings[i] != want {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want, findings[i])
+		}
+	}
+
+	t.Run("removed lines are ignored", func(t *testing.T) {
+		for _, f := range findings {
+			if strings.Contains(f.Text, "old note") {
+				t.Error("expected removed TODO to be ignored")
+			}
+		}
+	})
+
+	t.Run("custom patterns replace defaults", func(t *testing.T) {
+		custom, err := compileWIPPatterns([]string{`run\(\)`})
+		if err != nil {
+			t.Fatal(err)
+		}
+		findings := scanWIP(diff, custom)
+		if len(findings) != 1 || findings[0].Line != 12 {
+			t.Errorf("expected only run() on line 12, got %+v", findings)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		if _, err := compileWIPPatterns([]string{"("}); err == nil {
+			t.Error("expected error for invalid pattern")
+		}
+	})
+}
+
+func TestFormatWIP(t *testing.T) {
+	findings := make([]wipFinding, maxWIPFindings+5)
+	for i := range findings {
+		findings[i] = wipFinding{File: "a.go", Line: i + 1, Text: "// TODO"}
+	}
+
+	result := formatWIP(findings, git.PathRewriter{})
+	if !strings.Contains(result, "⚠️ Possible unfinished work") {
+		t.Error("expected warning header")
+	}
+	if !strings.Contains(result, "- a.go:1 `// TODO`") {
+		t.Errorf("expected file:line entry, got:\n%s", result)
+	}
+	if !strings.Contains(result, "... and 5 more") {
+		t.Errorf("expected truncation note, got:\n%s", result)
+	}
+
+	paths := git.PathRewriter{Mode: git.PathsAbsolute, Root: "/repo", Dir: "/repo"}
+	result = formatWIP(findings[:1], paths)
+	if !strings.Contains(result, "- "+filepath.Join("/repo", "a.go")+":1 `// TODO`") {
+		t.Errorf("expected absolute path, got:\n%s", result)
+	}
+}
+
+func TestGetBranchDiff(t *testing.T) {
+	var diffed string
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			diffed = args[len(args)-1]
+			return "", nil
+		},
+	}
+
+	getBranchDiff("/test", "upstream/main", mock)
+	if diffed != "upstream/main...HEAD" {
+		t.Errorf("expected diff against the base, got %q", diffed)
+	}
+}
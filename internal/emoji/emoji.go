@@ -0,0 +1,55 @@
+// Package emoji swaps the emoji in vibes output for ASCII markers, for
+// terminals, logs, and tools that can't display them.
+package emoji
+
+import (
+	"os"
+	"strings"
+)
+
+// Disabled replaces emoji with ASCII markers in Render. Set once at startup.
+var Disabled bool
+
+// markers maps each emoji vibes prints to its ASCII equivalent. Variants
+// with the emoji presentation selector come first so it isn't left behind.
+var markers = []string{
+	"⚠️", "[warn]",
+	"⚠", "[warn]",
+	"✅", "[ok]",
+	"❌", "[FAIL]",
+	"⏳", "[pending]",
+	"⏭️", "[skipped]",
+	"⏭", "[skipped]",
+	"ℹ️", "[info]",
+	"ℹ", "[info]",
+	"💬", "[comment]",
+	"📤", "[push]",
+	"🚫", "[blocked]",
+	"🤖 ", "",
+	"→", "->",
+}
+
+var replacer = strings.NewReplacer(markers...)
+
+// Render returns s with emoji replaced when Disabled, otherwise s unchanged.
+func Render(s string) string {
+	if !Disabled {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// Unsupported reports whether the environment asks for plain output:
+// NO_COLOR is set, or the locale is explicitly set to a non-UTF-8 one.
+func Unsupported() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return !strings.Contains(locale, "utf-8") && !strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package emoji
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// isEmoji reports whether r is a pictograph or the emoji presentation selector
+func isEmoji(r rune) bool {
+	return unicode.Is(unicode.So, r) || r == '️' || r == '→'
+}
+
+func TestRender(t *testing.T) {
+	sample := "⚠️ Branch is behind\n✅ Tests pass\n❌ Tests failed\n⏳ ci pending\n⏭️ skipped\n💬 Check inbox\n📤 push\n🚫 blocked\nℹ️ No remote\n🤖 Generated\n- main → feature"
+
+	t.Run("enabled leaves output alone", func(t *testing.T) {
+		Disabled = false
+		if got := Render(sample); got != sample {
+			t.Errorf("expected unchanged output, got %q", got)
+		}
+	})
+
+	t.Run("disabled uses ASCII markers", func(t *testing.T) {
+		Disabled = true
+		t.Cleanup(func() { Disabled = false })
+
+		got := Render(sample)
+		for _, marker := range []string{"[warn] Branch", "[ok] Tests pass", "[FAIL] Tests failed", "[pending] ci", "[comment] Check", "main -> feature"} {
+			if !strings.Contains(got, marker) {
+				t.Errorf("expected %q in:\n%s", marker, got)
+			}
+		}
+		if strings.IndexFunc(got, isEmoji) >= 0 {
+			t.Errorf("expected no emoji, got:\n%s", got)
+		}
+	})
+}
+
+// TestRenderCoversSources guards the map: every emoji in a string literal
+// of a package that prints prompts must have an ASCII replacement.
+func TestRenderCoversSources(t *testing.T) {
+	Disabled = true
+	t.Cleanup(func() { Disabled = false })
+
+	files, err := filepath.Glob("../*/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		// setup's interactive output is styled separately and never piped to an agent
+		if strings.HasSuffix(path, "_test.go") || strings.Contains(path, "/setup/") || strings.Contains(path, "/emoji/") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := parser.ParseFile(fset, path, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			if rendered := Render(lit.Value); strings.IndexFunc(rendered, isEmoji) >= 0 {
+				t.Errorf("%s: no ASCII replacement for emoji in %s", fset.Position(lit.Pos()), lit.Value)
+			}
+			return true
+		})
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		expected bool
+	}{
+		{"UTF-8 locale", map[string]string{"LANG": "en_US.UTF-8"}, false},
+		{"utf8 spelling", map[string]string{"LANG": "C.utf8"}, false},
+		{"NO_COLOR", map[string]string{"LANG": "en_US.UTF-8", "NO_COLOR": "1"}, true},
+		{"non-UTF-8 locale", map[string]string{"LANG": "en_US.ISO-8859-1"}, true},
+		{"LC_ALL wins over LANG", map[string]string{"LC_ALL": "C", "LANG": "en_US.UTF-8"}, true},
+		{"no locale set", map[string]string{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"NO_COLOR", "LC_ALL", "LC_CTYPE", "LANG"} {
+				t.Setenv(name, tc.env[name])
+			}
+			if got := Unsupported(); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
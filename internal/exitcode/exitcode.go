@@ -0,0 +1,47 @@
+// Package exitcode maps vibes errors to process exit codes, so scripts can
+// tell failures apart.
+package exitcode
+
+import (
+	"errors"
+
+	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// Exit codes. Any failure without a more specific code exits with Failure.
+const (
+	OK          = 0
+	Failure     = 1
+	NotGitRepo  = 2 // The directory isn't a git repository
+	NoTasks     = 3 // No beads task graph, or no ready tasks (strict mode)
+	ToolMissing = 4 // A required tool such as bd, bv, or gh isn't installed
+	AuthFailed  = 5 // gh isn't logged in
+)
+
+// codes is checked in order, so more specific errors come first.
+var codes = []struct {
+	err  error
+	code int
+}{
+	{git.ErrNotGitRepo, NotGitRepo},
+	{beads.ErrNotInitialized, NoTasks},
+	{beads.ErrNoTasks, NoTasks},
+	{runner.ErrToolMissing, ToolMissing},
+	{runner.ErrAuthFailed, AuthFailed},
+}
+
+// For returns the exit code for err: OK for nil, Failure when err matches
+// no known error.
+func For(err error) int {
+	if err == nil {
+		return OK
+	}
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return Failure
+}
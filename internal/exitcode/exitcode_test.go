@@ -0,0 +1,35 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+func TestFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"success", nil, OK},
+		{"not a git repository", fmt.Errorf("directory '/tmp' is %w", git.ErrNotGitRepo), NotGitRepo},
+		{"no task graph", beads.ErrNotInitialized, NoTasks},
+		{"no ready tasks", beads.ErrNoTasks, NoTasks},
+		{"missing tool", fmt.Errorf("gh is %w", runner.ErrToolMissing), ToolMissing},
+		{"not logged in", fmt.Errorf("%w: `gh auth status` failed", runner.ErrAuthFailed), AuthFailed},
+		{"anything else", errors.New("exit status 1"), Failure},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := For(tc.err); got != tc.expected {
+				t.Errorf("expected exit code %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+// Package explain describes what each vibes command gathers, for
+// `vibes explain`. It only documents commands; nothing here runs them.
+package explain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vibes-project/vibes/internal/done"
+	"github.com/vibes-project/vibes/internal/feedback"
+	"github.com/vibes-project/vibes/internal/next"
+	"github.com/vibes-project/vibes/internal/pr"
+	"github.com/vibes-project/vibes/internal/resume"
+	"github.com/vibes-project/vibes/internal/stuck"
+)
+
+// Source is something a command reads to fill a section.
+type Source struct {
+	Command string // Command line with <placeholders>, or empty for non-command sources
+	Note    string // What it's used for, and when it runs if not always
+}
+
+// Command describes what a vibes command gathers and what it can change.
+type Command struct {
+	Name     string
+	Summary  string
+	Sections []string            // Section names, in output order
+	Sources  map[string][]Source // What fills each section
+	Changes  []string            // State the command can change, and what triggers it
+}
+
+// Shared sources, listed in the order the commands run them.
+var (
+	branchSource = Source{"git rev-parse --abbrev-ref HEAD", "current branch"}
+	taskSources  = []Source{
+		{"bd list --status in_progress", "detect the current task"},
+		{"bd show <id>", "task title, status, and last update (falls back to the bead ID in the branch name)"},
+	}
+	baseSources = []Source{
+		{"git remote", "remote names, to recognize remote base branches"},
+		{"git rev-parse --verify main", "pick the base branch (then master)"},
+	}
+	branchCommits = Source{"git log --oneline main..HEAD", "commits on the branch (master..HEAD, or the last 5 commits, as fallbacks)"}
+	templateNote  = func(name string) Source {
+		return Source{"", fmt.Sprintf("the built-in protocol, or .vibes/templates/%s.md when present", name)}
+	}
+)
+
+// Output flags that post or write somewhere.
+const (
+	splitChange       = "`--output-dir` writes system.md and user.md"
+	taskCommentChange = "`--comment` posts the summary sections to the task with `bd comment add` (asks first)"
+	postCommentChange = "`--post-comment <number>` posts the prompt as a comment on that PR with gh"
+)
+
+// Registry lists the commands `vibes explain` can describe.
+var Registry = []Command{
+	{
+		Name:     "next",
+		Summary:  "Recommends the next task from the beads task graph.",
+		Sections: next.Sections,
+		Sources: map[string][]Source{
+			"Project Context": {
+				branchSource,
+				{"git status --porcelain", "working tree summary"},
+				{"git log -1 --format=%s (%ar)", "latest commit"},
+			},
+			"Recently Closed": {
+				{"bd list --status closed --json", "with --recent-closed"},
+			},
+			"Recommended Task": {
+				{"bd sync --import-only", "with --refresh, before triage"},
+				{"bd list --status in_progress", "flag in-progress tasks not updated within stale_days"},
+				{"bd show <id>", "last update of each in-progress task"},
+				{"bv --robot-triage", "ranked task listing, trimmed to --max-tasks"},
+				{"bd ready", "fallback when bv is missing or lists nothing, re-sorted by priority"},
+				{"bd show <id>", "confirm the top task is still open; with --full-task or --verbose, its description"},
+			},
+			"Protocol": {templateNote("next")},
+		},
+		Changes: []string{
+			"`--record` appends the recommended task to .vibes/history.jsonl",
+			"`--refresh` re-imports the beads database from its JSONL",
+			splitChange,
+		},
+	},
+	{
+		Name:     "resume",
+		Summary:  "Restores context for work in progress.",
+		Sections: resume.Sections,
+		Sources: map[string][]Source{
+			"Current Work": append([]Source{branchSource}, taskSources...),
+			"Work in Progress": {
+				{"git status --porcelain", "uncommitted changes"},
+				{"git stash list", "stashed work"},
+				{"git rev-parse --git-dir", "detect an unfinished rebase, merge, or cherry-pick"},
+			},
+			"Test Status": {
+				{"", "with --last-test, the cached result in .vibes/last-test.json"},
+				{"git rev-parse HEAD", "whether the cached result is for the current commit"},
+				{"git status --porcelain", "whether uncommitted changes make the cached result stale"},
+				{"<test command>", "with --last-test, only when no result is cached for the same command"},
+			},
+			"Recent Commits": {branchCommits},
+			"Pending Attention": {
+				{"git fetch --quiet", "unless --no-fetch"},
+				{"git status -sb", "ahead/behind the upstream"},
+			},
+			"Protocol": {templateNote("resume")},
+		},
+		Changes: []string{
+			"Fetches from the remote, updating remote-tracking branches (skip with `--no-fetch`)",
+			"`--last-test` may run the test command and cache its result in .vibes/last-test.json",
+			taskCommentChange,
+			splitChange,
+		},
+	},
+	{
+		Name:     "done",
+		Summary:  "Wraps up the current task: summary, checks, and completion steps.",
+		Sections: done.Sections,
+		Sources: map[string][]Source{
+			"Work Summary": append(append([]Source{branchSource}, taskSources...),
+				branchCommits,
+				Source{"git status --porcelain", "uncommitted changes"},
+				Source{"git rev-parse --git-dir", "detect an unfinished rebase, merge, or cherry-pick"},
+				Source{"git fetch --quiet", "only with --fetch"},
+				Source{"git status -sb", "ahead/behind the upstream"},
+				Source{"git config user.email", "with --check-identity"},
+				Source{"gh api user", "with --check-identity, the authenticated account and its emails"},
+			),
+			"Recent Commits": {branchCommits},
+			"Unblocks": {
+				{"bd show <id> --json", "with --dependencies or --verbose, tasks this one blocks"},
+			},
+			"Unfinished Work": {
+				{"git diff -U0 --no-color <base>...HEAD", "with --check-wip or --verbose, lines added on a work branch; the base is the upstream default branch in forks, otherwise main or master"},
+			},
+			"Verification": {
+				{"<test command>", "with --verify; the result is cached in .vibes/last-test.json"},
+			},
+			"Close Task": {
+				{"bd show <id> --json", "with --close, open tasks blocking this one"},
+				{"bd update <id> --status closed", "with --close"},
+			},
+			"Completion Protocol": {templateNote("done")},
+		},
+		Changes: []string{
+			"`--close` closes the task in beads",
+			"`--verify` runs the test command and writes .vibes/last-test.json",
+			"`--fetch` fetches from the remote",
+			taskCommentChange,
+			postCommentChange,
+			splitChange,
+		},
+	},
+	{
+		Name:     "pr",
+		Summary:  "Prepares a pull request description, or reports on an existing one.",
+		Sections: pr.Sections,
+		Sources: map[string][]Source{
+			"Existing PR": {
+				{"gh pr list --head <branch>", "an open PR for this branch"},
+			},
+			"Branch Info": append(append([]Source{branchSource}, baseSources...),
+				Source{"git rev-parse --abbrev-ref --symbolic-full-name @{upstream}", "detect a stacked parent branch"},
+				Source{"git branch --merged HEAD --no-merged <base>", "detect a stacked parent branch"},
+				Source{"git diff --stat <base>...HEAD", "change size"},
+				Source{"git status --porcelain", "uncommitted changes"},
+				Source{"gh api user", "with --check-identity"},
+			),
+			"Task Context": taskSources,
+			"Linked Issues": {
+				{"git log --format=%B <base>..HEAD", "closing keywords in commit messages"},
+				{"gh pr view <number> --json body,closingIssuesReferences", "for an existing PR"},
+				{"gh issue view <number>", "title and state of each linked issue"},
+			},
+			"Commits":       {{"git log --oneline <base>..HEAD", "commits to include"}},
+			"Files Changed": {{"git diff --name-status <base>...HEAD", "changed files"}},
+			"Protocol":      {templateNote("pr")},
+		},
+		Changes: []string{postCommentChange, splitChange},
+	},
+	{
+		Name:     "pr-fix",
+		Summary:  "Collects what is blocking the current branch's pull request.",
+		Sections: []string{"PR Status", "PR Description", "Linked Issues", "CI Checks", "Reviews", "Issues to Address", "Protocol"},
+		Sources: map[string][]Source{
+			"PR Status": append([]Source{
+				branchSource,
+				{"gh pr view --json number,title,url,state,mergeable,baseRefName,headRefName,body", "the PR and whether it merges cleanly"},
+			}, taskSources...),
+			"PR Description": {{"", "the PR body from gh pr view"}},
+			"Linked Issues": {
+				{"git log --format=%B <base>..HEAD", "closing keywords in commit messages"},
+				{"gh pr view <number> --json body,closingIssuesReferences", "issues GitHub links to the PR"},
+				{"gh issue view <number>", "title and state of each linked issue"},
+			},
+			"CI Checks": {
+				{"gh pr checks <number>", "check status and conclusions"},
+				{"gh api repos/{owner}/{repo}/branches/<base>/protection", "required checks, only when a check was skipped"},
+			},
+			"Reviews": {
+				{"gh pr view <number> --json reviews", "review states"},
+				{"gh pr view <number> --json reviewRequests,comments", "review comments (gh api .../pulls/<number>/comments as fallback)"},
+				{"gh api graphql", "with --only-failing or --resolve-comments, unresolved review threads"},
+			},
+			"Issues to Address": {{"", "derived from the sections above"}},
+			"Protocol": {
+				{"gh pr view <n> --json commits", "commit authors, to warn before force-pushing a shared branch"},
+				templateNote("pr-fix"),
+			},
+		},
+		Changes: []string{
+			"`--resolve-comments` resolves the review threads you pick on GitHub (asks first)",
+			"`--notify` runs notify_command or posts to notify_webhook",
+		},
+	},
+	{
+		Name:     "feedback",
+		Summary:  "Prepares a prompt to act on review feedback.",
+		Sections: feedback.Sections,
+		Sources: map[string][]Source{
+			"Current Context": append(append([]Source{branchSource}, taskSources...),
+				Source{"git status --porcelain", "uncommitted changes"},
+			),
+			"Recent Commits": {branchCommits},
+			"Changes Summary": append(append([]Source{}, baseSources...),
+				Source{"gh pr view --json baseRefName", "the PR's base branch, when there is a PR"},
+				Source{"git diff --stat <base>...HEAD", "change size"},
+			),
+			"Check Review Feedback": {{"", "Agent Mail inbox and <task-id>-review thread instructions (nothing is queried)"}},
+			"Protocol":              {templateNote("feedback")},
+		},
+		Changes: []string{postCommentChange, splitChange},
+	},
+	{
+		Name:     "stuck",
+		Summary:  "Gathers recent changes and build errors to debug a problem.",
+		Sections: stuck.Sections,
+		Sources: map[string][]Source{
+			"Current Context": append(append([]Source{branchSource}, taskSources...),
+				Source{"git status --porcelain", "uncommitted changes (not with --range)"},
+				Source{"git rev-parse --git-dir", "detect an unfinished rebase, merge, or cherry-pick"},
+			),
+			"Recent Changes": {
+				{"git diff --cached --stat", "staged changes"},
+				{"git diff --stat", "unstaged changes"},
+				{"git diff HEAD", "the full diff (git diff <good> <bad> with --range)"},
+			},
+			"Recent Commits": {
+				branchCommits,
+				{"git log --oneline <good>..<bad>", "with --range"},
+			},
+			"Detected Errors": {
+				{"go build ./...", "Go projects, followed by go vet ./..."},
+				{"npx tsc --noEmit", "TypeScript projects"},
+				{"python -m py_compile <file>", "changed Python files"},
+				{"git worktree add --detach <tmp> <bad>", "with --range, checks run in a temporary worktree that is removed afterwards"},
+			},
+			"Problem":            {{"", "the description you pass"}},
+			"Debugging Protocol": {templateNote("stuck")},
+		},
+		Changes: []string{
+			"`--range` adds and then removes a temporary git worktree",
+			taskCommentChange,
+			splitChange,
+		},
+	},
+	{
+		Name:     "ralph",
+		Summary:  "Builds an iteration prompt for autonomous loops.",
+		Sections: []string{"Project Context", "Current Objective", "Completion Requirements (CRITICAL)", "Checkpoint Commits", "Iteration Protocol"},
+		Sources: map[string][]Source{
+			"Project Context": {
+				branchSource,
+				{"git status --porcelain", "working tree summary"},
+				{"git log -1 --format=%s (%ar)", "latest commit"},
+			},
+			"Current Objective": {
+				{"bv --robot-triage", "the task to work on, unless --goal is given"},
+				{"bd ready", "fallback when bv is missing or lists nothing"},
+				{"bd show <id>", "confirm the top task is still open, skipping closed ones"},
+			},
+			"Completion Requirements (CRITICAL)": {{"", "the detected or configured test command (not run)"}},
+			"Checkpoint Commits": {
+				{"git log --format=%s <base>..HEAD", "count earlier ralph iterations; the base is the upstream default branch in forks, otherwise main or master, and on the base itself the last 50 commits are scanned"},
+			},
+			"Iteration Protocol": {{"", "the built-in iteration protocol for the mode (single task, --goal, or --autopilot)"}},
+		},
+		Changes: []string{
+			"`--record` appends the targeted task to .vibes/history.jsonl",
+		},
+	},
+	{
+		Name:     "report",
+		Summary:  "Summarizes commits, closed tasks, and pull requests over a period.",
+		Sections: []string{"Summary", "Commits", "Tasks Closed", "Pull Requests"},
+		Sources: map[string][]Source{
+			"Summary": {{"git config user.email", "the author, unless --author is given"}},
+			"Commits": {{"git log --all --no-merges --since=<time> --author=<email>", "commits in the period"}},
+			"Tasks Closed": {
+				{"bd list --status closed --json", "tasks closed in the period; tasks bd has no close date for are kept and flagged"},
+			},
+			"Pull Requests": {
+				{"gh pr list --author @me --state all", "PRs opened or merged in the period"},
+			},
+		},
+	},
+	{
+		Name:     "init-task",
+		Summary:  "Creates a bead and a feature branch, then prints the next prompt for it.",
+		Sections: next.Sections,
+		Sources: map[string][]Source{
+			"Project Context": {
+				branchSource,
+				{"git status --porcelain", "working tree summary"},
+				{"git log -1 --format=%s (%ar)", "latest commit"},
+			},
+			"Recently Closed":  {{"", "not included"}},
+			"Recommended Task": {{"bd show <id>", "the new bead"}},
+			"Protocol":         {templateNote("next")},
+		},
+		Changes: []string{
+			"Creates the bead with `bd create --json -p <priority> -- <title>` (asks first unless --yes)",
+			"Creates and switches to its branch with `git checkout -b <branch>`",
+			splitChange,
+		},
+	},
+}
+
+// Lookup returns the description of the named command.
+func Lookup(name string) (Command, error) {
+	for _, c := range Registry {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return Command{}, fmt.Errorf("no explanation for %q (expected one of: %s)", name, strings.Join(Names(), ", "))
+}
+
+// Names lists the commands that can be explained, sorted.
+func Names() []string {
+	names := make([]string, len(Registry))
+	for i, c := range Registry {
+		names[i] = c.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render describes the command as markdown.
+func Render(c Command) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("# vibes %s\n\n", c.Name))
+	out.WriteString(c.Summary + "\n\n")
+	out.WriteString("Each section lists what fills it. Sections with nothing to show are left out of the prompt.\n\n")
+
+	for _, name := range c.Sections {
+		out.WriteString(fmt.Sprintf("## %s\n", name))
+		for _, s := range c.Sources[name] {
+			if s.Command == "" {
+				out.WriteString(fmt.Sprintf("- %s\n", s.Note))
+				continue
+			}
+			out.WriteString(fmt.Sprintf("- `%s` — %s\n", s.Command, s.Note))
+		}
+		out.WriteString("\n")
+	}
+
+	out.WriteString("## What It Changes\n")
+	if len(c.Changes) == 0 {
+		out.WriteString("Nothing: it only reads from git, beads, and GitHub.\n")
+	}
+	for _, change := range c.Changes {
+		out.WriteString(fmt.Sprintf("- %s\n", change))
+	}
+	return out.String()
+}
+
+// List describes every command in one line each.
+func List() string {
+	var out strings.Builder
+	out.WriteString("Commands vibes can explain:\n\n")
+	for _, name := range Names() {
+		c, _ := Lookup(name)
+		out.WriteString(fmt.Sprintf("- `%s` — %s\n", c.Name, c.Summary))
+	}
+	out.WriteString("\nRun `vibes explain <command>` for what it gathers and runs.\n")
+	return out.String()
+}
@@ -0,0 +1,86 @@
+package explain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range Registry {
+		t.Run(c.Name, func(t *testing.T) {
+			if seen[c.Name] {
+				t.Errorf("duplicate entry")
+			}
+			seen[c.Name] = true
+
+			if c.Summary == "" || len(c.Sections) == 0 {
+				t.Errorf("expected a summary and sections, got %+v", c)
+			}
+			// Catches sections renamed or added in the command without updating its sources
+			for _, section := range c.Sections {
+				if len(c.Sources[section]) == 0 {
+					t.Errorf("section %q has no sources", section)
+				}
+			}
+			for section := range c.Sources {
+				if !contains(c.Sections, section) {
+					t.Errorf("sources for unknown section %q", section)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Run("known command", func(t *testing.T) {
+		c, err := Lookup("stuck")
+		if err != nil || c.Name != "stuck" {
+			t.Errorf("expected stuck, got %+v (err %v)", c, err)
+		}
+	})
+
+	t.Run("unknown command lists the known ones", func(t *testing.T) {
+		_, err := Lookup("setup")
+		if err == nil || !strings.Contains(err.Error(), "next") {
+			t.Errorf("expected error listing commands, got %v", err)
+		}
+	})
+}
+
+func TestRender(t *testing.T) {
+	t.Run("sections in order with their sources", func(t *testing.T) {
+		c, _ := Lookup("next")
+		result := Render(c)
+
+		last := -1
+		for _, section := range c.Sections {
+			i := strings.Index(result, "## "+section+"\n")
+			if i < last {
+				t.Errorf("section %q missing or out of order", section)
+			}
+			last = i
+		}
+		for _, want := range []string{"- `bv --robot-triage` — ranked task listing", "- `--record` appends"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %q in:\n%s", want, result)
+			}
+		}
+	})
+
+	t.Run("read-only command", func(t *testing.T) {
+		c, _ := Lookup("report")
+		if result := Render(c); !strings.Contains(result, "## What It Changes\nNothing:") {
+			t.Errorf("expected read-only note, got:\n%s", result)
+		}
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,23 +1,37 @@
 package feedback
 
 import (
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/runner"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 // Options configures the feedback command behavior
 type Options struct {
-	Dir     string               // Target directory (defaults to cwd)
-	Verbose bool                 // Include full protocol details
-	Runner  runner.CommandRunner // Command runner (defaults to runner.Default)
+	Dir            string               // Target directory (defaults to cwd)
+	Verbose        bool                 // Include full protocol details
+	Thread         string               // Review thread ID (defaults to <task-id>-review)
+	ProjectKey     string               // Agent Mail project key for MCP snippets (defaults to the directory name)
+	ReservationTTL int                  // Seconds in the file reservation snippet (0 = prompt.DefaultReservationTTL)
+	TemplateVars   map[string]string    // Custom variables for protocol templates
+	Output         prompt.Output        // Render or post as a PR comment
+	Tracker        *beads.IssueTracker  // External issue tracker for branches without a bead ID (nil = Beads only)
+	Runner         runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
+// Sections lists every section this command can produce, in output order.
+var Sections = []string{"Current Context", "Recent Commits", "Changes Summary", "Check Review Feedback", "Protocol"}
+
 // Run executes the feedback command and returns the prompt to stdout
 func Run(opts Options) error {
 	dir := opts.Dir
@@ -33,89 +47,98 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
-
-	var out strings.Builder
+	r = git.NewCache(r)
 
 	// Header
 	projectName := filepath.Base(dir)
-	out.WriteString(fmt.Sprintf("# Act on Review Feedback in %s\n\n", projectName))
+	doc := prompt.New(fmt.Sprintf("Act on Review Feedback in %s", projectName), Sections...)
 
 	// Get current branch and task context
 	branch := git.GetCurrentBranch(dir, r)
-	baseBranch := getBaseBranch(dir, r)
-	task := beads.DetectCurrentTask(dir, branch, r)
+	// An existing PR's base is authoritative; detection is only a guess
+	baseBranch := git.BaseBranch(dir, r)
+	prBase := getPRBase(dir, r)
+	if prBase != "" {
+		baseBranch = git.ResolveBase(dir, prBase, r)
+		slog.Info("base branch", "branch", baseBranch, "via", "existing PR")
+	}
+	task := beads.DetectCurrentTask(dir, branch, opts.Tracker, r)
 	task.ProjectName = projectName
+	if opts.ProjectKey != "" {
+		task.ProjectName = opts.ProjectKey
+	}
 
 	// Context section
-	out.WriteString("## Current Context\n")
+	var context strings.Builder
 	if branch != "" {
-		out.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
+		context.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
 	}
 	if task.ID != "" {
 		if task.Title != "" {
-			out.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"\n", task.ID, task.Title))
+			context.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"\n", task.ID, task.Title))
 		} else {
-			out.WriteString(fmt.Sprintf("- **Task**: %s\n", task.ID))
+			context.WriteString(fmt.Sprintf("- **Task**: %s\n", task.Ref()))
 		}
-		out.WriteString(fmt.Sprintf("- **Review Thread**: %s-review\n", task.ID))
+	}
+	if task.ID != "" || opts.Thread != "" {
+		context.WriteString(fmt.Sprintf("- **Review Thread**: %s\n", reviewThread(task, opts.Thread)))
 	}
 
 	// Working tree status
 	status := git.GetWorkingTreeStatus(dir, r)
 	if status != "" {
-		out.WriteString(fmt.Sprintf("- **Working tree**: %s\n", status))
+		context.WriteString(fmt.Sprintf("- **Working tree**: %s\n", status))
 	} else {
-		out.WriteString("- **Working tree**: Clean\n")
+		context.WriteString("- **Working tree**: Clean\n")
 	}
-	out.WriteString("\n")
+	context.WriteString("\n")
+	doc.Add(prompt.Summary, "Current Context", context.String())
 
 	// Recent commits on branch
-	commits := git.GetBranchCommits(dir, branch, r)
+	var commits string
+	if prBase != "" {
+		commits = git.GetCommitsSince(dir, baseBranch, r)
+	} else {
+		commits = git.GetBranchCommits(dir, branch, r)
+	}
 	if commits != "" {
-		out.WriteString("## Recent Commits\n")
-		out.WriteString("```\n")
-		out.WriteString(commits)
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Recent Commits", "```\n"+commits+"\n```\n\n")
 	}
 
 	// Changes since base branch
 	diffStats := getDiffStats(dir, baseBranch, r)
 	if diffStats != "" {
-		out.WriteString("## Changes Summary\n")
-		out.WriteString(fmt.Sprintf("- **Base**: %s\n", baseBranch))
-		out.WriteString(fmt.Sprintf("- **Stats**: %s\n", diffStats))
-		out.WriteString("\n")
+		doc.Add(prompt.Summary, "Changes Summary", fmt.Sprintf("- **Base**: %s\n- **Stats**: %s\n\n", baseBranch, diffStats))
 	}
 
 	// Inbox hint
-	out.WriteString("## Check Review Feedback\n")
-	out.WriteString(getInboxHint(task, opts.Verbose))
-	out.WriteString("\n")
+	doc.Add(prompt.Context, "Check Review Feedback", getInboxHint(task, opts.Thread, opts.Verbose)+"\n")
 
 	// Protocol
-	out.WriteString("## Protocol\n")
-	out.WriteString(getProtocol(task, opts.Verbose))
+	vars := templates.Merge(templates.Builtins(task.ID, projectName, branch), opts.TemplateVars)
+	protocol, err := templates.Protocol(dir, "feedback", getProtocol(task, opts.Thread, opts.Verbose, opts.ReservationTTL), vars)
+	if err != nil {
+		return err
+	}
+	doc.Add(prompt.Protocol, "Protocol", protocol)
 
-	fmt.Print(out.String())
-	return nil
+	return prompt.Emit(dir, doc, opts.Output, r)
 }
 
-// getBaseBranch determines the base branch (main or master)
-func getBaseBranch(dir string, r runner.CommandRunner) string {
-	// Check if main exists
-	_, err := r.Run(dir, "git", "rev-parse", "--verify", "main")
-	if err == nil {
-		return "main"
+// getPRBase returns the base branch of the current branch's pull request,
+// or empty string if there is none or gh is unavailable.
+func getPRBase(dir string, r runner.CommandRunner) string {
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "view", "--json", "baseRefName")
+	if err != nil || output == "" {
+		return ""
 	}
-
-	// Check if master exists
-	_, err = r.Run(dir, "git", "rev-parse", "--verify", "master")
-	if err == nil {
-		return "master"
+	var pr struct {
+		Base string `json:"baseRefName"`
 	}
-
-	// Default to main
-	return "main"
+	if err := json.Unmarshal([]byte(output), &pr); err != nil {
+		return ""
+	}
+	return pr.Base
 }
 
 // getDiffStats returns a summary of the diff (files changed, insertions, deletions)
@@ -135,11 +158,19 @@ func getDiffStats(dir string, baseBranch string, r runner.CommandRunner) string
 	return strings.TrimSpace(summary)
 }
 
-func getInboxHint(task beads.TaskInfo, verbose bool) string {
-	threadID := "<task-id>-review"
+// reviewThread returns the review thread ID, preferring an explicit override
+func reviewThread(task beads.TaskInfo, override string) string {
+	if override != "" {
+		return override
+	}
 	if task.ID != "" {
-		threadID = task.ID + "-review"
+		return task.ID + "-review"
 	}
+	return "<task-id>-review"
+}
+
+func getInboxHint(task beads.TaskInfo, thread string, verbose bool) string {
+	threadID := reviewThread(task, thread)
 
 	projectKey := task.ProjectName
 	if projectKey == "" {
@@ -173,11 +204,12 @@ Look for feedback categories:
 `, threadID)
 }
 
-func getProtocol(task beads.TaskInfo, verbose bool) string {
+func getProtocol(task beads.TaskInfo, thread string, verbose bool, ttl int) string {
 	taskID := task.ID
 	if taskID == "" {
 		taskID = "<task-id>"
 	}
+	threadID := reviewThread(task, thread)
 
 	projectKey := task.ProjectName
 	if projectKey == "" {
@@ -201,7 +233,7 @@ func getProtocol(task beads.TaskInfo, verbose bool) string {
        project_key="%s",
        agent_name="YourAgentIdentity",
        patterns=["<your-file-patterns>"],
-       ttl_seconds=3600,
+       ttl_seconds=%d,
        exclusive=true
    )
    `+"```"+`
@@ -225,7 +257,7 @@ func getProtocol(task beads.TaskInfo, verbose bool) string {
    send_message(
        project_key="%s",
        from_agent="YourAgentIdentity",
-       thread_id="%s-review",
+       thread_id="%s",
        subject="Review Feedback Addressed",
        body="All items addressed. Ready for re-review."
    )
@@ -239,10 +271,10 @@ func getProtocol(task beads.TaskInfo, verbose bool) string {
    `+"```"+`
 
 Address the review feedback now.
-`, projectKey, taskID, projectKey, taskID)
+`, projectKey, prompt.ReservationTTL(ttl), taskID, projectKey, threadID)
 	}
 
-	return fmt.Sprintf(`1. Retrieve feedback from %s-review thread
+	return fmt.Sprintf(`1. Retrieve feedback from %s thread
 2. Triage: blocking > suggestions > questions > nitpicks
 3. Re-reserve files if needed
 4. Fix blocking issues first
@@ -252,5 +284,5 @@ Address the review feedback now.
 8. When approved: `+"`claude \"$(vibes pr)\"`"+`
 
 Address the review feedback now.
-`, taskID)
+`, threadID)
 }
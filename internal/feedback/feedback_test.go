@@ -1,11 +1,14 @@
 package feedback
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/prompt"
 )
 
 // MockRunner is a mock implementation of runner.CommandRunner for testing
@@ -32,7 +35,7 @@ func TestGetProtocol(t *testing.T) {
 	task := beads.TaskInfo{ID: "bd-123", Title: "Test task", Branch: "feature/test", ProjectName: "my-project"}
 
 	t.Run("non-verbose protocol", func(t *testing.T) {
-		result := getProtocol(task, false)
+		result := getProtocol(task, "", false, 0)
 
 		if !strings.Contains(result, "bd-123-review") {
 			t.Error("expected task ID review thread reference")
@@ -46,7 +49,7 @@ func TestGetProtocol(t *testing.T) {
 	})
 
 	t.Run("verbose protocol", func(t *testing.T) {
-		result := getProtocol(task, true)
+		result := getProtocol(task, "", true, 0)
 
 		if !strings.Contains(result, "**Retrieve review feedback**") {
 			t.Error("expected bold headers in verbose mode")
@@ -67,7 +70,7 @@ func TestGetProtocol(t *testing.T) {
 
 	t.Run("uses placeholder when no task ID", func(t *testing.T) {
 		emptyTask := beads.TaskInfo{}
-		result := getProtocol(emptyTask, false)
+		result := getProtocol(emptyTask, "", false, 0)
 
 		if !strings.Contains(result, "<task-id>-review") {
 			t.Error("expected placeholder when no task ID")
@@ -76,19 +79,45 @@ func TestGetProtocol(t *testing.T) {
 
 	t.Run("uses default project-name when no project name", func(t *testing.T) {
 		taskNoProject := beads.TaskInfo{ID: "bd-456"}
-		result := getProtocol(taskNoProject, true)
+		result := getProtocol(taskNoProject, "", true, 0)
 
 		if !strings.Contains(result, "project_key=\"project-name\"") {
 			t.Error("expected default project-name when no project name set")
 		}
 	})
+
+	t.Run("thread override propagates", func(t *testing.T) {
+		for _, verbose := range []bool{false, true} {
+			result := getProtocol(task, "team/auth-review", verbose, 0)
+
+			if !strings.Contains(result, "team/auth-review") {
+				t.Errorf("verbose=%v: expected override thread, got: %s", verbose, result)
+			}
+			if strings.Contains(result, "bd-123-review") {
+				t.Errorf("verbose=%v: expected computed thread to be replaced, got: %s", verbose, result)
+			}
+		}
+
+		if result := getProtocol(task, "team/auth-review", true, 0); !strings.Contains(result, `thread_id="team/auth-review"`) {
+			t.Errorf("expected override in send_message snippet, got: %s", result)
+		}
+	})
+
+	t.Run("reservation TTL", func(t *testing.T) {
+		if result := getProtocol(task, "", true, 0); !strings.Contains(result, "ttl_seconds=3600,") {
+			t.Errorf("expected default TTL, got: %s", result)
+		}
+		if result := getProtocol(task, "", true, 900); !strings.Contains(result, "ttl_seconds=900,") {
+			t.Errorf("expected TTL override, got: %s", result)
+		}
+	})
 }
 
 func TestGetInboxHint(t *testing.T) {
 	task := beads.TaskInfo{ID: "bd-123", ProjectName: "my-project"}
 
 	t.Run("non-verbose hint", func(t *testing.T) {
-		result := getInboxHint(task, false)
+		result := getInboxHint(task, "", false)
 
 		if !strings.Contains(result, "resource://inbox/YourAgentIdentity") {
 			t.Error("expected inbox resource reference")
@@ -99,7 +128,7 @@ func TestGetInboxHint(t *testing.T) {
 	})
 
 	t.Run("verbose hint", func(t *testing.T) {
-		result := getInboxHint(task, true)
+		result := getInboxHint(task, "", true)
 
 		if !strings.Contains(result, "get_thread_messages") {
 			t.Error("expected get_thread_messages function")
@@ -114,12 +143,23 @@ func TestGetInboxHint(t *testing.T) {
 
 	t.Run("uses placeholder when no task ID", func(t *testing.T) {
 		emptyTask := beads.TaskInfo{}
-		result := getInboxHint(emptyTask, false)
+		result := getInboxHint(emptyTask, "", false)
 
 		if !strings.Contains(result, "<task-id>-review") {
 			t.Error("expected placeholder thread ID")
 		}
 	})
+
+	t.Run("thread override propagates", func(t *testing.T) {
+		result := getInboxHint(task, "team/auth-review", true)
+
+		if !strings.Contains(result, `thread_id="team/auth-review"`) {
+			t.Errorf("expected override in get_thread_messages snippet, got: %s", result)
+		}
+		if strings.Contains(result, "bd-123-review") {
+			t.Errorf("expected computed thread to be replaced, got: %s", result)
+		}
+	})
 }
 
 func TestRun(t *testing.T) {
@@ -161,6 +201,43 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("existing PR base overrides detection", func(t *testing.T) {
+		var ranges []string
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if args[0] == "rev-parse" && args[1] == "--abbrev-ref" {
+					return "feature/bd-123-test", nil
+				}
+				if args[0] == "rev-parse" && args[1] == "--verify" {
+					return "abc123", nil
+				}
+				if args[0] == "log" || args[0] == "diff" {
+					ranges = append(ranges, args[len(args)-1])
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "gh" && args[1] == "view" {
+					return `{"baseRefName":"develop"}`, nil
+				}
+				return "", nil
+			},
+		}
+
+		if err := Run(Options{Dir: t.TempDir(), Runner: mock}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, want := range []string{"develop...HEAD", "develop..HEAD"} {
+			found := false
+			for _, rng := range ranges {
+				found = found || rng == want
+			}
+			if !found {
+				t.Errorf("expected range %s, got %v", want, ranges)
+			}
+		}
+	})
+
 	t.Run("verbose mode", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -195,24 +272,6 @@ func TestRun(t *testing.T) {
 	})
 }
 
-func TestGetBaseBranch(t *testing.T) {
-	t.Run("returns main when main exists", func(t *testing.T) {
-		mock := &MockRunner{
-			RunFunc: func(dir string, command string, args ...string) (string, error) {
-				if command == "git" && len(args) >= 2 && args[1] == "main" {
-					return "main", nil
-				}
-				return "", nil
-			},
-		}
-
-		result := getBaseBranch("/tmp", mock)
-		if result != "main" {
-			t.Errorf("expected main, got %s", result)
-		}
-	})
-}
-
 func TestGetDiffStats(t *testing.T) {
 	t.Run("returns summary line", func(t *testing.T) {
 		mock := &MockRunner{
@@ -240,3 +299,28 @@ func TestGetDiffStats(t *testing.T) {
 		}
 	})
 }
+
+func TestRunProjectKey(t *testing.T) {
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			if command == "git" && len(args) >= 2 && args[0] == "rev-parse" && args[1] == "--abbrev-ref" {
+				return "feature/bd-123-test", nil
+			}
+			return "", nil
+		},
+	}
+
+	out := t.TempDir()
+	opts := Options{Dir: t.TempDir(), Verbose: true, ProjectKey: "acme-api", Output: prompt.Output{OutputDir: out}, Runner: mock}
+	if err := Run(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	system, err := os.ReadFile(filepath.Join(out, prompt.SystemFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(system), `project_key="acme-api"`) {
+		t.Errorf("expected project key override in MCP snippets, got:\n%s", system)
+	}
+}
@@ -0,0 +1,28 @@
+package git
+
+import "strings"
+
+// WorkBranchPrefixes are the conventional prefixes of short-lived work branches.
+var WorkBranchPrefixes = []string{"feature/", "fix/", "bugfix/", "hotfix/", "chore/"}
+
+// longLivedBranches are shared branches that work is merged into rather than done on.
+var longLivedBranches = map[string]bool{"main": true, "master": true, "develop": true, "trunk": true}
+
+// IsWorkBranch reports whether branch is one a PR would be opened from.
+// Branches with a conventional work prefix always are; otherwise the default
+// branch, long-lived branches such as develop, and release/ branches are not.
+// defaultBranch may include a remote, e.g. "upstream/main".
+func IsWorkBranch(branch string, defaultBranch string) bool {
+	if branch == "" {
+		return false
+	}
+	for _, prefix := range WorkBranchPrefixes {
+		if strings.HasPrefix(branch, prefix) {
+			return true
+		}
+	}
+	if branch == defaultBranch || branch == TrimRemote(defaultBranch) {
+		return false
+	}
+	return !longLivedBranches[branch] && !strings.HasPrefix(branch, "release/")
+}
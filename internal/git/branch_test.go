@@ -0,0 +1,35 @@
+package git
+
+import "testing"
+
+func TestIsWorkBranch(t *testing.T) {
+	testCases := []struct {
+		branch   string
+		base     string
+		expected bool
+	}{
+		{"feature/bd-12-login", "main", true},
+		{"fix/null-pointer", "main", true},
+		{"bugfix/bd-7", "main", true},
+		{"hotfix/1.2.1", "main", true},
+		{"chore/bump-deps", "main", true},
+		{"bd-12-login", "main", true},
+		{"alice/experiment", "main", true},
+		{"main", "main", false},
+		{"main", "upstream/main", false},
+		{"master", "main", false},
+		{"develop", "main", false},
+		{"trunk", "main", false},
+		{"release/1.2", "main", false},
+		{"staging", "staging", false},
+		{"", "main", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.branch+" onto "+tc.base, func(t *testing.T) {
+			if got := IsWorkBranch(tc.branch, tc.base); got != tc.expected {
+				t.Errorf("IsWorkBranch(%q, %q) = %v, expected %v", tc.branch, tc.base, got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// readOnlyCommands are git subcommands whose results are safe to reuse within
+// one vibes invocation.
+var readOnlyCommands = map[string]bool{
+	"rev-parse":  true,
+	"log":        true,
+	"status":     true,
+	"diff":       true,
+	"show":       true,
+	"merge-base": true,
+	"rev-list":   true,
+	"ls-files":   true,
+}
+
+// Cache wraps a CommandRunner and memoizes read-only git commands and gh API
+// queries, keyed by directory and arguments. Create one per command invocation.
+// Any other git command (e.g. fetch) runs uncached and clears the cache,
+// since it may change what later reads return.
+type Cache struct {
+	runner  runner.CommandRunner
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	output string
+	err    error
+}
+
+// NewCache returns a Cache backed by r.
+func NewCache(r runner.CommandRunner) *Cache {
+	return &Cache{runner: r, entries: map[string]cacheEntry{}}
+}
+
+// Run executes a command, returning a memoized result for repeated read-only git calls.
+func (c *Cache) Run(dir string, command string, args ...string) (string, error) {
+	return c.lookup(dir, command, args, func() (string, error) {
+		return c.runner.Run(dir, command, args...)
+	})
+}
+
+// RunWithTimeout executes a command with a timeout, sharing the cache with Run.
+func (c *Cache) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+	return c.lookup(dir, command, args, func() (string, error) {
+		return c.runner.RunWithTimeout(dir, timeout, command, args...)
+	})
+}
+
+func (c *Cache) lookup(dir, command string, args []string, run func() (string, error)) (string, error) {
+	if command == "gh" && isGHQuery(args) {
+		return c.memoize(command, dir, args, run)
+	}
+	if command != "git" {
+		return run()
+	}
+
+	if len(args) == 0 || !isReadOnly(args) {
+		c.mu.Lock()
+		c.entries = map[string]cacheEntry{}
+		c.mu.Unlock()
+		return run()
+	}
+
+	return c.memoize(command, dir, args, run)
+}
+
+func (c *Cache) memoize(command, dir string, args []string, run func() (string, error)) (string, error) {
+	key := command + "\x00" + dir + "\x00" + strings.Join(args, "\x00")
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		slog.Debug("cache hit", "cmd", strings.TrimSpace(command+" "+strings.Join(args, " ")))
+		return entry.output, entry.err
+	}
+
+	output, err := run()
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{output: output, err: err}
+	c.mu.Unlock()
+	return output, err
+}
+
+// isReadOnly reports whether git args only read repository state.
+func isReadOnly(args []string) bool {
+	switch args[0] {
+	case "stash":
+		return len(args) > 1 && args[1] == "list"
+	case "config":
+		// A single key reads the value; a key and value writes it
+		return len(args) == 2 || (len(args) == 3 && args[1] == "--get")
+	case "remote":
+		// Bare `git remote` lists; add, remove, set-url and friends write
+		return len(args) == 1 || args[1] == "-v" || args[1] == "--verbose" || args[1] == "get-url"
+	case "symbolic-ref":
+		// One ref reads it; a ref and target, or --delete, writes
+		positional := 0
+		for _, a := range args[1:] {
+			if a == "-d" || a == "--delete" {
+				return false
+			}
+			if !strings.HasPrefix(a, "-") {
+				positional++
+			}
+		}
+		return positional <= 1
+	case "branch":
+		// Only the listing forms; plain `git branch <name>` creates one
+		for _, a := range args[1:] {
+			if a == "--list" || strings.HasPrefix(a, "--format") || strings.HasPrefix(a, "--merged") {
+				return true
+			}
+		}
+		return false
+	}
+	return readOnlyCommands[args[0]]
+}
+
+// isGHQuery reports whether gh args are a plain GET against the API.
+func isGHQuery(args []string) bool {
+	if len(args) < 2 || args[0] != "api" {
+		return false
+	}
+	for _, a := range args[1:] {
+		switch {
+		case a == "-X", a == "--method", strings.HasPrefix(a, "--method="),
+			a == "-f", a == "-F", a == "--field", a == "--raw-field", a == "--input":
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package git
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	newCountingRunner := func(calls *int) *MockRunner {
+		return &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				*calls++
+				if args[0] == "rev-parse" && args[len(args)-1] == "missing" {
+					return "", errors.New("unknown revision")
+				}
+				return "feature/bd-123", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				*calls++
+				return "", nil
+			},
+		}
+	}
+
+	t.Run("repeated read-only call hits cache", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		first := GetCurrentBranch("/repo", c)
+		second := GetCurrentBranch("/repo", c)
+
+		if first != "feature/bd-123" || second != first {
+			t.Errorf("unexpected results %q, %q", first, second)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("errors are cached too", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		for i := 0; i < 2; i++ {
+			if _, err := c.Run("/repo", "git", "rev-parse", "--verify", "missing"); err == nil {
+				t.Error("expected error")
+			}
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("keyed by dir and args", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		c.Run("/repo", "git", "log", "-5", "--oneline")
+		c.Run("/other", "git", "log", "-5", "--oneline")
+		c.Run("/repo", "git", "log", "-1", "--oneline")
+
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("fetch bypasses and clears cache", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		c.Run("/repo", "git", "status", "-sb")
+		c.RunWithTimeout("/repo", time.Second, "git", "fetch", "--quiet")
+		c.RunWithTimeout("/repo", time.Second, "git", "fetch", "--quiet")
+		c.Run("/repo", "git", "status", "-sb")
+
+		if calls != 4 {
+			t.Errorf("expected 4 calls, got %d", calls)
+		}
+	})
+
+	t.Run("non-git commands are not cached", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		c.Run("/repo", "bd", "list")
+		c.Run("/repo", "bd", "list")
+
+		if calls != 2 {
+			t.Errorf("expected 2 calls, got %d", calls)
+		}
+	})
+
+	t.Run("only stash list is cached", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		c.Run("/repo", "git", "stash", "list")
+		c.Run("/repo", "git", "stash", "list")
+		if calls != 1 {
+			t.Errorf("expected 1 call for stash list, got %d", calls)
+		}
+
+		c.Run("/repo", "git", "stash", "pop")
+		c.Run("/repo", "git", "stash", "list")
+		if calls != 3 {
+			t.Errorf("expected stash pop to clear cache, got %d calls", calls)
+		}
+	})
+
+	t.Run("config reads are cached, writes clear", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		c.Run("/repo", "git", "config", "user.email")
+		c.Run("/repo", "git", "config", "user.email")
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+
+		c.Run("/repo", "git", "config", "user.email", "dev@work.com")
+		c.Run("/repo", "git", "config", "user.email")
+		if calls != 3 {
+			t.Errorf("expected config write to clear cache, got %d calls", calls)
+		}
+	})
+
+	t.Run("remote, symbolic-ref and branch listings are cached", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		for i := 0; i < 2; i++ {
+			Remotes("/repo", c)
+			c.Run("/repo", "git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+			c.Run("/repo", "git", "branch", "--format=%(refname:short)", "--merged", "HEAD")
+			c.Run("/repo", "git", "branch", "--list", "feature/*")
+		}
+		if calls != 4 {
+			t.Errorf("expected 4 calls, got %d", calls)
+		}
+	})
+
+	t.Run("remote, symbolic-ref and branch writes clear", func(t *testing.T) {
+		writes := [][]string{
+			{"remote", "add", "upstream", "https://example.com/repo.git"},
+			{"symbolic-ref", "HEAD", "refs/heads/main"},
+			{"symbolic-ref", "--delete", "refs/remotes/origin/HEAD"},
+			{"branch", "feature/new"},
+			{"branch", "-D", "feature/old"},
+		}
+		for _, write := range writes {
+			calls := 0
+			c := NewCache(newCountingRunner(&calls))
+
+			c.Run("/repo", "git", "remote")
+			c.Run("/repo", "git", write...)
+			c.Run("/repo", "git", write...)
+			c.Run("/repo", "git", "remote")
+			if calls != 4 {
+				t.Errorf("expected git %v to bypass and clear cache, got %d calls", write, calls)
+			}
+		}
+	})
+
+	t.Run("gh api queries are cached, mutations are not", func(t *testing.T) {
+		calls := 0
+		c := NewCache(newCountingRunner(&calls))
+
+		c.RunWithTimeout("/repo", time.Second, "gh", "api", "user")
+		c.RunWithTimeout("/repo", time.Second, "gh", "api", "user")
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+
+		c.RunWithTimeout("/repo", time.Second, "gh", "api", "-X", "POST", "repos/o/r/issues")
+		c.RunWithTimeout("/repo", time.Second, "gh", "api", "-X", "POST", "repos/o/r/issues")
+		if calls != 3 {
+			t.Errorf("expected mutations to bypass cache, got %d calls", calls)
+		}
+	})
+}
@@ -2,7 +2,9 @@
 package git
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
@@ -10,6 +12,10 @@ import (
 	"github.com/vibes-project/vibes/internal/runner"
 )
 
+// ErrNotGitRepo is returned when a command needs a git repository and the
+// directory isn't in one.
+var ErrNotGitRepo = errors.New("not a git repository")
+
 // StatusCounts holds counts of different file states in the working tree.
 type StatusCounts struct {
 	Staged    int
@@ -134,11 +140,117 @@ type RemoteStatus struct {
 	Ahead  int
 	Behind int
 	Info   string // e.g., "ahead 2", "behind 3", "ahead 1, behind 2"
+	// NoRemote is set when the repository has no remote configured
+	NoRemote bool
+}
+
+// HasRemote returns true if the repository has at least one remote configured.
+func HasRemote(dir string, r runner.CommandRunner) bool {
+	output, err := r.Run(dir, "git", "remote")
+	return err == nil && strings.TrimSpace(output) != ""
+}
+
+// RemotePreference orders remotes by how likely their default branch is the
+// base for new work: in a fork, upstream is the project and origin the fork.
+var RemotePreference = []string{"upstream", "origin"}
+
+// Remotes returns the names of the configured remotes.
+func Remotes(dir string, r runner.CommandRunner) []string {
+	output, err := r.Run(dir, "git", "remote")
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(output)
+}
+
+// GetDefaultBranch returns the default branch of the most preferred remote
+// that has one recorded, e.g. "upstream/main", or empty string if none does.
+// Remotes default to RemotePreference.
+func GetDefaultBranch(dir string, r runner.CommandRunner, remotes ...string) string {
+	if len(remotes) == 0 {
+		remotes = RemotePreference
+	}
+
+	configured := map[string]bool{}
+	for _, name := range Remotes(dir, r) {
+		configured[name] = true
+	}
+
+	for _, remote := range remotes {
+		if !configured[remote] {
+			continue
+		}
+		output, err := r.Run(dir, "git", "symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD")
+		if err == nil && strings.TrimSpace(output) != "" {
+			return strings.TrimSpace(output)
+		}
+	}
+	return ""
+}
+
+// BaseBranch determines the branch new work is based on: the upstream (or
+// origin) default branch in multi-remote repos, otherwise main or master.
+// Defaults to main when neither exists.
+func BaseBranch(dir string, r runner.CommandRunner) string {
+	// With a fork and its upstream, local main may track either one
+	if len(Remotes(dir, r)) > 1 {
+		if base := GetDefaultBranch(dir, r); base != "" {
+			slog.Info("base branch", "branch", base, "via", "remote HEAD")
+			return base
+		}
+	}
+
+	for _, name := range []string{"main", "master"} {
+		if _, err := r.Run(dir, "git", "rev-parse", "--verify", name); err == nil {
+			slog.Info("base branch", "branch", name, "via", name+" exists")
+			return name
+		}
+	}
+
+	slog.Info("base branch", "branch", "main", "via", "default (neither main nor master found)")
+	return "main"
+}
+
+// TrimRemote strips a preferred remote's prefix from a ref, turning
+// "upstream/main" into the branch name tools like gh expect.
+func TrimRemote(ref string) string {
+	for _, remote := range RemotePreference {
+		if name, ok := strings.CutPrefix(ref, remote+"/"); ok {
+			return name
+		}
+	}
+	return ref
+}
+
+// ResolveBase returns a ref for the named base branch that can be used in
+// ranges: the local branch if it exists, otherwise its origin counterpart.
+func ResolveBase(dir string, name string, r runner.CommandRunner) string {
+	if _, err := r.Run(dir, "git", "rev-parse", "--verify", "--quiet", name); err == nil {
+		return name
+	}
+	if _, err := r.Run(dir, "git", "rev-parse", "--verify", "--quiet", "origin/"+name); err == nil {
+		return "origin/" + name
+	}
+	return name
+}
+
+// GetCommitsSince returns the one-line log of commits in base..HEAD.
+func GetCommitsSince(dir string, base string, r runner.CommandRunner) string {
+	output, err := r.Run(dir, "git", "log", "--oneline", base+"..HEAD")
+	if err != nil {
+		return ""
+	}
+	return output
 }
 
 // CheckRemoteStatus checks if the branch is ahead/behind the remote.
-// If fetch is true, fetches from remote first.
+// If fetch is true, fetches from remote first. Local-only repositories
+// are reported with NoRemote and skip the fetch entirely.
 func CheckRemoteStatus(dir string, r runner.CommandRunner, fetch bool) RemoteStatus {
+	if !HasRemote(dir, r) {
+		return RemoteStatus{NoRemote: true}
+	}
+
 	if fetch {
 		// Fetch with timeout to avoid hanging
 		_, _ = r.RunWithTimeout(dir, 5*time.Second, "git", "fetch", "--quiet")
@@ -197,6 +197,9 @@ func TestCheckRemoteStatus(t *testing.T) {
 	t.Run("detects behind remote", func(t *testing.T) {
 		mock := &MockRunner{
 			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && len(args) >= 1 && args[0] == "remote" {
+					return "origin", nil
+				}
 				if command == "git" && len(args) >= 1 && args[0] == "status" {
 					return "## feature/test...origin/feature/test [behind 3]", nil
 				}
@@ -219,6 +222,9 @@ func TestCheckRemoteStatus(t *testing.T) {
 	t.Run("detects ahead of remote", func(t *testing.T) {
 		mock := &MockRunner{
 			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && len(args) >= 1 && args[0] == "remote" {
+					return "origin", nil
+				}
 				if command == "git" && len(args) >= 1 && args[0] == "status" {
 					return "## feature/test...origin/feature/test [ahead 2]", nil
 				}
@@ -238,6 +244,9 @@ func TestCheckRemoteStatus(t *testing.T) {
 	t.Run("detects ahead and behind", func(t *testing.T) {
 		mock := &MockRunner{
 			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && len(args) >= 1 && args[0] == "remote" {
+					return "origin", nil
+				}
 				if command == "git" && len(args) >= 1 && args[0] == "status" {
 					return "## feature/test...origin/feature/test [ahead 1, behind 2]", nil
 				}
@@ -252,6 +261,173 @@ func TestCheckRemoteStatus(t *testing.T) {
 	})
 }
 
+func TestCheckRemoteStatusNoRemote(t *testing.T) {
+	fetched := false
+	statusChecked := false
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			if args[0] == "status" {
+				statusChecked = true
+			}
+			return "", nil
+		},
+		RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			if args[0] == "fetch" {
+				fetched = true
+			}
+			return "", nil
+		},
+	}
+
+	if HasRemote("/test/dir", mock) {
+		t.Error("expected no remote")
+	}
+
+	result := CheckRemoteStatus("/test/dir", mock, true)
+	if !result.NoRemote {
+		t.Error("expected NoRemote to be set")
+	}
+	if fetched || statusChecked {
+		t.Errorf("expected fetch and status to be skipped, got fetch=%v status=%v", fetched, statusChecked)
+	}
+}
+
+func TestGetDefaultBranch(t *testing.T) {
+	// remoteMock reports the given remotes, each with a recorded HEAD
+	remoteMock := func(remotes ...string) *MockRunner {
+		return &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				switch args[0] {
+				case "remote":
+					return strings.Join(remotes, "\n"), nil
+				case "symbolic-ref":
+					for _, remote := range remotes {
+						if args[2] == "refs/remotes/"+remote+"/HEAD" {
+							return remote + "/main", nil
+						}
+					}
+					return "", errors.New("not a symbolic ref")
+				}
+				return "", nil
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		remotes  []string
+		expected string
+	}{
+		{"origin only", []string{"origin"}, "origin/main"},
+		{"origin and upstream prefers upstream", []string{"origin", "upstream"}, "upstream/main"},
+		{"unknown remotes", []string{"fork"}, ""},
+		{"no remotes", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := GetDefaultBranch("/repo", remoteMock(tc.remotes...)); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("explicit preference order", func(t *testing.T) {
+		if got := GetDefaultBranch("/repo", remoteMock("origin", "upstream"), "origin", "upstream"); got != "origin/main" {
+			t.Errorf("expected origin/main, got %q", got)
+		}
+	})
+}
+
+func TestBaseBranch(t *testing.T) {
+	t.Run("returns main when main exists", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && len(args) >= 3 && args[2] == "main" {
+					return "abc123", nil
+				}
+				return "", nil
+			},
+		}
+
+		result := BaseBranch("/test", mock)
+		if result != "main" {
+			t.Errorf("expected main, got %s", result)
+		}
+	})
+
+	t.Run("returns master when main doesn't exist", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && len(args) >= 3 && args[2] == "main" {
+					return "", errors.New("unknown revision")
+				}
+				if command == "git" && len(args) >= 3 && args[2] == "master" {
+					return "abc123", nil
+				}
+				return "", nil
+			},
+		}
+
+		result := BaseBranch("/test", mock)
+		if result != "master" {
+			t.Errorf("expected master, got %s", result)
+		}
+	})
+
+	t.Run("defaults to main when neither exists", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return "", errors.New("unknown revision")
+			},
+		}
+
+		result := BaseBranch("/test", mock)
+		if result != "main" {
+			t.Errorf("expected main as default, got %s", result)
+		}
+	})
+
+	remotesMock := func(remotes string) *MockRunner {
+		return &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				switch args[0] {
+				case "remote":
+					return remotes, nil
+				case "symbolic-ref":
+					return strings.TrimPrefix(strings.TrimSuffix(args[2], "/HEAD"), "refs/remotes/") + "/main", nil
+				}
+				return "abc123", nil
+			},
+		}
+	}
+
+	t.Run("origin only uses local main", func(t *testing.T) {
+		if result := BaseBranch("/test", remotesMock("origin")); result != "main" {
+			t.Errorf("expected main, got %s", result)
+		}
+	})
+
+	t.Run("fork with upstream uses upstream's default branch", func(t *testing.T) {
+		if result := BaseBranch("/test", remotesMock("origin\nupstream")); result != "upstream/main" {
+			t.Errorf("expected upstream/main, got %s", result)
+		}
+	})
+}
+
+func TestTrimRemote(t *testing.T) {
+	for ref, expected := range map[string]string{
+		"upstream/main":  "main",
+		"origin/develop": "develop",
+		"main":           "main",
+		"feature/login":  "feature/login",
+	} {
+		if got := TrimRemote(ref); got != expected {
+			t.Errorf("TrimRemote(%q): expected %q, got %q", ref, expected, got)
+		}
+	}
+}
+
 func TestCountLines(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// noreplyDomain is the host GitHub uses for private commit email addresses.
+const noreplyDomain = "@users.noreply.github.com"
+
+// CheckIdentity compares the configured commit email with the emails of the
+// authenticated gh account. Returns a warning when they don't match, or empty
+// string when they match or either identity can't be determined.
+func CheckIdentity(dir string, r runner.CommandRunner) string {
+	email, err := r.Run(dir, "git", "config", "user.email")
+	if err != nil || email == "" {
+		return ""
+	}
+
+	login, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "api", "user", "--jq", ".login")
+	if err != nil || login == "" {
+		return ""
+	}
+
+	if isNoreplyFor(email, login) {
+		return ""
+	}
+
+	// Listing emails needs the user:email scope; without it we can't tell
+	emails, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "api", "user/emails", "--jq", ".[].email")
+	if err != nil {
+		return ""
+	}
+	for _, e := range strings.Split(emails, "\n") {
+		if strings.EqualFold(strings.TrimSpace(e), email) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("⚠️ Committing as %s but authenticated gh account is %s", email, login)
+}
+
+// isNoreplyFor reports whether email is the GitHub noreply address for login,
+// either "login@..." or "12345+login@...".
+func isNoreplyFor(email, login string) bool {
+	lower := strings.ToLower(email)
+	if !strings.HasSuffix(lower, noreplyDomain) {
+		return false
+	}
+	local := strings.TrimSuffix(lower, noreplyDomain)
+	if _, name, ok := strings.Cut(local, "+"); ok {
+		local = name
+	}
+	return local == strings.ToLower(login)
+}
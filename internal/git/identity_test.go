@@ -0,0 +1,100 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func identityRunner(email, login, emails string, emailsErr error) *MockRunner {
+	return &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			if command == "git" && args[0] == "config" {
+				return email, nil
+			}
+			return "", nil
+		},
+		RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			if command != "gh" {
+				return "", nil
+			}
+			switch args[1] {
+			case "user":
+				return login, nil
+			case "user/emails":
+				return emails, emailsErr
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestCheckIdentity(t *testing.T) {
+	t.Run("matching email", func(t *testing.T) {
+		mock := identityRunner("Dev@Work.com", "dev-work", "dev@personal.com\ndev@work.com", nil)
+		if result := CheckIdentity("/repo", mock); result != "" {
+			t.Errorf("expected no warning, got %q", result)
+		}
+	})
+
+	t.Run("mismatched email", func(t *testing.T) {
+		mock := identityRunner("dev@personal.com", "dev-work", "dev@work.com", nil)
+		result := CheckIdentity("/repo", mock)
+		expected := "⚠️ Committing as dev@personal.com but authenticated gh account is dev-work"
+		if result != expected {
+			t.Errorf("expected %q, got %q", expected, result)
+		}
+	})
+
+	t.Run("noreply address for login", func(t *testing.T) {
+		for _, email := range []string{"12345+dev-work@users.noreply.github.com", "dev-work@users.noreply.github.com"} {
+			mock := identityRunner(email, "dev-work", "", errors.New("should not be called"))
+			if result := CheckIdentity("/repo", mock); result != "" {
+				t.Errorf("expected %s to match, got %q", email, result)
+			}
+		}
+	})
+
+	t.Run("noreply address for another login", func(t *testing.T) {
+		mock := identityRunner("12345+other@users.noreply.github.com", "dev-work", "dev@work.com", nil)
+		if result := CheckIdentity("/repo", mock); !strings.Contains(result, "Committing as") {
+			t.Errorf("expected warning, got %q", result)
+		}
+	})
+
+	t.Run("unknown identity is not a mismatch", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			mock *MockRunner
+		}{
+			{"no git email", identityRunner("", "dev-work", "dev@work.com", nil)},
+			{"gh not authenticated", identityRunner("dev@work.com", "", "", nil)},
+			{"missing email scope", identityRunner("dev@personal.com", "dev-work", "", errors.New("HTTP 404"))},
+		}
+
+		for _, tc := range testCases {
+			if result := CheckIdentity("/repo", tc.mock); result != "" {
+				t.Errorf("%s: expected no warning, got %q", tc.name, result)
+			}
+		}
+	})
+
+	t.Run("gh identity is cached per invocation", func(t *testing.T) {
+		calls := 0
+		mock := identityRunner("dev@personal.com", "dev-work", "dev@work.com", nil)
+		inner := mock.RunWithTimeoutFunc
+		mock.RunWithTimeoutFunc = func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			calls++
+			return inner(dir, timeout, command, args...)
+		}
+
+		c := NewCache(mock)
+		CheckIdentity("/repo", c)
+		CheckIdentity("/repo", c)
+
+		if calls != 2 {
+			t.Errorf("expected 2 gh calls, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// maxLinkedIssues bounds how many issues are looked up with gh
+const maxLinkedIssues = 10
+
+// Issue is a GitHub issue a pull request closes.
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	URL    string `json:"url"`
+}
+
+// closingKeyword matches GitHub's closing keywords, e.g. "Closes #123" or "fixes: #45"
+var closingKeyword = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+#(\d+)\b`)
+
+// ParseClosingRefs returns the issue numbers referenced with closing keywords,
+// in order of first appearance.
+func ParseClosingRefs(text string) []int {
+	var refs []int
+	seen := map[int]bool{}
+	for _, m := range closingKeyword.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || seen[n] {
+			continue
+		}
+		seen[n] = true
+		refs = append(refs, n)
+	}
+	return refs
+}
+
+// GetCommitMessages returns the full messages of commits in base..HEAD.
+func GetCommitMessages(dir string, base string, r runner.CommandRunner) string {
+	output, err := r.Run(dir, "git", "log", "--format=%B", base+"..HEAD")
+	if err != nil {
+		return ""
+	}
+	return output
+}
+
+// GetLinkedIssues collects the issues a pull request closes: GitHub's
+// closingIssuesReferences and closing keywords in the PR body (when prNumber
+// is set), plus closing keywords in texts such as commit messages.
+// Titles and states are filled in with gh; issues gh can't read keep only
+// their number.
+func GetLinkedIssues(dir string, prNumber int, texts []string, r runner.CommandRunner) []Issue {
+	var issues []Issue
+	seen := map[int]bool{}
+	add := func(issue Issue) {
+		if issue.Number <= 0 || seen[issue.Number] || len(issues) >= maxLinkedIssues {
+			return
+		}
+		seen[issue.Number] = true
+		issues = append(issues, issue)
+	}
+
+	if prNumber > 0 {
+		output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "view", strconv.Itoa(prNumber), "--json", "body,closingIssuesReferences")
+		if err == nil && output != "" {
+			var view struct {
+				Body   string  `json:"body"`
+				Closes []Issue `json:"closingIssuesReferences"`
+			}
+			if json.Unmarshal([]byte(output), &view) == nil {
+				for _, issue := range view.Closes {
+					add(issue)
+				}
+				texts = append([]string{view.Body}, texts...)
+			}
+		}
+	}
+
+	for _, text := range texts {
+		for _, n := range ParseClosingRefs(text) {
+			add(Issue{Number: n})
+		}
+	}
+
+	for i, issue := range issues {
+		if issue.Title != "" && issue.State != "" {
+			continue
+		}
+		output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "issue", "view", strconv.Itoa(issue.Number), "--json", "number,title,state,url")
+		if err != nil || output == "" {
+			continue
+		}
+		var full Issue
+		if json.Unmarshal([]byte(output), &full) == nil && full.Number == issue.Number {
+			issues[i] = full
+		}
+	}
+
+	return issues
+}
+
+// FormatLinkedIssues renders issues as a markdown list, or empty string if there are none.
+func FormatLinkedIssues(issues []Issue) string {
+	if len(issues) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	for _, issue := range issues {
+		line := fmt.Sprintf("- #%d", issue.Number)
+		if issue.Title != "" {
+			line += " " + issue.Title
+		}
+		if issue.State != "" {
+			line += fmt.Sprintf(" [%s]", issue.State)
+		}
+		out.WriteString(line + "\n")
+	}
+	out.WriteString("\n")
+	return out.String()
+}
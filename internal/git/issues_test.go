@@ -0,0 +1,76 @@
+package git
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClosingRefs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []int
+	}{
+		{"closes", "Closes #123", []int{123}},
+		{"keyword variants", "fixed #1, resolves #2 and close: #3", []int{1, 2, 3}},
+		{"case insensitive", "FIXES #45", []int{45}},
+		{"duplicates removed", "Fixes #7\nfixes #7", []int{7}},
+		{"plain reference ignored", "See #12 for context", nil},
+		{"no refs", "Add login page", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if refs := ParseClosingRefs(tc.text); !reflect.DeepEqual(refs, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, refs)
+			}
+		})
+	}
+}
+
+func TestGetLinkedIssues(t *testing.T) {
+	t.Run("merges API references, body, and commits", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				switch strings.Join(args[:2], " ") {
+				case "pr view":
+					return `{"body":"Fixes #2","closingIssuesReferences":[{"number":1,"title":"Crash on start","state":"OPEN","url":"u1"}]}`, nil
+				case "issue view":
+					if args[2] == "2" {
+						return `{"number":2,"title":"Typo in docs","state":"CLOSED","url":"u2"}`, nil
+					}
+				}
+				return "", errors.New("not found")
+			},
+		}
+
+		issues := GetLinkedIssues("/repo", 42, []string{"Add tests\n\nCloses #3\nCloses #1"}, mock)
+
+		expected := []Issue{
+			{Number: 1, Title: "Crash on start", State: "OPEN", URL: "u1"},
+			{Number: 2, Title: "Typo in docs", State: "CLOSED", URL: "u2"},
+			{Number: 3},
+		}
+		if !reflect.DeepEqual(issues, expected) {
+			t.Errorf("expected %+v, got %+v", expected, issues)
+		}
+
+		formatted := FormatLinkedIssues(issues)
+		if !strings.Contains(formatted, "- #1 Crash on start [OPEN]\n") || !strings.Contains(formatted, "- #3\n") {
+			t.Errorf("unexpected formatting:\n%s", formatted)
+		}
+	})
+
+	t.Run("no PR and no keywords", func(t *testing.T) {
+		issues := GetLinkedIssues("/repo", 0, []string{"Add tests"}, &MockRunner{})
+		if len(issues) != 0 {
+			t.Errorf("expected no issues, got %+v", issues)
+		}
+		if FormatLinkedIssues(issues) != "" {
+			t.Error("expected empty output")
+		}
+	})
+}
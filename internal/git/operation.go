@@ -0,0 +1,72 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// In-progress operations reported by GetInProgressOperation.
+const (
+	OpMerge      = "merge"
+	OpRebase     = "rebase"
+	OpCherryPick = "cherry-pick"
+	OpRevert     = "revert"
+	OpBisect     = "bisect"
+)
+
+// operationSentinels maps the files git leaves in its directory during an
+// operation to the operation they indicate, in order of precedence.
+var operationSentinels = []struct {
+	path string
+	op   string
+}{
+	{"rebase-merge", OpRebase},
+	{"rebase-apply", OpRebase},
+	{"MERGE_HEAD", OpMerge},
+	{"CHERRY_PICK_HEAD", OpCherryPick},
+	{"REVERT_HEAD", OpRevert},
+	{"BISECT_LOG", OpBisect},
+	{"BISECT_START", OpBisect},
+}
+
+// GetInProgressOperation reports an unfinished merge, rebase, cherry-pick,
+// revert, or bisect by checking git's sentinel files.
+// Returns empty string if none is in progress.
+func GetInProgressOperation(dir string, r runner.CommandRunner) string {
+	gitDir, err := r.Run(dir, "git", "rev-parse", "--git-dir")
+	if err != nil || gitDir == "" {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+
+	for _, s := range operationSentinels {
+		if _, err := os.Stat(filepath.Join(gitDir, s.path)); err == nil {
+			return s.op
+		}
+	}
+	return ""
+}
+
+// InProgressWarning describes how to finish or abandon an in-progress operation.
+// Returns empty string for no operation.
+func InProgressWarning(op string) string {
+	switch op {
+	case OpMerge:
+		return "⚠️ Merge in progress — resolve conflicts and commit, or run `git merge --abort`"
+	case OpRebase:
+		return "⚠️ Rebase in progress — run `git rebase --continue` or `git rebase --abort`"
+	case OpCherryPick:
+		return "⚠️ Cherry-pick in progress — run `git cherry-pick --continue` or `git cherry-pick --abort`"
+	case OpRevert:
+		return "⚠️ Revert in progress — run `git revert --continue` or `git revert --abort`"
+	case OpBisect:
+		return "⚠️ Bisect in progress — run `git bisect reset` to restore your branch"
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetInProgressOperation(t *testing.T) {
+	gitDirMock := func(gitDir string) *MockRunner {
+		return &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return gitDir, nil
+			},
+		}
+	}
+
+	testCases := []struct {
+		sentinel string
+		expected string
+	}{
+		{"BISECT_LOG", OpBisect},
+		{"BISECT_START", OpBisect},
+		{"MERGE_HEAD", OpMerge},
+		{"rebase-merge", OpRebase},
+		{"rebase-apply", OpRebase},
+		{"CHERRY_PICK_HEAD", OpCherryPick},
+		{"REVERT_HEAD", OpRevert},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.sentinel, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, ".git", tc.sentinel), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			op := GetInProgressOperation(dir, gitDirMock(".git"))
+			if op != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, op)
+			}
+		})
+	}
+
+	t.Run("absolute git dir", func(t *testing.T) {
+		gitDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(gitDir, "BISECT_LOG"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if op := GetInProgressOperation(t.TempDir(), gitDirMock(gitDir)); op != OpBisect {
+			t.Errorf("expected bisect, got %q", op)
+		}
+	})
+
+	t.Run("no operation", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if op := GetInProgressOperation(dir, gitDirMock(".git")); op != "" {
+			t.Errorf("expected no operation, got %q", op)
+		}
+	})
+
+	t.Run("not a git repo", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return "", errors.New("not a git repository")
+			},
+		}
+
+		if op := GetInProgressOperation(t.TempDir(), mock); op != "" {
+			t.Errorf("expected no operation, got %q", op)
+		}
+	})
+}
+
+func TestInProgressWarning(t *testing.T) {
+	if got := InProgressWarning(OpBisect); !strings.Contains(got, "git bisect reset") {
+		t.Errorf("expected bisect reset hint, got %q", got)
+	}
+	if got := InProgressWarning(""); got != "" {
+		t.Errorf("expected no warning, got %q", got)
+	}
+}
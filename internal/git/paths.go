@@ -0,0 +1,140 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// PathMode controls how file paths from git are displayed.
+type PathMode string
+
+const (
+	PathsRepoRoot PathMode = "repo-root" // Relative to the repository root (git's native form)
+	PathsRelative PathMode = "relative"  // Relative to the directory vibes was run from
+	PathsAbsolute PathMode = "absolute"  // Absolute filesystem paths
+)
+
+// ParsePathMode validates a --paths value. Empty selects PathsRepoRoot.
+func ParsePathMode(s string) (PathMode, error) {
+	switch mode := PathMode(s); mode {
+	case "":
+		return PathsRepoRoot, nil
+	case PathsRepoRoot, PathsRelative, PathsAbsolute:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid path mode %q (expected %s, %s, or %s)", s, PathsRepoRoot, PathsRelative, PathsAbsolute)
+}
+
+// GetRepoRoot returns the absolute path of the repository containing dir.
+// Returns empty string if dir is not inside a git repository.
+func GetRepoRoot(dir string, r runner.CommandRunner) string {
+	root, err := r.Run(dir, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	return root
+}
+
+// PathRewriter converts repo-root-relative paths, as printed by git, to a display mode.
+type PathRewriter struct {
+	Mode PathMode
+	Root string // Repository root
+	Dir  string // Directory vibes was run from
+}
+
+// NewPathRewriter returns a rewriter for paths in the repository containing dir.
+// Without a known repository root, paths are left in git's native form.
+func NewPathRewriter(dir string, mode PathMode, r runner.CommandRunner) PathRewriter {
+	rw := PathRewriter{Mode: mode, Dir: dir}
+	if mode != PathsRepoRoot && mode != "" {
+		rw.Root = GetRepoRoot(dir, r)
+	}
+	return rw
+}
+
+// Rewrite converts a single repo-root-relative path.
+func (p PathRewriter) Rewrite(path string) string {
+	if p.Root == "" || path == "" {
+		return path
+	}
+
+	abs := filepath.Join(p.Root, filepath.FromSlash(path))
+	switch p.Mode {
+	case PathsAbsolute:
+		return abs
+	case PathsRelative:
+		dir := p.Dir
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		if rel, err := filepath.Rel(dir, abs); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+// RewriteNameStatus rewrites the paths in `git diff --name-status` output,
+// including both sides of renames and copies.
+func (p PathRewriter) RewriteNameStatus(output string) string {
+	if p.Root == "" {
+		return output
+	}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		fields := strings.Split(line, "\t")
+		for j := 1; j < len(fields); j++ {
+			fields[j] = p.Rewrite(fields[j])
+		}
+		lines[i] = strings.Join(fields, "\t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RewriteStat rewrites the paths in `git diff --stat` output and realigns the
+// name column. Paths git abbreviated with "..." or quoted, and renames within
+// a directory such as "dir/{a => b}", are left as git printed them.
+func (p PathRewriter) RewriteStat(output string) string {
+	if p.Root == "" {
+		return output
+	}
+
+	lines := strings.Split(output, "\n")
+	names := make([]string, len(lines))
+	seps := make([]int, len(lines))
+	width := 0
+	for i, line := range lines {
+		seps[i] = strings.LastIndex(line, " | ")
+		if seps[i] < 0 {
+			continue
+		}
+		names[i] = p.rewriteStatName(strings.TrimSpace(line[:seps[i]]))
+		width = max(width, utf8.RuneCountInString(names[i]))
+	}
+
+	for i, line := range lines {
+		if seps[i] < 0 {
+			continue
+		}
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(names[i]))
+		lines[i] = " " + names[i] + pad + line[seps[i]:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rewriteStatName rewrites one --stat file name, including both sides of a
+// "old => new" rename.
+func (p PathRewriter) rewriteStatName(name string) string {
+	if strings.HasPrefix(name, "...") || strings.HasPrefix(name, `"`) || strings.Contains(name, "{") {
+		return name
+	}
+	if from, to, ok := strings.Cut(name, " => "); ok {
+		return p.Rewrite(from) + " => " + p.Rewrite(to)
+	}
+	return p.Rewrite(name)
+}
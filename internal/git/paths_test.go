@@ -0,0 +1,113 @@
+package git
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePathMode(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected PathMode
+		wantErr  bool
+	}{
+		{"", PathsRepoRoot, false},
+		{"repo-root", PathsRepoRoot, false},
+		{"relative", PathsRelative, false},
+		{"absolute", PathsAbsolute, false},
+		{"cwd", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			mode, err := ParsePathMode(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mode != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, mode)
+			}
+		})
+	}
+}
+
+func TestPathRewriter(t *testing.T) {
+	root := t.TempDir()
+	root, _ = filepath.EvalSymlinks(root)
+	subdir := filepath.Join(root, "internal", "git")
+
+	newRewriter := func(mode PathMode) PathRewriter {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && args[0] == "rev-parse" && args[1] == "--show-toplevel" {
+					return root, nil
+				}
+				return "", nil
+			},
+		}
+		return NewPathRewriter(subdir, mode, mock)
+	}
+
+	output := "M\tinternal/git/git.go\nA\tREADME.md\nR100\tinternal/old.go\tinternal/git/new.go"
+
+	t.Run("repo-root leaves paths unchanged", func(t *testing.T) {
+		if result := newRewriter(PathsRepoRoot).RewriteNameStatus(output); result != output {
+			t.Errorf("expected unchanged output, got:\n%s", result)
+		}
+	})
+
+	t.Run("relative to invocation directory", func(t *testing.T) {
+		expected := "M\tgit.go\nA\t" + filepath.Join("..", "..", "README.md") +
+			"\nR100\t" + filepath.Join("..", "old.go") + "\tnew.go"
+		if result := newRewriter(PathsRelative).RewriteNameStatus(output); result != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+		}
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		expected := "M\t" + filepath.Join(root, "internal", "git", "git.go") +
+			"\nA\t" + filepath.Join(root, "README.md") +
+			"\nR100\t" + filepath.Join(root, "internal", "old.go") + "\t" + filepath.Join(root, "internal", "git", "new.go")
+		if result := newRewriter(PathsAbsolute).RewriteNameStatus(output); result != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+		}
+	})
+
+	t.Run("stat output is rewritten and realigned", func(t *testing.T) {
+		stat := " internal/git/git.go        | 4 ++--\n" +
+			" README.md                  | 1 +\n" +
+			" internal/old.go => new.go  | 0\n" +
+			" internal/{a.go => b.go}    | 0\n" +
+			" .../very/long/path/file.go | 2 +-\n" +
+			" 5 files changed, 4 insertions(+), 2 deletions(-)"
+		readme := filepath.Join("..", "..", "README.md")
+		rename := filepath.Join("..", "old.go") + " => " + filepath.Join("..", "..", "new.go")
+		width := len(".../very/long/path/file.go")
+		pad := func(name string) string { return " " + name + strings.Repeat(" ", width-len(name)) }
+		expected := pad("git.go") + " | 4 ++--\n" +
+			pad(readme) + " | 1 +\n" +
+			pad(rename) + " | 0\n" +
+			pad("internal/{a.go => b.go}") + " | 0\n" +
+			pad(".../very/long/path/file.go") + " | 2 +-\n" +
+			" 5 files changed, 4 insertions(+), 2 deletions(-)"
+		if result := newRewriter(PathsRelative).RewriteStat(stat); result != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+		}
+		if result := newRewriter(PathsRepoRoot).RewriteStat(stat); result != stat {
+			t.Errorf("expected unchanged stat, got:\n%s", result)
+		}
+	})
+
+	t.Run("unknown repo root leaves paths unchanged", func(t *testing.T) {
+		rw := NewPathRewriter(subdir, PathsAbsolute, &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return "", errors.New("not a git repository")
+			},
+		})
+		if result := rw.Rewrite("README.md"); result != "README.md" {
+			t.Errorf("expected unchanged path, got %q", result)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// DetectParent guesses the branch a stacked branch was cut from, so its
+// diff can exclude the parent's commits. The branch's upstream wins when it
+// tracks a different branch; otherwise the closest local branch that HEAD
+// contains but base doesn't is used, skipping branches at HEAD's own
+// commit. Returns empty string for branches
+// based directly on base.
+func DetectParent(dir string, branch string, base string, r runner.CommandRunner) string {
+	if branch == "" {
+		return ""
+	}
+
+	if upstream := upstreamBranch(dir, r); upstream != "" {
+		name := upstream
+		for _, remote := range Remotes(dir, r) {
+			if trimmed, ok := strings.CutPrefix(upstream, remote+"/"); ok {
+				name = trimmed
+				break
+			}
+		}
+		if name != branch && name != TrimRemote(base) {
+			slog.Info("parent branch", "branch", upstream, "via", "upstream")
+			return upstream
+		}
+	}
+
+	output, err := r.Run(dir, "git", "branch", "--format=%(refname:short)", "--merged", "HEAD", "--no-merged", base)
+	if err != nil {
+		return ""
+	}
+
+	parent, closest := "", -1
+	for _, candidate := range strings.Fields(output) {
+		if candidate == branch {
+			continue
+		}
+		count, err := r.Run(dir, "git", "rev-list", "--count", candidate+"..HEAD")
+		if err != nil {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		// A branch at HEAD's commit (e.g. a backup copy) isn't a parent
+		if err != nil || n == 0 {
+			continue
+		}
+		if closest < 0 || n < closest {
+			parent, closest = candidate, n
+		}
+	}
+	if parent != "" {
+		slog.Info("parent branch", "branch", parent, "via", "merge-base")
+	}
+	return parent
+}
+
+// upstreamBranch returns the current branch's upstream, e.g. "origin/feature/a",
+// or empty string if none is set.
+func upstreamBranch(dir string, r runner.CommandRunner) string {
+	output, err := r.Run(dir, "git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectParent(t *testing.T) {
+	// stackMock reports an upstream and the local branches HEAD contains
+	// but main doesn't, with how many commits each is behind HEAD.
+	stackMock := func(upstream string, merged map[string]string) *MockRunner {
+		return &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				switch args[0] {
+				case "remote":
+					return "origin", nil
+				case "rev-parse":
+					if upstream == "" {
+						return "", errors.New("no upstream configured")
+					}
+					return upstream, nil
+				case "branch":
+					var names string
+					for name := range merged {
+						names += name + "\n"
+					}
+					return names, nil
+				case "rev-list":
+					for name, count := range merged {
+						if args[2] == name+"..HEAD" {
+							return count, nil
+						}
+					}
+				}
+				return "", errors.New("unexpected command")
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		upstream string
+		merged   map[string]string
+		expected string
+	}{
+		{"upstream tracks the parent", "origin/feature/auth", nil, "origin/feature/auth"},
+		{"upstream is the branch itself", "origin/feature/ui", map[string]string{"feature/ui": "0"}, ""},
+		{"upstream is the base", "origin/main", nil, ""},
+		{"closest merged branch", "", map[string]string{"feature/ui": "0", "feature/auth": "3", "feature/db": "7"}, "feature/auth"},
+		{"based directly on main", "", map[string]string{"feature/ui": "0"}, ""},
+		{"branch copy at HEAD is skipped", "", map[string]string{"feature/ui": "0", "backup": "0", "feature/auth": "3"}, "feature/auth"},
+		{"only a branch copy at HEAD", "", map[string]string{"feature/ui": "0", "backup": "0"}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DetectParent("/repo", "feature/ui", "main", stackMock(tc.upstream, tc.merged)); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
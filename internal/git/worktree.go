@@ -0,0 +1,59 @@
+package git
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// ParseRange splits a "good..bad" commit range into its two ends.
+func ParseRange(spec string) (good string, bad string, err error) {
+	good, bad, ok := strings.Cut(spec, "..")
+	if !ok || good == "" || bad == "" || strings.HasPrefix(bad, ".") {
+		return "", "", fmt.Errorf("invalid range %q (expected <good>..<bad>)", spec)
+	}
+	return good, bad, nil
+}
+
+// ResolveCommit returns the full hash of ref, or an error if it doesn't
+// name a commit.
+func ResolveCommit(dir string, ref string, r runner.CommandRunner) (string, error) {
+	hash, err := r.Run(dir, "git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil || strings.TrimSpace(hash) == "" {
+		return "", fmt.Errorf("unknown commit %q", ref)
+	}
+	return strings.TrimSpace(hash), nil
+}
+
+// AddWorktree checks out ref, detached, in a new temporary worktree so it
+// can be built and tested without touching the user's tree. The returned
+// cleanup removes the worktree and must be called once the caller is done.
+func AddWorktree(dir string, ref string, r runner.CommandRunner) (string, func(), error) {
+	path, err := os.MkdirTemp("", "vibes-worktree-")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating worktree directory: %w", err)
+	}
+
+	if _, err := r.RunWithTimeout(dir, time.Minute, "git", "worktree", "add", "--detach", path, ref); err != nil {
+		os.RemoveAll(path)
+		if output := runner.ErrorOutput(err); output != "" {
+			return "", nil, fmt.Errorf("checking out %s in a worktree: %w\n%s", ref, err, output)
+		}
+		return "", nil, fmt.Errorf("checking out %s in a worktree: %w", ref, err)
+	}
+	slog.Info("added worktree", "ref", ref, "path", path)
+
+	cleanup := func() {
+		if _, err := r.RunWithTimeout(dir, 30*time.Second, "git", "worktree", "remove", "--force", path); err != nil {
+			slog.Warn("removing worktree", "path", path, "err", err)
+		}
+		os.RemoveAll(path)
+		// Drop the bookkeeping for the worktree if remove failed
+		r.Run(dir, "git", "worktree", "prune")
+	}
+	return path, cleanup, nil
+}
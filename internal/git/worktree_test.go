@@ -0,0 +1,101 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	testCases := []struct {
+		spec string
+		good string
+		bad  string
+		ok   bool
+	}{
+		{"v1.2..HEAD", "v1.2", "HEAD", true},
+		{"abc123..def456", "abc123", "def456", true},
+		{"HEAD", "", "", false},
+		{"..HEAD", "", "", false},
+		{"v1.2..", "", "", false},
+		{"main...HEAD", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			good, bad, err := ParseRange(tc.spec)
+			if (err == nil) != tc.ok {
+				t.Fatalf("expected ok=%v, got err %v", tc.ok, err)
+			}
+			if good != tc.good || bad != tc.bad {
+				t.Errorf("expected %q..%q, got %q..%q", tc.good, tc.bad, good, bad)
+			}
+		})
+	}
+}
+
+func TestResolveCommit(t *testing.T) {
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			if args[len(args)-1] == "v1.2^{commit}" {
+				return "abc123\n", nil
+			}
+			return "", errors.New("exit status 1")
+		},
+	}
+
+	if hash, err := ResolveCommit("/repo", "v1.2", mock); err != nil || hash != "abc123" {
+		t.Errorf("expected abc123, got %q (err %v)", hash, err)
+	}
+	if _, err := ResolveCommit("/repo", "nope", mock); err == nil || !strings.Contains(err.Error(), `"nope"`) {
+		t.Errorf("expected unknown commit error, got %v", err)
+	}
+}
+
+func TestAddWorktree(t *testing.T) {
+	t.Run("checks out detached and removes on cleanup", func(t *testing.T) {
+		var calls []string
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				calls = append(calls, strings.Join(args[:3], " "))
+				return "", nil
+			},
+		}
+
+		path, cleanup, err := AddWorktree("/repo", "abc123", mock)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected worktree directory to exist: %v", err)
+		}
+
+		cleanup()
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected worktree directory removed, got %v", err)
+		}
+		expected := "worktree add --detach,worktree remove --force"
+		if strings.Join(calls, ",") != expected {
+			t.Errorf("expected %s, got %v", expected, calls)
+		}
+	})
+
+	t.Run("failed checkout leaves nothing behind", func(t *testing.T) {
+		var path string
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				path = args[len(args)-2]
+				return "", errors.New("exit status 128")
+			},
+		}
+
+		if _, _, err := AddWorktree("/repo", "abc123", mock); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected temp directory removed, got %v", err)
+		}
+	})
+}
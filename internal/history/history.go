@@ -0,0 +1,65 @@
+// Package history keeps a local, append-only record of what vibes told agents
+// to work on, for tracing an agent's actions back to its prompt.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// File is the repo-relative location of the history log.
+const File = ".vibes/history.jsonl"
+
+// Entry is one recorded invocation.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Task    string    `json:"task,omitempty"`   // Detected or recommended task ID
+	Branch  string    `json:"branch,omitempty"` // Branch at the time of the invocation
+	Mode    string    `json:"mode,omitempty"`   // Command-specific mode, e.g. ralph's goal or autopilot
+	Goal    string    `json:"goal,omitempty"`
+}
+
+// Path returns the history file for the repository containing dir,
+// falling back to dir itself outside a git repository.
+func Path(dir string, r runner.CommandRunner) string {
+	root := git.GetRepoRoot(dir, r)
+	if root == "" {
+		root = dir
+	}
+	return filepath.Join(root, File)
+}
+
+// Append adds an entry to the history file, creating it if needed.
+// A zero Time is set to the current time.
+func Append(dir string, e Entry, r runner.CommandRunner) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding history entry: %w", err)
+	}
+
+	path := Path(dir, r)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MockRunner is a mock implementation of runner.CommandRunner for testing
+type MockRunner struct {
+	RunFunc            func(dir string, command string, args ...string) (string, error)
+	RunWithTimeoutFunc func(dir string, timeout time.Duration, command string, args ...string) (string, error)
+}
+
+func (m *MockRunner) Run(dir string, command string, args ...string) (string, error) {
+	if m.RunFunc != nil {
+		return m.RunFunc(dir, command, args...)
+	}
+	return "", nil
+}
+
+func (m *MockRunner) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+	if m.RunWithTimeoutFunc != nil {
+		return m.RunWithTimeoutFunc(dir, timeout, command, args...)
+	}
+	return "", nil
+}
+
+func TestAppend(t *testing.T) {
+	t.Run("appends entries at the repo root", func(t *testing.T) {
+		root := t.TempDir()
+		sub := filepath.Join(root, "cmd", "app")
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return root, nil
+			},
+		}
+
+		if err := Append(sub, Entry{Command: "next", Task: "bd-1", Branch: "main"}, mock); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := Append(sub, Entry{Command: "ralph", Mode: "goal", Goal: "Add auth"}, mock); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(root, File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 entries, got %d:\n%s", len(lines), content)
+		}
+
+		var first Entry
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+			t.Fatal(err)
+		}
+		if first.Command != "next" || first.Task != "bd-1" || first.Branch != "main" {
+			t.Errorf("unexpected entry: %+v", first)
+		}
+		if first.Time.IsZero() {
+			t.Error("expected timestamp to be set")
+		}
+		if strings.Contains(lines[0], "goal") {
+			t.Errorf("expected empty fields to be omitted, got %s", lines[0])
+		}
+	})
+
+	t.Run("outside a git repo uses dir", func(t *testing.T) {
+		dir := t.TempDir()
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return "", errors.New("not a git repository")
+			},
+		}
+
+		if err := Append(dir, Entry{Command: "next"}, mock); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, File)); err != nil {
+			t.Errorf("expected history file in dir: %v", err)
+		}
+	})
+}
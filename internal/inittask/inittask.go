@@ -0,0 +1,173 @@
+package inittask
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/next"
+	"github.com/vibes-project/vibes/internal/prompt"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// DefaultPriority is the bead priority used when none is given
+const DefaultPriority = 2
+
+// ErrCancelled is returned when the user declines the confirmation
+var ErrCancelled = errors.New("cancelled")
+
+// Options configures the init-task command behavior
+type Options struct {
+	Dir            string               // Target directory (defaults to cwd)
+	Title          string               // Bead title (prompted for when empty)
+	Priority       int                  // Bead priority, 0 (highest) to 4
+	Yes            bool                 // Skip the confirmation prompt
+	Verbose        bool                 // Include full protocol details
+	ProjectKey     string               // Agent Mail project key for MCP snippets (defaults to the directory name)
+	ReservationTTL int                  // Seconds in the file reservation snippet (0 = prompt.DefaultReservationTTL)
+	TemplateVars   map[string]string    // Custom variables for protocol templates
+	Output         prompt.Output        // Output rendering (e.g. context only)
+	Runner         runner.CommandRunner // Command runner (defaults to runner.Default)
+}
+
+// Run creates a bead, checks out a feature branch for it, and outputs the
+// next prompt for the new task.
+func Run(opts Options) error {
+	dir := opts.Dir
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getting current directory: %w", err)
+		}
+		dir = cwd
+	}
+
+	r := opts.Runner
+	if r == nil {
+		r = &runner.Default{}
+	}
+
+	if !beads.IsInitialized(dir) {
+		return beads.ErrNotInitialized
+	}
+
+	title, priority := strings.TrimSpace(opts.Title), opts.Priority
+	if title == "" {
+		var err error
+		if title, priority, err = askTask(priority); err != nil {
+			return err
+		}
+	}
+	if title == "" {
+		return fmt.Errorf("a task title is required")
+	}
+	if priority < 0 || priority > 4 {
+		return fmt.Errorf("invalid priority %d (expected 0-4)", priority)
+	}
+
+	if !opts.Yes {
+		base := git.GetCurrentBranch(dir, r)
+		ok, err := confirm(fmt.Sprintf("Create bead %q (P%d) and a feature branch from %s?", title, priority, base))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrCancelled
+		}
+	}
+
+	id, err := createBead(dir, title, priority, r)
+	if err != nil {
+		return err
+	}
+	slog.Info("created bead", "id", id, "title", title)
+
+	branch := beads.SuggestBranchName(id, title)
+	if _, err := r.Run(dir, "git", "checkout", "-b", branch); err != nil {
+		if output := runner.ErrorOutput(err); output != "" {
+			return fmt.Errorf("created %s but could not create branch %s: %w\n%s", id, branch, err, output)
+		}
+		return fmt.Errorf("created %s but could not create branch %s: %w", id, branch, err)
+	}
+	fmt.Fprintf(os.Stderr, "Created %s and switched to %s\n", id, branch)
+
+	return next.Run(next.Options{
+		Dir:            dir,
+		Verbose:        opts.Verbose,
+		Task:           id,
+		ProjectKey:     opts.ProjectKey,
+		ReservationTTL: opts.ReservationTTL,
+		TemplateVars:   opts.TemplateVars,
+		Output:         opts.Output,
+		Runner:         r,
+	})
+}
+
+// createBead runs `bd create` and returns the new bead's ID. The title follows
+// "--" so one starting with a dash isn't read as a flag.
+func createBead(dir string, title string, priority int, r runner.CommandRunner) (string, error) {
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "create", "--json", "-p", strconv.Itoa(priority), "--", title)
+	if err != nil {
+		if output := runner.ErrorOutput(err); output != "" {
+			return "", fmt.Errorf("creating bead: %w\n%s", err, output)
+		}
+		return "", fmt.Errorf("creating bead: %w", err)
+	}
+
+	id := beads.ParseCreateOutput(output)
+	if id == "" {
+		return "", fmt.Errorf("could not find the new bead ID in bd create output:\n%s", output)
+	}
+	return id, nil
+}
+
+// askTask prompts for the task title and priority.
+func askTask(priority int) (string, int, error) {
+	var title string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Task title").
+				Value(&title),
+			huh.NewSelect[int]().
+				Title("Priority").
+				Options(
+					huh.NewOption("P0 - critical", 0),
+					huh.NewOption("P1 - high", 1),
+					huh.NewOption("P2 - medium", 2),
+					huh.NewOption("P3 - low", 3),
+					huh.NewOption("P4 - backlog", 4),
+				).
+				Value(&priority),
+		),
+	).WithOutput(os.Stderr)
+
+	if err := form.Run(); err != nil {
+		return "", 0, err
+	}
+	return strings.TrimSpace(title), priority, nil
+}
+
+// confirm asks a yes/no question on stderr, keeping stdout for the prompt.
+func confirm(question string) (bool, error) {
+	var ok bool
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(question).
+				Value(&ok),
+		),
+	).WithOutput(os.Stderr)
+
+	if err := form.Run(); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
@@ -0,0 +1,123 @@
+package inittask
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/beads"
+)
+
+// MockRunner is a mock implementation of runner.CommandRunner for testing
+type MockRunner struct {
+	RunFunc            func(dir string, command string, args ...string) (string, error)
+	RunWithTimeoutFunc func(dir string, timeout time.Duration, command string, args ...string) (string, error)
+}
+
+func (m *MockRunner) Run(dir string, command string, args ...string) (string, error) {
+	if m.RunFunc != nil {
+		return m.RunFunc(dir, command, args...)
+	}
+	return "", nil
+}
+
+func (m *MockRunner) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+	if m.RunWithTimeoutFunc != nil {
+		return m.RunWithTimeoutFunc(dir, timeout, command, args...)
+	}
+	return "", nil
+}
+
+func beadsDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	t.Run("creates bead and branch", func(t *testing.T) {
+		var created, checkout []string
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && args[0] == "checkout" {
+					checkout = args
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" && args[0] == "create" {
+					created = args
+					return `{"id":"bd-42","title":"Add login page"}`, nil
+				}
+				return "", nil
+			},
+		}
+
+		err := Run(Options{Dir: beadsDir(t), Title: "Add login page", Priority: 1, Yes: true, Runner: mock})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(created, " ") != "create --json -p 1 -- Add login page" {
+			t.Errorf("unexpected bd create args: %v", created)
+		}
+		if strings.Join(checkout, " ") != "checkout -b feature/bd-42-add-login-page" {
+			t.Errorf("unexpected checkout args: %v", checkout)
+		}
+	})
+
+	t.Run("unparseable create output", func(t *testing.T) {
+		checkedOut := false
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if args[0] == "checkout" {
+					checkedOut = true
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "Created.", nil
+			},
+		}
+
+		err := Run(Options{Dir: beadsDir(t), Title: "Add login page", Yes: true, Runner: mock})
+		if err == nil || !strings.Contains(err.Error(), "could not find the new bead ID") {
+			t.Errorf("expected parse error, got %v", err)
+		}
+		if checkedOut {
+			t.Error("expected no branch without a bead ID")
+		}
+	})
+
+	t.Run("bd create fails", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("exit status 1")
+			},
+		}
+
+		err := Run(Options{Dir: beadsDir(t), Title: "Add login page", Yes: true, Runner: mock})
+		if err == nil || !strings.Contains(err.Error(), "creating bead") {
+			t.Errorf("expected create error, got %v", err)
+		}
+	})
+
+	t.Run("beads not initialized", func(t *testing.T) {
+		err := Run(Options{Dir: t.TempDir(), Title: "Add login page", Yes: true, Runner: &MockRunner{}})
+		if !errors.Is(err, beads.ErrNotInitialized) {
+			t.Errorf("expected ErrNotInitialized, got %v", err)
+		}
+	})
+
+	t.Run("invalid priority", func(t *testing.T) {
+		err := Run(Options{Dir: beadsDir(t), Title: "Add login page", Priority: 7, Yes: true, Runner: &MockRunner{}})
+		if err == nil || !strings.Contains(err.Error(), "invalid priority") {
+			t.Errorf("expected priority error, got %v", err)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package next
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,23 +10,70 @@ import (
 
 	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/history"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/runner"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 // Options configures the next command behavior
 type Options struct {
-	Dir     string               // Target directory (defaults to cwd)
-	Verbose bool                 // Include full protocol details
-	Runner  runner.CommandRunner // Command runner (defaults to runner.Default)
+	Dir            string               // Target directory (defaults to cwd)
+	Verbose        bool                 // Include full protocol details
+	FullTask       bool                 // Include the top task's full description (always on with Verbose)
+	Task           string               // Prompt for this bead instead of the triage recommendation
+	Record         bool                 // Append the recommended task to .vibes/history.jsonl
+	RecentClosed   bool                 // List the most recently closed tasks for continuity
+	Refresh        bool                 // Re-import bd's database before triage in case its cache is stale
+	StaleAfter     time.Duration        // Flag in-progress tasks untouched this long (0 = beads.DefaultStaleAfter)
+	Strict         bool                 // Fail instead of degrading when beads tools are missing or fail
+	MaxTasks       int                  // Max tasks kept from triage output (0 = unlimited)
+	ProjectKey     string               // Agent Mail project key for MCP snippets (defaults to the directory name)
+	ReservationTTL int                  // Seconds in the file reservation snippet (0 = prompt.DefaultReservationTTL)
+	TemplateVars   map[string]string    // Custom variables for protocol templates
+	Output         prompt.Output        // Output rendering (e.g. context only)
+	Runner         runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
+// Sections lists every section this command can produce, in output order.
+var Sections = []string{"Project Context", "Recently Closed", "Recommended Task", "Protocol"}
+
 // Run executes the next command and returns the prompt to stdout
 func Run(opts Options) error {
+	session, err := NewSession(opts)
+	if err != nil {
+		return err
+	}
+
+	doc, err := session.Render()
+	if err != nil {
+		return err
+	}
+	return prompt.Emit(session.dir, doc, opts.Output, session.r)
+}
+
+// Session separates gathering next's project context from rendering the
+// prompt. Git context is gathered once by NewSession; each Render re-queries
+// only the task listing, so a long-lived caller can refresh the recommendation
+// without re-running git.
+type Session struct {
+	opts        Options
+	dir         string
+	r           runner.CommandRunner
+	projectName string
+	projectKey  string
+	branch      string
+	gitContext  string
+	stale       []string // Warnings for in-progress tasks that may have stalled
+}
+
+// NewSession gathers the git context for next prompts.
+func NewSession(opts Options) (*Session, error) {
 	dir := opts.Dir
 	if dir == "" {
 		cwd, err := os.Getwd()
 		if err != nil {
-			return fmt.Errorf("getting current directory: %w", err)
+			return nil, fmt.Errorf("getting current directory: %w", err)
 		}
 		dir = cwd
 	}
@@ -34,37 +82,97 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
+	r = git.NewCache(r)
 
-	var out strings.Builder
+	projectKey := opts.ProjectKey
+	if projectKey == "" {
+		projectKey = filepath.Base(dir)
+	}
+
+	// Staleness is measured in days, so it's checked once rather than per refresh
+	var stale []string
+	if opts.Task == "" && beads.IsInitialized(dir) {
+		stale = beads.StaleInProgress(dir, opts.StaleAfter, r)
+	}
+
+	return &Session{
+		opts:        opts,
+		dir:         dir,
+		r:           r,
+		projectName: filepath.Base(dir),
+		projectKey:  projectKey,
+		branch:      git.GetCurrentBranch(dir, r),
+		gitContext:  getGitContext(dir, r),
+		stale:       stale,
+	}, nil
+}
 
+// Render builds the prompt from the gathered context and a fresh task recommendation.
+func (s *Session) Render() (*prompt.Document, error) {
 	// Header
-	projectName := filepath.Base(dir)
-	out.WriteString(fmt.Sprintf("# Next Task for %s\n\n", projectName))
+	doc := prompt.New(fmt.Sprintf("Next Task for %s", s.projectName), Sections...)
 
 	// Git context
-	gitContext := getGitContext(dir, r)
-	if gitContext != "" {
-		out.WriteString("## Project Context\n")
-		out.WriteString(gitContext)
-		out.WriteString("\n")
-	}
-
-	// Get recommended task from beads
-	taskInfo := getTaskRecommendation(dir, r)
-	out.WriteString("## Recommended Task\n")
-	if taskInfo != "" {
-		out.WriteString(taskInfo)
+	if s.gitContext != "" {
+		doc.Add(prompt.Summary, "Project Context", s.gitContext+"\n")
+	}
+
+	if s.opts.RecentClosed && beads.IsInitialized(s.dir) {
+		if closed := getRecentlyClosed(s.dir, s.r); closed != "" {
+			doc.Add(prompt.Context, "Recently Closed", closed+"\n")
+		}
+	}
+
+	// recommended fills the protocol in when it must not fall back to "the highest priority task"
+	var taskInfo, topTask, recommended string
+	if s.opts.Task != "" {
+		topTask = s.opts.Task
+		recommended = topTask
+		taskInfo = getTask(s.dir, topTask, s.r)
 	} else {
-		out.WriteString("No beads task graph found. Run `bd init` to initialize, or use `vibes` to set up the project.\n")
+		// Get recommended task from beads
+		if s.opts.Refresh && beads.IsInitialized(s.dir) {
+			beads.Refresh(s.dir, s.r)
+		}
+		var err error
+		taskInfo, err = getTaskRecommendation(s.dir, s.r, s.opts.MaxTasks, s.opts.Strict)
+		if err != nil {
+			return nil, err
+		}
+		// The first open bead in the listing is the top recommendation
+		var skipped []string
+		topTask, skipped = beads.FirstOpenTask(s.dir, taskInfo, s.r)
+		if taskInfo == "" {
+			taskInfo = "No beads task graph found. Run `bd init` to initialize, or use `vibes` to set up the project.\n"
+		} else if len(s.stale) > 0 {
+			taskInfo = strings.Join(s.stale, "\n") + "\n\n" + taskInfo
+		}
+		if len(skipped) > 0 {
+			taskInfo = closedNote(skipped, topTask) + "\n" + taskInfo
+			recommended = topTask
+		}
+		if s.opts.FullTask || s.opts.Verbose {
+			taskInfo += getTaskDetails(s.dir, topTask, s.r)
+		}
+	}
+	doc.Add(prompt.Summary, "Recommended Task", taskInfo+"\n")
+
+	if s.opts.Record {
+		entry := history.Entry{Command: "next", Task: topTask, Branch: s.branch}
+		if err := history.Append(s.dir, entry, s.r); err != nil {
+			slog.Warn("could not record history", "err", err)
+		}
 	}
-	out.WriteString("\n")
 
 	// Protocol
-	out.WriteString("## Protocol\n")
-	out.WriteString(getProtocol(opts.Verbose))
+	vars := templates.Merge(templates.Builtins(topTask, s.projectName, s.branch), s.opts.TemplateVars)
+	protocol, err := templates.Protocol(s.dir, "next", getProtocol(s.opts.Verbose, recommended, s.projectKey, s.opts.ReservationTTL), vars)
+	if err != nil {
+		return nil, err
+	}
+	doc.Add(prompt.Protocol, "Protocol", protocol)
 
-	fmt.Print(out.String())
-	return nil
+	return doc, nil
 }
 
 func getGitContext(dir string, r runner.CommandRunner) string {
@@ -93,55 +201,166 @@ func getGitContext(dir string, r runner.CommandRunner) string {
 	return out.String()
 }
 
-func getTaskRecommendation(dir string, r runner.CommandRunner) string {
+// getRecentlyClosed lists the last few closed tasks, newest first, so the
+// agent knows what was just finished. Returns empty string if bd fails.
+func getRecentlyClosed(dir string, r runner.CommandRunner) string {
+	tasks, err := beads.RecentlyClosed(dir, beads.MaxRecentClosed, r)
+	if err != nil {
+		slog.Info("omitting recently closed tasks", "err", err)
+		return ""
+	}
+	if len(tasks) == 0 {
+		return "No tasks closed yet.\n"
+	}
+
+	var out strings.Builder
+	for _, t := range tasks {
+		line := fmt.Sprintf("- %s %s", t.ID, t.Title)
+		if t.ClosedAt != nil {
+			line += fmt.Sprintf(" (closed %s)", t.ClosedAt.Local().Format("2006-01-02 15:04"))
+		}
+		out.WriteString(line + "\n")
+	}
+	return out.String()
+}
+
+// getTaskRecommendation lists recommended tasks. In strict mode a missing
+// task graph, failed triage, or empty listing is an error instead of a note
+// in the prompt.
+func getTaskRecommendation(dir string, r runner.CommandRunner, maxTasks int, strict bool) (string, error) {
 	// Check if beads is initialized
 	if !beads.IsInitialized(dir) {
+		if strict {
+			return "", beads.ErrNotInitialized
+		}
+		return "", nil
+	}
+
+	triage := beads.GetTriage(dir, r, maxTasks)
+	if strict {
+		if err := triage.Require(); err != nil {
+			return "", err
+		}
+	}
+	if triage.Output != "" {
+		if triage.Warning != "" {
+			return triage.Warning + "\n" + triage.Output, nil
+		}
+		return triage.Output, nil
+	}
+	if triage.Warning != "" {
+		return triage.Warning, nil
+	}
+
+	return "Beads initialized but no ready tasks found. Create tasks with `bd create \"Task name\" -p 1`\n", nil
+}
+
+// getTask describes a specific bead with its title and description from bd show.
+func getTask(dir string, id string, r runner.CommandRunner) string {
+	header := id
+	var description string
+	var closed bool
+	if output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "show", id); err == nil {
+		if title := beads.ExtractTitleFromShow(output); title != "" {
+			header = fmt.Sprintf("%s \"%s\"", id, title)
+		}
+		description = beads.ExtractDescriptionFromShow(output)
+		closed = beads.IsClosedStatus(beads.ExtractStatusFromShow(output))
+	}
+
+	task := fmt.Sprintf("**Task**: %s\n", header)
+	if closed {
+		task += fmt.Sprintf("\n⚠️ %s is already closed. Pick another task, or reopen it with `bd update %s --status open`.\n", id, id)
+	}
+	if description != "" {
+		task += "\n" + description + "\n"
+	}
+	return task
+}
+
+// closedNote explains which listed tasks were skipped as already closed.
+func closedNote(skipped []string, next string) string {
+	var note strings.Builder
+	for _, id := range skipped {
+		note.WriteString(fmt.Sprintf("⚠️ %s appears closed — refreshing recommendation\n", id))
+	}
+	if next != "" {
+		note.WriteString(fmt.Sprintf("**Top open task**: %s\n", next))
+	} else {
+		note.WriteString("No open task found in the listing. Run with `--refresh`, or check `bd ready`.\n")
+	}
+	return note.String()
+}
+
+// getTaskDetails returns the full description of the first listed task, which
+// often holds acceptance criteria the title doesn't mention.
+func getTaskDetails(dir string, id string, r runner.CommandRunner) string {
+	if id == "" {
 		return ""
 	}
 
-	// Try bv --robot-triage first (more intelligent recommendations)
-	if output, err := r.RunWithTimeout(dir, 10*time.Second, "bv", "--robot-triage"); err == nil && output != "" {
-		return output
+	output, err := r.RunWithTimeout(dir, 5*time.Second, "bd", "show", id)
+	if err != nil {
+		return ""
+	}
+	description := beads.ExtractDescriptionFromShow(output)
+	if description == "" {
+		return ""
 	}
 
-	// Fall back to bd ready
-	if output, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "ready"); err == nil && output != "" {
-		return output
+	header := id
+	if title := beads.ExtractTitleFromShow(output); title != "" {
+		header = fmt.Sprintf("%s \"%s\"", id, title)
 	}
+	return fmt.Sprintf("\n**Top task**: %s\n\n%s\n", header, description)
+}
 
-	return "Beads initialized but no ready tasks found. Create tasks with `bd create \"Task name\" -p 1`\n"
+// getProtocol returns the start-task protocol, filled in with taskID when
+// the task is already known and with the Agent Mail project key and
+// reservation TTL.
+func getProtocol(verbose bool, taskID string, projectKey string, ttl int) string {
+	protocol := getProtocolTemplate(verbose, prompt.ReservationTTL(ttl))
+	if projectKey != "" {
+		protocol = strings.ReplaceAll(protocol, `project_key="project-name"`, fmt.Sprintf("project_key=%q", projectKey))
+	}
+	if taskID == "" {
+		return protocol
+	}
+	protocol = strings.ReplaceAll(protocol, "bd-XXXX", taskID)
+	protocol = strings.ReplaceAll(protocol, "<id>", taskID)
+	return strings.ReplaceAll(protocol, "the highest priority task", taskID)
 }
 
-func getProtocol(verbose bool) string {
+func getProtocolTemplate(verbose bool, ttl int) string {
 	if verbose {
-		return `1. **Claim the work**:
-   ` + "```bash" + `
+		return fmt.Sprintf(`1. **Claim the work**:
+   `+"```bash"+`
    bd update bd-XXXX --status in_progress
    bd show bd-XXXX
-   ` + "```" + `
+   `+"```"+`
 
 2. **Reserve files** via MCP Agent Mail:
-   ` + "```" + `
+   `+"```"+`
    file_reservation_paths(
        project_key="project-name",
        agent_name="YourAgentIdentity",
        patterns=["<your-file-patterns>"],
-       ttl_seconds=3600,
+       ttl_seconds=%d,
        exclusive=true
    )
-   ` + "```" + `
+   `+"```"+`
 
 3. **Announce start** in the bead's thread
 
 4. **Execute** the implementation
 
 5. **Complete**:
-   ` + "```bash" + `
+   `+"```bash"+`
    bd update bd-XXXX --status closed
-   ` + "```" + `
+   `+"```"+`
 
 Begin working on the highest priority task now.
-`
+`, ttl)
 	}
 
 	return `1. Claim: ` + "`bd update <id> --status in_progress`" + `
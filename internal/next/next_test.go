@@ -1,12 +1,16 @@
 package next
 
 import (
+	"context"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/runner"
 )
 
@@ -32,7 +36,7 @@ func (m *MockRunner) RunWithTimeout(dir string, timeout time.Duration, command s
 
 func TestGetProtocol(t *testing.T) {
 	t.Run("non-verbose protocol", func(t *testing.T) {
-		result := getProtocol(false)
+		result := getProtocol(false, "", "", 0)
 
 		if !strings.Contains(result, "Claim:") {
 			t.Error("expected non-verbose protocol to contain 'Claim:'")
@@ -46,7 +50,7 @@ func TestGetProtocol(t *testing.T) {
 	})
 
 	t.Run("verbose protocol", func(t *testing.T) {
-		result := getProtocol(true)
+		result := getProtocol(true, "", "", 0)
 
 		if !strings.Contains(result, "**Claim the work**") {
 			t.Error("expected verbose protocol to contain bold headers")
@@ -64,6 +68,22 @@ func TestGetProtocol(t *testing.T) {
 			t.Error("expected verbose protocol to end with call to action")
 		}
 	})
+
+	t.Run("project key override propagates", func(t *testing.T) {
+		result := getProtocol(true, "", "acme-api", 0)
+		if !strings.Contains(result, `project_key="acme-api"`) || strings.Contains(result, "project-name") {
+			t.Errorf("expected project key in MCP snippet, got:\n%s", result)
+		}
+	})
+
+	t.Run("reservation TTL", func(t *testing.T) {
+		if result := getProtocol(true, "", "", 0); !strings.Contains(result, "ttl_seconds=3600,") {
+			t.Errorf("expected default TTL, got:\n%s", result)
+		}
+		if result := getProtocol(true, "", "", 600); !strings.Contains(result, "ttl_seconds=600,") {
+			t.Errorf("expected TTL override, got:\n%s", result)
+		}
+	})
 }
 
 func TestGetGitContext(t *testing.T) {
@@ -125,7 +145,7 @@ func TestGetTaskRecommendation(t *testing.T) {
 		tmpDir := t.TempDir()
 		mock := &MockRunner{}
 
-		result := getTaskRecommendation(tmpDir, mock)
+		result, _ := getTaskRecommendation(tmpDir, mock, 0, false)
 
 		if result != "" {
 			t.Errorf("expected empty result when no .beads dir, got: %s", result)
@@ -148,13 +168,39 @@ func TestGetTaskRecommendation(t *testing.T) {
 			},
 		}
 
-		result := getTaskRecommendation(tmpDir, mock)
+		result, _ := getTaskRecommendation(tmpDir, mock, 0, false)
 
 		if !strings.Contains(result, "Task 1: Fix bug") {
 			t.Errorf("expected bv output, got: %s", result)
 		}
 	})
 
+	t.Run("limits large triage output", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		beadsDir := filepath.Join(tmpDir, ".beads")
+		if err := os.MkdirAll(beadsDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bv" {
+					return `{"recommendations":[{"id":"bd-1"},{"id":"bd-2"},{"id":"bd-3"}]}`, nil
+				}
+				return "", nil
+			},
+		}
+
+		result, _ := getTaskRecommendation(tmpDir, mock, 1, false)
+
+		if !strings.Contains(result, "bd-1") {
+			t.Errorf("expected top task, got: %s", result)
+		}
+		if strings.Contains(result, "bd-3") {
+			t.Errorf("expected lower tasks to be trimmed, got: %s", result)
+		}
+	})
+
 	t.Run("beads directory with both commands failing", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		beadsDir := filepath.Join(tmpDir, ".beads")
@@ -168,12 +214,370 @@ func TestGetTaskRecommendation(t *testing.T) {
 			},
 		}
 
-		result := getTaskRecommendation(tmpDir, mock)
+		result, _ := getTaskRecommendation(tmpDir, mock, 0, false)
 
 		if !strings.Contains(result, "no ready tasks found") {
 			t.Errorf("expected fallback message, got: %s", result)
 		}
 	})
+
+	t.Run("bv timeout is reported", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bv" {
+					return "", context.DeadlineExceeded
+				}
+				return "", nil
+			},
+		}
+
+		result, _ := getTaskRecommendation(tmpDir, mock, 0, false)
+
+		if !strings.Contains(result, "`bv` timed out after 10s") {
+			t.Errorf("expected timeout warning, got: %s", result)
+		}
+		if strings.Contains(result, "no ready tasks found") {
+			t.Errorf("timeout should not be reported as an empty backlog, got: %s", result)
+		}
+	})
+
+	t.Run("bv timeout with bd fallback", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bv" {
+					return "", context.DeadlineExceeded
+				}
+				return "bd-1 [P1] Ready task", nil
+			},
+		}
+
+		result, _ := getTaskRecommendation(tmpDir, mock, 0, false)
+
+		if !strings.Contains(result, "timed out") || !strings.Contains(result, "bd-1 [P1] Ready task") {
+			t.Errorf("expected warning and bd ready output, got: %s", result)
+		}
+	})
+}
+
+func TestGetTaskDetails(t *testing.T) {
+	t.Run("includes title and description", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" && len(args) == 2 && args[0] == "show" && args[1] == "bd-7" {
+					return "Title: Add login\nStatus: open\nDescription:\nMust support SSO.\n- tests pass\nPriority: 1\n", nil
+				}
+				return "", errors.New("unexpected command")
+			},
+		}
+
+		result := getTaskDetails("/test", "bd-7", mock)
+		if !strings.Contains(result, "**Top task**: bd-7 \"Add login\"") {
+			t.Errorf("expected task header, got:\n%s", result)
+		}
+		if !strings.Contains(result, "Must support SSO.\n- tests pass") {
+			t.Errorf("expected full description, got:\n%s", result)
+		}
+	})
+
+	t.Run("no description", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "Title: Add login\nStatus: open\n", nil
+			},
+		}
+
+		if result := getTaskDetails("/test", "bd-7", mock); result != "" {
+			t.Errorf("expected no details, got %q", result)
+		}
+	})
+
+	t.Run("no task", func(t *testing.T) {
+		if result := getTaskDetails("/test", "", &MockRunner{}); result != "" {
+			t.Errorf("expected no details, got %q", result)
+		}
+	})
+}
+
+func TestSessionRender(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	triage := "1. bd-1 First task"
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			calls = append(calls, command+" "+strings.Join(args, " "))
+			if command == "git" && args[0] == "rev-parse" {
+				return "feature/x", nil
+			}
+			return "", nil
+		},
+		RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			calls = append(calls, command+" "+strings.Join(args, " "))
+			return triage, nil
+		},
+	}
+
+	session, err := NewSession(Options{Dir: tmpDir, Runner: mock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := session.Render(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	firstRender := len(calls)
+
+	calls = nil
+	triage = "1. bd-2 Second task"
+	doc, err := session.Render()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Logf("subprocess calls: %d for the first render, %d for a refresh", firstRender, len(calls))
+
+	// Refreshing re-runs triage and the top task's status check, not the git context
+	if len(calls) != 2 || calls[0] != "bv --robot-triage" || calls[1] != "bd show bd-2" {
+		t.Errorf("expected only triage and status check on refresh (first render made %d calls), got %v", firstRender, calls)
+	}
+	result := doc.Markdown()
+	if !strings.Contains(result, "bd-2 Second task") || !strings.Contains(result, "**Branch**: feature/x") {
+		t.Errorf("expected refreshed task with cached context, got:\n%s", result)
+	}
+}
+
+func TestSessionStaleTasks(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := time.Now().Add(-6 * 24 * time.Hour).Format(time.RFC3339)
+	mock := &MockRunner{
+		RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			switch {
+			case command == "bd" && args[0] == "list":
+				return "bd-12  Abandoned work  [in_progress]", nil
+			case command == "bd" && args[0] == "show":
+				return "Title: Abandoned work\nUpdated: " + updated, nil
+			}
+			return "1. bd-1 First task", nil
+		},
+	}
+
+	session, err := NewSession(Options{Dir: tmpDir, Runner: mock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, err := session.Render()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := doc.Markdown()
+	if !strings.Contains(result, "bd-12 has been in progress for 6 days") || !strings.Contains(result, "bd-1 First task") {
+		t.Errorf("expected staleness warning above the recommendation, got:\n%s", result)
+	}
+}
+
+func TestRenderSkipsClosedTasks(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// showMock lists bd-12 and bd-13, reporting the given tasks as closed
+	showMock := func(closed ...string) *MockRunner {
+		return &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" && args[0] == "show" {
+					for _, id := range closed {
+						if args[1] == id {
+							return "Title: Done already\nStatus: closed", nil
+						}
+					}
+					return "Title: Still open\nStatus: open", nil
+				}
+				if command == "bd" && args[0] == "list" {
+					return "", nil
+				}
+				return "1. bd-12 Done already\n2. bd-13 Still open", nil
+			},
+		}
+	}
+
+	t.Run("closed top task is skipped", func(t *testing.T) {
+		session, err := NewSession(Options{Dir: tmpDir, Runner: showMock("bd-12")})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		doc, err := session.Render()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		result := doc.Markdown()
+		if !strings.Contains(result, "bd-12 appears closed — refreshing recommendation") || !strings.Contains(result, "**Top open task**: bd-13") {
+			t.Errorf("expected closed task note, got:\n%s", result)
+		}
+		if !strings.Contains(result, "Claim: `bd update bd-13 --status in_progress`") {
+			t.Errorf("expected protocol to name the open task, got:\n%s", result)
+		}
+	})
+
+	t.Run("open top task needs no note", func(t *testing.T) {
+		session, err := NewSession(Options{Dir: tmpDir, Runner: showMock()})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		doc, err := session.Render()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result := doc.Markdown(); strings.Contains(result, "appears closed") || !strings.Contains(result, "the highest priority task") {
+			t.Errorf("expected unchanged recommendation, got:\n%s", result)
+		}
+	})
+
+	t.Run("custom template gets the recommended task", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, ".beads"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, ".vibes", "templates"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".vibes", "templates", "next.md"), []byte("Work on {{.task_id}}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		session, err := NewSession(Options{Dir: dir, Runner: showMock("bd-12")})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		doc, err := session.Render()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result := doc.Markdown(); !strings.Contains(result, "Work on bd-13") {
+			t.Errorf("expected template to render the recommended task, got:\n%s", result)
+		}
+	})
+
+	t.Run("selected task is closed", func(t *testing.T) {
+		result := getTask(tmpDir, "bd-12", showMock("bd-12"))
+		if !strings.Contains(result, "bd-12 is already closed") {
+			t.Errorf("expected closed warning, got:\n%s", result)
+		}
+	})
+
+	t.Run("refresh re-imports before triage", func(t *testing.T) {
+		var calls []string
+		mock := showMock()
+		list := mock.RunWithTimeoutFunc
+		mock.RunWithTimeoutFunc = func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			calls = append(calls, command+" "+strings.Join(args, " "))
+			return list(dir, timeout, command, args...)
+		}
+		session, err := NewSession(Options{Dir: tmpDir, Refresh: true, Runner: mock})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		calls = nil
+		if _, err := session.Render(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(calls) < 2 || calls[0] != "bd sync --import-only" || calls[1] != "bv --robot-triage" {
+			t.Errorf("expected refresh before triage, got %v", calls)
+		}
+	})
+}
+
+func TestSessionRenderTask(t *testing.T) {
+	mock := &MockRunner{
+		RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			if command == "bd" && args[0] == "show" && args[1] == "bd-42" {
+				return "ID: bd-42\nTitle: Add login page\nStatus: open\n", nil
+			}
+			t.Errorf("unexpected command: %s %v", command, args)
+			return "", nil
+		},
+	}
+
+	session, err := NewSession(Options{Dir: t.TempDir(), Task: "bd-42", Runner: mock})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, err := session.Render()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := doc.Markdown()
+	if !strings.Contains(result, "**Task**: bd-42 \"Add login page\"") {
+		t.Errorf("expected the given task instead of a recommendation, got:\n%s", result)
+	}
+	if !strings.Contains(result, "`bd update bd-42 --status in_progress`") || strings.Contains(result, "<id>") {
+		t.Errorf("expected protocol filled in with the task ID, got:\n%s", result)
+	}
+}
+
+func TestSessionRenderRecentClosed(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(t *testing.T, closed string, enabled bool) string {
+		t.Helper()
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" && args[0] == "list" {
+					return closed, nil
+				}
+				return "1. bd-3 Build frontend", nil
+			},
+		}
+		session, err := NewSession(Options{Dir: tmpDir, RecentClosed: enabled, Runner: mock})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		doc, err := session.Render()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return doc.Markdown()
+	}
+
+	t.Run("lists closed tasks before the recommendation", func(t *testing.T) {
+		result := render(t, `[{"id":"bd-2","title":"Build API layer","closed_at":"2026-10-15T09:00:00Z"}]`, true)
+		closed := strings.Index(result, "## Recently Closed\n- bd-2 Build API layer (closed ")
+		if closed < 0 || closed > strings.Index(result, "## Recommended Task") {
+			t.Errorf("expected closed tasks before the recommendation, got:\n%s", result)
+		}
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		if result := render(t, "[]", true); !strings.Contains(result, "No tasks closed yet.") {
+			t.Errorf("expected no-history note, got:\n%s", result)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		if result := render(t, `[{"id":"bd-2","title":"Build API layer"}]`, false); strings.Contains(result, "Recently Closed") {
+			t.Errorf("expected no closed section without the flag, got:\n%s", result)
+		}
+	})
 }
 
 func TestRun(t *testing.T) {
@@ -221,6 +625,40 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("strict mode fails without a task listing", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := Run(Options{Dir: tmpDir, Strict: true, Runner: &MockRunner{}}); !errors.Is(err, beads.ErrNotInitialized) {
+			t.Errorf("expected ErrNotInitialized, got %v", err)
+		}
+
+		if err := os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("executable file not found")
+			},
+		}
+		err := Run(Options{Dir: tmpDir, Strict: true, Runner: mock})
+		if err == nil || !strings.Contains(err.Error(), "could not list tasks") {
+			t.Errorf("expected triage error, got %v", err)
+		}
+
+		mock.RunWithTimeoutFunc = func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			return "", &runner.CommandError{Err: exec.ErrNotFound}
+		}
+		if err := Run(Options{Dir: tmpDir, Strict: true, Runner: mock}); !errors.Is(err, runner.ErrToolMissing) {
+			t.Errorf("expected ErrToolMissing when bv and bd are missing, got %v", err)
+		}
+
+		mock.RunWithTimeoutFunc = func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+			return "", nil
+		}
+		if err := Run(Options{Dir: tmpDir, Strict: true, Runner: mock}); !errors.Is(err, beads.ErrNoTasks) {
+			t.Errorf("expected ErrNoTasks for an empty listing, got %v", err)
+		}
+	})
+
 	t.Run("with nil runner uses default", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -0,0 +1,77 @@
+// Package notify sends completion notifications to a user-configured
+// command or webhook.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// Timeout bounds each delivery so a slow hook can't hold up the command
+const Timeout = 5 * time.Second
+
+// Options configures where notifications are delivered
+type Options struct {
+	Command string               // Shell command, run with the title and message as $1 and $2
+	Webhook string               // URL that receives a JSON POST
+	Runner  runner.CommandRunner // Command runner (defaults to runner.Default)
+}
+
+// Enabled reports whether any notification target is configured.
+func (o Options) Enabled() bool {
+	return o.Command != "" || o.Webhook != ""
+}
+
+// payload is the webhook body. "text" is what Slack-style incoming
+// webhooks display; title and message are there for everything else.
+type payload struct {
+	Text    string `json:"text"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// Send delivers a notification to every configured target. Failures are
+// logged and never returned, so a broken hook can't change a command's outcome.
+func Send(opts Options, title string, message string) {
+	if opts.Command != "" {
+		r := opts.Runner
+		if r == nil {
+			r = &runner.Default{}
+		}
+		if _, err := r.RunWithTimeout("", Timeout, "sh", "-c", opts.Command, "vibes", title, message); err != nil {
+			slog.Warn("notify command failed", "err", err)
+		}
+	}
+
+	if opts.Webhook != "" {
+		if err := post(opts.Webhook, payload{Text: title + ": " + message, Title: title, Message: message}); err != nil {
+			slog.Warn("notify webhook failed", "err", err)
+		}
+	}
+}
+
+// post sends the payload as JSON and treats any non-2xx response as an error.
+func post(url string, p payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: Timeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MockRunner is a mock implementation of runner.CommandRunner for testing
+type MockRunner struct {
+	RunFunc            func(dir string, command string, args ...string) (string, error)
+	RunWithTimeoutFunc func(dir string, timeout time.Duration, command string, args ...string) (string, error)
+}
+
+func (m *MockRunner) Run(dir string, command string, args ...string) (string, error) {
+	if m.RunFunc != nil {
+		return m.RunFunc(dir, command, args...)
+	}
+	return "", nil
+}
+
+func (m *MockRunner) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+	if m.RunWithTimeoutFunc != nil {
+		return m.RunWithTimeoutFunc(dir, timeout, command, args...)
+	}
+	return "", nil
+}
+
+func TestSend(t *testing.T) {
+	t.Run("runs command with title and message", func(t *testing.T) {
+		var got []string
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if timeout != Timeout {
+					t.Errorf("expected timeout %s, got %s", Timeout, timeout)
+				}
+				got = append([]string{command}, args...)
+				return "", nil
+			},
+		}
+
+		Send(Options{Command: `notify-send "$1" "$2"`, Runner: mock}, "vibes pr-fix", "PR #42: all checks passed")
+
+		expected := []string{"sh", "-c", `notify-send "$1" "$2"`, "vibes", "vibes pr-fix", "PR #42: all checks passed"}
+		if strings.Join(got, "|") != strings.Join(expected, "|") {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	})
+
+	t.Run("command failure is swallowed", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("exit status 1")
+			},
+		}
+		Send(Options{Command: "false", Runner: mock}, "title", "message")
+	})
+
+	t.Run("posts JSON to webhook", func(t *testing.T) {
+		var got payload
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected JSON content type, got %s", ct)
+			}
+			if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+		}))
+		defer server.Close()
+
+		Send(Options{Webhook: server.URL}, "vibes ralph", "iteration 3 ready")
+
+		if got.Text != "vibes ralph: iteration 3 ready" || got.Title != "vibes ralph" || got.Message != "iteration 3 ready" {
+			t.Errorf("unexpected payload: %+v", got)
+		}
+	})
+
+	t.Run("webhook error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		if err := post(server.URL, payload{Text: "x"}); err == nil || !strings.Contains(err.Error(), "500") {
+			t.Errorf("expected status error, got %v", err)
+		}
+		Send(Options{Webhook: server.URL}, "title", "message")
+	})
+}
+
+func TestEnabled(t *testing.T) {
+	if (Options{}).Enabled() {
+		t.Error("expected empty options to be disabled")
+	}
+	if !(Options{Webhook: "https://example.com/hook"}).Enabled() {
+		t.Error("expected webhook to enable notifications")
+	}
+}
@@ -3,6 +3,7 @@ package pr
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,7 +11,9 @@ import (
 
 	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/runner"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 // PRInfo holds information about an existing pull request
@@ -19,15 +22,26 @@ type PRInfo struct {
 	Title  string `json:"title"`
 	URL    string `json:"url"`
 	State  string `json:"state"`
+	Base   string `json:"baseRefName"`
 }
 
 // Options configures the pr command behavior
 type Options struct {
-	Dir     string               // Target directory (defaults to cwd)
-	Verbose bool                 // Include full protocol details
-	Runner  runner.CommandRunner // Command runner (defaults to runner.Default)
+	Dir          string               // Target directory (defaults to cwd)
+	Verbose      bool                 // Include full protocol details
+	CheckID      bool                 // Warn if the commit email doesn't belong to the gh account
+	Parent       string               // Branch this one is stacked on, used as the base (detected when empty)
+	Paths        git.PathMode         // How file paths are displayed (defaults to repo-root)
+	TemplateVars map[string]string    // Custom variables for protocol templates
+	Output       prompt.Output        // Render or post as a PR comment
+	Strict       bool                 // Fail instead of degrading when gh is missing or unauthenticated
+	Tracker      *beads.IssueTracker  // External issue tracker for branches without a bead ID (nil = Beads only)
+	Runner       runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
+// Sections lists every section this command can produce, in output order.
+var Sections = []string{"Existing PR", "Branch Info", "Task Context", "Linked Issues", "Commits", "Files Changed", "Protocol"}
+
 // Run executes the pr command and returns the prompt to stdout
 func Run(opts Options) error {
 	dir := opts.Dir
@@ -43,129 +57,156 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
+	r = git.NewCache(r)
 
-	var out strings.Builder
+	if opts.Strict {
+		if err := runner.RequireAuth(dir, r, "gh", "auth", "status"); err != nil {
+			return err
+		}
+	}
 
 	projectName := filepath.Base(dir)
 
 	// Get current branch and task context
 	branch := git.GetCurrentBranch(dir, r)
-	baseBranch := getBaseBranch(dir, r)
-	task := beads.DetectCurrentTask(dir, branch, r)
+	baseBranch := git.BaseBranch(dir, r)
+	task := beads.DetectCurrentTask(dir, branch, opts.Tracker, r)
 	task.ProjectName = projectName
 
-	// Check if we're on the base branch (early exit)
-	if branch == baseBranch || branch == "main" || branch == "master" {
-		out.WriteString(fmt.Sprintf("# Create Pull Request for %s\n\n", projectName))
-		out.WriteString("## Branch Info\n")
-		out.WriteString(fmt.Sprintf("- **Current**: %s\n", branch))
-		out.WriteString(fmt.Sprintf("- **Base**: %s\n", baseBranch))
-		out.WriteString("\n⚠️ You are on the base branch. Create a feature branch first:\n")
-		out.WriteString("```bash\n")
-		out.WriteString("git checkout -b feature/your-feature-name\n")
-		out.WriteString("```\n")
-		fmt.Print(out.String())
-		return nil
+	// PRs come from work branches, unless --parent says where this one goes (early exit)
+	if !git.IsWorkBranch(branch, baseBranch) && opts.Parent == "" {
+		doc := prompt.New(fmt.Sprintf("Create Pull Request for %s", projectName), Sections...)
+		var info strings.Builder
+		info.WriteString(fmt.Sprintf("- **Current**: %s\n", branch))
+		info.WriteString(fmt.Sprintf("- **Base**: %s\n", baseBranch))
+		if branch == baseBranch || branch == git.TrimRemote(baseBranch) {
+			info.WriteString("\n⚠️ You are on the base branch. Create a feature branch first:\n")
+		} else {
+			info.WriteString(fmt.Sprintf("\n⚠️ %s is a long-lived branch, not a work branch. To open a PR from it anyway, pass `--parent <target-branch>`. Otherwise create a feature branch first:\n", branch))
+		}
+		info.WriteString("```bash\n")
+		info.WriteString("git checkout -b feature/your-feature-name\n")
+		info.WriteString("```\n")
+		doc.Add(prompt.Summary, "Branch Info", info.String())
+		return prompt.Emit(dir, doc, opts.Output, r)
 	}
 
 	// Check for existing PR
 	existingPR := getExistingPR(dir, branch, r)
 
+	// --parent and an existing PR's base are authoritative; detection is only a guess
+	var commits, baseNote string
+	switch {
+	case opts.Parent != "":
+		baseBranch = git.ResolveBase(dir, opts.Parent, r)
+		commits = git.GetCommitsSince(dir, baseBranch, r)
+		baseNote = " (parent branch)"
+		slog.Info("base branch", "branch", baseBranch, "via", "--parent")
+	case existingPR != nil && existingPR.Base != "":
+		baseBranch = git.ResolveBase(dir, existingPR.Base, r)
+		commits = git.GetCommitsSince(dir, baseBranch, r)
+		slog.Info("base branch", "branch", baseBranch, "via", fmt.Sprintf("PR #%d", existingPR.Number))
+	default:
+		if parent := git.DetectParent(dir, branch, baseBranch, r); parent != "" {
+			baseBranch = parent
+			commits = git.GetCommitsSince(dir, baseBranch, r)
+			baseNote = " (detected parent branch; use --parent to override)"
+		} else {
+			commits = git.GetBranchCommits(dir, branch, r)
+		}
+	}
+
 	// Header - changes based on whether PR exists
+	var doc *prompt.Document
 	if existingPR != nil {
-		out.WriteString(fmt.Sprintf("# Pull Request #%d for %s\n\n", existingPR.Number, projectName))
-		out.WriteString("## Existing PR\n")
-		out.WriteString(fmt.Sprintf("- **PR**: #%d %s\n", existingPR.Number, existingPR.Title))
-		out.WriteString(fmt.Sprintf("- **Status**: %s\n", existingPR.State))
-		out.WriteString(fmt.Sprintf("- **URL**: %s\n", existingPR.URL))
-		out.WriteString("\n")
+		doc = prompt.New(fmt.Sprintf("Pull Request #%d for %s", existingPR.Number, projectName), Sections...)
+		var existing strings.Builder
+		existing.WriteString(fmt.Sprintf("- **PR**: #%d %s\n", existingPR.Number, existingPR.Title))
+		existing.WriteString(fmt.Sprintf("- **Status**: %s\n", existingPR.State))
+		existing.WriteString(fmt.Sprintf("- **URL**: %s\n", existingPR.URL))
+		existing.WriteString("\n")
+		doc.Add(prompt.Summary, "Existing PR", existing.String())
 	} else {
-		out.WriteString(fmt.Sprintf("# Create Pull Request for %s\n\n", projectName))
+		doc = prompt.New(fmt.Sprintf("Create Pull Request for %s", projectName), Sections...)
 	}
 
 	// Branch info section
-	out.WriteString("## Branch Info\n")
+	var info strings.Builder
 	if branch != "" {
-		out.WriteString(fmt.Sprintf("- **Current**: %s\n", branch))
+		info.WriteString(fmt.Sprintf("- **Current**: %s\n", branch))
 	}
-	out.WriteString(fmt.Sprintf("- **Base**: %s\n", baseBranch))
+	info.WriteString(fmt.Sprintf("- **Base**: %s%s\n", baseBranch, baseNote))
 
 	// Commits ahead
-	commits := git.GetBranchCommits(dir, branch, r)
 	if commits != "" {
 		commitCount := git.CountLines(commits)
-		out.WriteString(fmt.Sprintf("- **Commits**: %d ahead of %s\n", commitCount, baseBranch))
+		info.WriteString(fmt.Sprintf("- **Commits**: %d ahead of %s\n", commitCount, baseBranch))
 	}
 
 	// Diff stats
 	diffStats := getDiffStats(dir, baseBranch, r)
 	if diffStats != "" {
-		out.WriteString(fmt.Sprintf("- **Changes**: %s\n", diffStats))
+		info.WriteString(fmt.Sprintf("- **Changes**: %s\n", diffStats))
 	}
 
 	// Working tree status
 	status := git.GetWorkingTreeStatus(dir, r)
 	if status != "" {
-		out.WriteString(fmt.Sprintf("- **Working tree**: %s (uncommitted)\n", status))
+		info.WriteString(fmt.Sprintf("- **Working tree**: %s (uncommitted)\n", status))
 	}
-	out.WriteString("\n")
+
+	// Commit identity
+	if opts.CheckID {
+		if warning := git.CheckIdentity(dir, r); warning != "" {
+			info.WriteString(fmt.Sprintf("- %s\n", warning))
+		}
+	}
+	info.WriteString("\n")
+	doc.Add(prompt.Summary, "Branch Info", info.String())
 
 	// Task context section (if available)
 	if task.ID != "" {
-		out.WriteString("## Task Context\n")
 		if task.Title != "" {
-			out.WriteString(fmt.Sprintf("- **Bead**: %s \"%s\"\n", task.ID, task.Title))
+			doc.Add(prompt.Summary, "Task Context", fmt.Sprintf("- **Bead**: %s \"%s\"\n\n", task.ID, task.Title))
 		} else {
-			out.WriteString(fmt.Sprintf("- **Bead**: %s\n", task.ID))
+			doc.Add(prompt.Summary, "Task Context", fmt.Sprintf("- **Bead**: %s\n\n", task.ID))
 		}
-		out.WriteString("\n")
+	}
+
+	// Issues the PR closes
+	prNumber := 0
+	if existingPR != nil {
+		prNumber = existingPR.Number
+	}
+	messages := git.GetCommitMessages(dir, baseBranch, r)
+	if linked := git.FormatLinkedIssues(git.GetLinkedIssues(dir, prNumber, []string{messages}, r)); linked != "" {
+		doc.Add(prompt.Summary, "Linked Issues", linked)
 	}
 
 	// Commits section
 	if commits != "" {
-		out.WriteString("## Commits\n")
-		out.WriteString("```\n")
-		out.WriteString(commits)
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Commits", "```\n"+commits+"\n```\n\n")
 	}
 
 	// Files changed section
-	filesChanged := getFilesChanged(dir, baseBranch, r)
+	filesChanged := git.NewPathRewriter(dir, opts.Paths, r).RewriteNameStatus(getFilesChanged(dir, baseBranch, r))
 	if filesChanged != "" {
-		out.WriteString("## Files Changed\n")
-		out.WriteString("```\n")
-		out.WriteString(filesChanged)
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Files Changed", "```\n"+filesChanged+"\n```\n\n")
 	}
 
 	// Protocol
-	out.WriteString("## Protocol\n")
+	protocol := getProtocol(task, git.TrimRemote(baseBranch), opts.Verbose)
 	if existingPR != nil {
-		out.WriteString(getExistingPRProtocol(existingPR, opts.Verbose))
-	} else {
-		out.WriteString(getProtocol(task, baseBranch, opts.Verbose))
+		protocol = getExistingPRProtocol(existingPR, opts.Verbose)
 	}
-
-	fmt.Print(out.String())
-	return nil
-}
-
-// getBaseBranch determines the base branch (main or master)
-func getBaseBranch(dir string, r runner.CommandRunner) string {
-	// Check if main exists
-	_, err := r.Run(dir, "git", "rev-parse", "--verify", "main")
-	if err == nil {
-		return "main"
-	}
-
-	// Check if master exists
-	_, err = r.Run(dir, "git", "rev-parse", "--verify", "master")
-	if err == nil {
-		return "master"
+	vars := templates.Merge(templates.Builtins(task.ID, projectName, branch), opts.TemplateVars)
+	protocol, err := templates.Protocol(dir, "pr", protocol, vars)
+	if err != nil {
+		return err
 	}
+	doc.Add(prompt.Protocol, "Protocol", protocol)
 
-	// Default to main
-	return "main"
+	return prompt.Emit(dir, doc, opts.Output, r)
 }
 
 // getDiffStats returns a summary of the diff (files changed, insertions, deletions)
@@ -257,7 +298,7 @@ Please review the changes and create the pull request.
 
 // getExistingPR checks if a PR already exists for the given branch
 func getExistingPR(dir string, branch string, r runner.CommandRunner) *PRInfo {
-	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "list", "--head", branch, "--json", "number,title,url,state", "--limit", "1")
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "list", "--head", branch, "--json", "number,title,url,state,baseRefName", "--limit", "1")
 	if err != nil || output == "" {
 		return nil
 	}
@@ -1,11 +1,14 @@
 package pr
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/prompt"
 )
 
 // MockRunner is a mock implementation of runner.CommandRunner for testing
@@ -94,56 +97,6 @@ func TestGetProtocol(t *testing.T) {
 	})
 }
 
-func TestGetBaseBranch(t *testing.T) {
-	t.Run("returns main when main exists", func(t *testing.T) {
-		mock := &MockRunner{
-			RunFunc: func(dir string, command string, args ...string) (string, error) {
-				if command == "git" && len(args) >= 3 && args[2] == "main" {
-					return "abc123", nil
-				}
-				return "", nil
-			},
-		}
-
-		result := getBaseBranch("/test", mock)
-		if result != "main" {
-			t.Errorf("expected main, got %s", result)
-		}
-	})
-
-	t.Run("returns master when main doesn't exist", func(t *testing.T) {
-		mock := &MockRunner{
-			RunFunc: func(dir string, command string, args ...string) (string, error) {
-				if command == "git" && len(args) >= 3 && args[2] == "main" {
-					return "", &mockError{}
-				}
-				if command == "git" && len(args) >= 3 && args[2] == "master" {
-					return "abc123", nil
-				}
-				return "", nil
-			},
-		}
-
-		result := getBaseBranch("/test", mock)
-		if result != "master" {
-			t.Errorf("expected master, got %s", result)
-		}
-	})
-
-	t.Run("defaults to main when neither exists", func(t *testing.T) {
-		mock := &MockRunner{
-			RunFunc: func(dir string, command string, args ...string) (string, error) {
-				return "", &mockError{}
-			},
-		}
-
-		result := getBaseBranch("/test", mock)
-		if result != "main" {
-			t.Errorf("expected main as default, got %s", result)
-		}
-	})
-}
-
 func TestGetDiffStats(t *testing.T) {
 	t.Run("returns summary line", func(t *testing.T) {
 		mock := &MockRunner{
@@ -247,6 +200,135 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("existing PR base overrides detection", func(t *testing.T) {
+		var ranges []string
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if args[0] == "rev-parse" && args[1] == "--abbrev-ref" {
+					return "feature/bd-123-test", nil
+				}
+				if args[0] == "rev-parse" && args[1] == "--verify" {
+					return "abc123", nil // main and develop both exist
+				}
+				if rng := args[len(args)-1]; strings.Contains(rng, "..") {
+					ranges = append(ranges, rng)
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "gh" && args[1] == "list" {
+					return `[{"number":42,"title":"Test PR","url":"u","state":"OPEN","baseRefName":"develop"}]`, nil
+				}
+				return "", nil
+			},
+		}
+
+		if err := Run(Options{Dir: t.TempDir(), Runner: mock}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(ranges) == 0 {
+			t.Fatal("expected log and diff calls")
+		}
+		for _, rng := range ranges {
+			if !strings.HasPrefix(rng, "develop..") {
+				t.Errorf("expected range based on develop, got %s", rng)
+			}
+		}
+	})
+
+	t.Run("stacked branch diffs against its parent", func(t *testing.T) {
+		// stackMock records ranges; detected is what git branch --merged lists
+		stackMock := func(ranges *[]string, detected string) *MockRunner {
+			return &MockRunner{
+				RunFunc: func(dir string, command string, args ...string) (string, error) {
+					if args[0] == "rev-parse" && args[1] == "--abbrev-ref" && args[2] == "HEAD" {
+						return "feature/ui", nil
+					}
+					if args[0] == "rev-parse" && args[1] == "--verify" {
+						return "abc123", nil
+					}
+					if args[0] == "branch" {
+						return detected, nil
+					}
+					if args[0] == "rev-list" {
+						return "2", nil
+					}
+					if rng := args[len(args)-1]; strings.Contains(rng, "..") {
+						*ranges = append(*ranges, rng)
+					}
+					return "", nil
+				},
+			}
+		}
+
+		for _, tc := range []struct {
+			name     string
+			parent   string
+			detected string
+		}{
+			{"--parent", "feature/auth", ""},
+			{"--parent wins over detection", "feature/auth", "feature/db"},
+			{"detected", "", "feature/auth"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				var ranges []string
+				if err := Run(Options{Dir: t.TempDir(), Parent: tc.parent, Runner: stackMock(&ranges, tc.detected)}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(ranges) == 0 {
+					t.Fatal("expected log and diff calls")
+				}
+				for _, rng := range ranges {
+					if !strings.HasPrefix(rng, "feature/auth..") {
+						t.Errorf("expected range based on feature/auth, got %s", rng)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("long-lived branches need --parent", func(t *testing.T) {
+		// render runs pr on branch and returns the user message
+		render := func(t *testing.T, branch string, parent string) string {
+			t.Helper()
+			mock := &MockRunner{
+				RunFunc: func(dir string, command string, args ...string) (string, error) {
+					if args[0] == "rev-parse" && args[1] == "--abbrev-ref" && args[2] == "HEAD" {
+						return branch, nil
+					}
+					if args[0] == "rev-parse" && args[1] == "--verify" {
+						return "abc123", nil
+					}
+					return "", nil
+				},
+			}
+			out := filepath.Join(t.TempDir(), "out")
+			if err := Run(Options{Dir: t.TempDir(), Parent: parent, Output: prompt.Output{OutputDir: out}, Runner: mock}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			user, err := os.ReadFile(filepath.Join(out, prompt.UserFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(user)
+		}
+
+		if user := render(t, "main", ""); !strings.Contains(user, "You are on the base branch") {
+			t.Errorf("expected base branch warning, got:\n%s", user)
+		}
+		if user := render(t, "develop", ""); !strings.Contains(user, "develop is a long-lived branch") {
+			t.Errorf("expected long-lived branch warning, got:\n%s", user)
+		}
+		if user := render(t, "develop", "main"); strings.Contains(user, "⚠️") {
+			t.Errorf("expected --parent to allow a PR from develop, got:\n%s", user)
+		}
+		for _, branch := range []string{"fix/login", "hotfix/1.2.1", "chore/deps", "bd-12-login"} {
+			if user := render(t, branch, ""); strings.Contains(user, "⚠️") {
+				t.Errorf("expected %s treated as a work branch, got:\n%s", branch, user)
+			}
+		}
+	})
+
 	t.Run("on main branch shows warning", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -9,8 +9,12 @@ import (
 	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/emoji"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/notify"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/runner"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 // PRInfo holds information about an existing pull request
@@ -22,6 +26,7 @@ type PRInfo struct {
 	Mergeable string `json:"mergeable"`
 	BaseRef   string `json:"baseRefName"`
 	HeadRef   string `json:"headRefName"`
+	Body      string `json:"body"`
 }
 
 // CheckInfo holds information about a CI check
@@ -54,9 +59,15 @@ type ReviewComment struct {
 
 // Options configures the pr-fix command behavior
 type Options struct {
-	Dir     string               // Target directory (defaults to cwd)
-	Verbose bool                 // Include full protocol details
-	Runner  runner.CommandRunner // Command runner (defaults to runner.Default)
+	Dir          string               // Target directory (defaults to cwd)
+	Verbose      bool                 // Include full protocol details
+	TemplateVars map[string]string    // Custom variables for protocol templates
+	Strict       bool                 // Fail instead of degrading when gh is missing or unauthenticated
+	Notify       notify.Options       // Where to report the PR's state when done (disabled when empty)
+	Resolve      bool                 // Resolve addressed review threads instead of printing a prompt
+	OnlyFailing  bool                 // Show only failing/pending checks, changes requested, and unresolved threads
+	Tracker      *beads.IssueTracker  // External issue tracker for branches without a bead ID (nil = Beads only)
+	Runner       runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
 // Run executes the pr-fix command and returns the prompt to stdout
@@ -74,6 +85,13 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
+	r = git.NewCache(r)
+
+	if opts.Strict {
+		if err := runner.RequireAuth(dir, r, "gh", "auth", "status"); err != nil {
+			return err
+		}
+	}
 
 	var out strings.Builder
 
@@ -84,12 +102,18 @@ func Run(opts Options) error {
 	if branch == "" {
 		out.WriteString(fmt.Sprintf("# Fix PR Issues for %s\n\n", projectName))
 		out.WriteString("⚠️ Could not determine current branch.\n")
-		fmt.Print(out.String())
+		fmt.Print(emoji.Render(out.String()))
 		return nil
 	}
 
 	// Get existing PR
 	pr := getExistingPR(dir, branch, r)
+	if opts.Resolve {
+		if pr == nil {
+			return fmt.Errorf("no pull request found for branch %s", branch)
+		}
+		return resolveComments(dir, pr, r)
+	}
 	if pr == nil {
 		out.WriteString(fmt.Sprintf("# Fix PR Issues for %s\n\n", projectName))
 		out.WriteString("## No PR Found\n")
@@ -98,12 +122,12 @@ func Run(opts Options) error {
 		out.WriteString("```bash\n")
 		out.WriteString("claude \"$(vibes pr)\"\n")
 		out.WriteString("```\n")
-		fmt.Print(out.String())
+		fmt.Print(emoji.Render(out.String()))
 		return nil
 	}
 
 	// Get task context
-	task := beads.DetectCurrentTask(dir, branch, r)
+	task := beads.DetectCurrentTask(dir, branch, opts.Tracker, r)
 	task.ProjectName = projectName
 
 	// Header
@@ -125,85 +149,59 @@ func Run(opts Options) error {
 		if task.Title != "" {
 			out.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"\n", task.ID, task.Title))
 		} else {
-			out.WriteString(fmt.Sprintf("- **Task**: %s\n", task.ID))
+			out.WriteString(fmt.Sprintf("- **Task**: %s\n", task.Ref()))
 		}
 	}
 	out.WriteString("\n")
 
+	// The author's stated intent
+	if description := formatDescription(pr.Body); description != "" {
+		out.WriteString("## PR Description\n")
+		out.WriteString(description)
+	}
+
+	// Issues the PR closes
+	messages := git.GetCommitMessages(dir, pr.BaseRef, r)
+	if linked := git.FormatLinkedIssues(git.GetLinkedIssues(dir, pr.Number, []string{messages}, r)); linked != "" {
+		out.WriteString("## Linked Issues\n")
+		out.WriteString(linked)
+	}
+
 	// CI Checks section
 	checks := getChecks(dir, pr.Number, r)
-	failingChecks, passingChecks, pendingChecks := categorizeChecks(checks)
+	failingChecks, passingChecks, pendingChecks, skippedChecks := categorizeChecks(checks)
+
+	// Branch protection is only consulted when something was skipped
+	var required map[string]bool
+	if len(skippedChecks) > 0 {
+		required = getRequiredChecks(dir, pr.BaseRef, r)
+	}
 
 	out.WriteString("## CI Checks\n")
 	if len(checks) == 0 {
 		out.WriteString("No CI checks configured.\n")
 	} else {
-		// Summary line
-		out.WriteString(fmt.Sprintf("- ✅ Passing: %d\n", len(passingChecks)))
-		out.WriteString(fmt.Sprintf("- ❌ Failing: %d\n", len(failingChecks)))
-		out.WriteString(fmt.Sprintf("- ⏳ Pending: %d\n", len(pendingChecks)))
-		out.WriteString("\n")
-
-		// Show failing checks in detail
-		if len(failingChecks) > 0 {
-			out.WriteString("### Failing Checks\n")
-			out.WriteString("```\n")
-			for _, check := range failingChecks {
-				out.WriteString(fmt.Sprintf("❌ %s\n", check.Name))
-				if check.DetailsURL != "" {
-					out.WriteString(fmt.Sprintf("   %s\n", check.DetailsURL))
-				}
-			}
-			out.WriteString("```\n")
-		}
-
-		// Show pending checks
-		if len(pendingChecks) > 0 {
-			out.WriteString("### Pending Checks\n")
-			out.WriteString("```\n")
-			for _, check := range pendingChecks {
-				out.WriteString(fmt.Sprintf("⏳ %s\n", check.Name))
-			}
-			out.WriteString("```\n")
-		}
+		out.WriteString(formatChecks(failingChecks, passingChecks, pendingChecks, skippedChecks, required, opts.OnlyFailing))
 	}
 	out.WriteString("\n")
 
 	// Reviews section
 	reviews := getReviews(dir, pr.Number, r)
 	comments := getReviewComments(dir, pr.Number, r)
-
-	out.WriteString("## Reviews\n")
-	if len(reviews) == 0 && len(comments) == 0 {
-		out.WriteString("No reviews yet.\n")
-	} else {
-		// Show review states
-		for _, review := range reviews {
-			emoji := getReviewEmoji(review.State)
-			out.WriteString(fmt.Sprintf("- %s **%s**: %s\n", emoji, review.Author, review.State))
-		}
-
-		// Show review comments
-		if len(comments) > 0 {
-			out.WriteString("\n### Review Comments\n")
-			for _, comment := range comments {
-				out.WriteString(fmt.Sprintf("\n**@%s** on `%s", comment.Author.Login, comment.Path))
-				if comment.Line > 0 {
-					out.WriteString(fmt.Sprintf(":%d", comment.Line))
-				}
-				out.WriteString("`:\n")
-				// Indent the comment body
-				lines := strings.Split(comment.Body, "\n")
-				for _, line := range lines {
-					out.WriteString(fmt.Sprintf("> %s\n", line))
-				}
-			}
+	if opts.OnlyFailing {
+		// Comments on resolved threads need no action; keep them all if
+		// resolution can't be checked
+		if threads, err := getReviewThreads(dir, pr.Number, r); err == nil {
+			comments = threadComments(threads)
 		}
 	}
+
+	out.WriteString("## Reviews\n")
+	out.WriteString(formatReviews(reviews, comments, opts.OnlyFailing))
 	out.WriteString("\n")
 
 	// Determine what needs to be fixed
-	issues := determineIssues(pr, failingChecks, pendingChecks, reviews, comments)
+	issues := determineIssues(pr, failingChecks, pendingChecks, skippedRequired(skippedChecks, required), reviews, comments)
 
 	// Instructions section
 	out.WriteString("## Issues to Address\n")
@@ -221,16 +219,55 @@ func Run(opts Options) error {
 	}
 
 	// Protocol
+	vars := templates.Merge(templates.Builtins(task.ID, projectName, branch), opts.TemplateVars)
+	shared := sharedBranchWarning(getBranchAuthors(dir, pr, r), reviews, comments)
+	protocol, err := templates.Protocol(dir, "pr-fix", getProtocol(pr, issues, shared, opts.Verbose), vars)
+	if err != nil {
+		return err
+	}
 	out.WriteString("## Protocol\n")
-	out.WriteString(getProtocol(pr, issues, opts.Verbose))
+	out.WriteString(protocol)
 
-	fmt.Print(out.String())
+	fmt.Print(emoji.Render(out.String()))
+
+	if opts.Notify.Enabled() {
+		notify.Send(opts.Notify, "vibes pr-fix", notifySummary(pr, failingChecks, pendingChecks, issues))
+	}
 	return nil
 }
 
+// notifySummary condenses the PR's state into a one-line notification.
+func notifySummary(pr *PRInfo, failingChecks, pendingChecks []CheckInfo, issues []string) string {
+	prefix := fmt.Sprintf("PR #%d", pr.Number)
+	switch {
+	case len(failingChecks) > 0:
+		return fmt.Sprintf("%s: %d check(s) failed", prefix, len(failingChecks))
+	case len(pendingChecks) > 0:
+		return fmt.Sprintf("%s: %d check(s) still running", prefix, len(pendingChecks))
+	case len(issues) == 0:
+		return prefix + ": checks passed, ready to merge"
+	default:
+		return fmt.Sprintf("%s: checks passed, %d issue(s) to address", prefix, len(issues))
+	}
+}
+
+// maxDescriptionLines bounds the PR body included in the prompt
+const maxDescriptionLines = 40
+
+// formatDescription folds the PR body into a collapsible block, or returns
+// empty string if the PR has no description.
+func formatDescription(body string) string {
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if body == "" {
+		return ""
+	}
+	return "<details>\n<summary>Author's description</summary>\n\n" +
+		prompt.TruncateLines(body, maxDescriptionLines) + "\n\n</details>\n\n"
+}
+
 // getExistingPR checks if a PR already exists for the given branch
 func getExistingPR(dir string, branch string, r runner.CommandRunner) *PRInfo {
-	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "view", "--json", "number,title,url,state,mergeable,baseRefName,headRefName")
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "view", "--json", "number,title,url,state,mergeable,baseRefName,headRefName,body")
 	if err != nil || output == "" {
 		return nil
 	}
@@ -258,14 +295,18 @@ func getChecks(dir string, prNumber int, r runner.CommandRunner) []CheckInfo {
 	return checks
 }
 
-// categorizeChecks separates checks into failing, passing, and pending
-func categorizeChecks(checks []CheckInfo) (failing, passing, pending []CheckInfo) {
+// categorizeChecks separates checks into failing, passing, pending, and skipped.
+// Skipped and neutral checks are kept apart from passing ones because a
+// skipped required check still blocks merging.
+func categorizeChecks(checks []CheckInfo) (failing, passing, pending, skipped []CheckInfo) {
 	for _, check := range checks {
 		switch {
 		case check.Status != "COMPLETED":
 			pending = append(pending, check)
-		case check.Conclusion == "SUCCESS" || check.Conclusion == "SKIPPED" || check.Conclusion == "NEUTRAL":
+		case check.Conclusion == "SUCCESS":
 			passing = append(passing, check)
+		case check.Conclusion == "SKIPPED" || check.Conclusion == "NEUTRAL":
+			skipped = append(skipped, check)
 		default:
 			failing = append(failing, check)
 		}
@@ -273,6 +314,176 @@ func categorizeChecks(checks []CheckInfo) (failing, passing, pending []CheckInfo
 	return
 }
 
+// getRequiredChecks returns the status checks branch protection requires on
+// base. Best-effort: unprotected branches and missing permissions yield nil.
+func getRequiredChecks(dir string, base string, r runner.CommandRunner) map[string]bool {
+	if base == "" {
+		return nil
+	}
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "api", fmt.Sprintf("repos/{owner}/{repo}/branches/%s/protection", base))
+	if err != nil || output == "" {
+		return nil
+	}
+
+	var protection struct {
+		RequiredStatusChecks struct {
+			Contexts []string `json:"contexts"`
+			Checks   []struct {
+				Context string `json:"context"`
+			} `json:"checks"`
+		} `json:"required_status_checks"`
+	}
+	if err := json.Unmarshal([]byte(output), &protection); err != nil {
+		return nil
+	}
+
+	required := map[string]bool{}
+	for _, name := range protection.RequiredStatusChecks.Contexts {
+		required[name] = true
+	}
+	for _, check := range protection.RequiredStatusChecks.Checks {
+		required[check.Context] = true
+	}
+	return required
+}
+
+// skippedRequired returns the names of skipped checks that are required
+func skippedRequired(skipped []CheckInfo, required map[string]bool) []string {
+	var names []string
+	for _, check := range skipped {
+		if required[check.Name] {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// formatChecks renders the CI Checks section body. With onlyFailing,
+// passing and non-required skipped checks are reduced to a count.
+func formatChecks(failing, passing, pending, skipped []CheckInfo, required map[string]bool, onlyFailing bool) string {
+	var out strings.Builder
+
+	// Summary line
+	if onlyFailing {
+		hidden := fmt.Sprintf("%d passing", len(passing))
+		if n := len(skipped) - len(skippedRequired(skipped, required)); n > 0 {
+			hidden += fmt.Sprintf(", %d skipped", n)
+		}
+		out.WriteString(fmt.Sprintf("- ✅ %s (not shown)\n", hidden))
+		if len(failing) > 0 {
+			out.WriteString(fmt.Sprintf("- ❌ Failing: %d\n", len(failing)))
+		}
+		if len(pending) > 0 {
+			out.WriteString(fmt.Sprintf("- ⏳ Pending: %d\n", len(pending)))
+		}
+	} else {
+		out.WriteString(fmt.Sprintf("- ✅ Passing: %d\n", len(passing)))
+		out.WriteString(fmt.Sprintf("- ❌ Failing: %d\n", len(failing)))
+		out.WriteString(fmt.Sprintf("- ⏳ Pending: %d\n", len(pending)))
+		out.WriteString(fmt.Sprintf("- ⏭️ Skipped: %d\n", len(skipped)))
+	}
+	out.WriteString("\n")
+
+	// Show failing checks in detail
+	if len(failing) > 0 {
+		out.WriteString("### Failing Checks\n")
+		out.WriteString("```\n")
+		for _, check := range failing {
+			out.WriteString(fmt.Sprintf("❌ %s\n", check.Name))
+			if check.DetailsURL != "" {
+				out.WriteString(fmt.Sprintf("   %s\n", check.DetailsURL))
+			}
+		}
+		out.WriteString("```\n")
+	}
+
+	// Show pending checks
+	if len(pending) > 0 {
+		out.WriteString("### Pending Checks\n")
+		out.WriteString("```\n")
+		for _, check := range pending {
+			out.WriteString(fmt.Sprintf("⏳ %s\n", check.Name))
+		}
+		out.WriteString("```\n")
+	}
+
+	// Show skipped checks, marking the ones branch protection requires
+	if onlyFailing {
+		var blocking []CheckInfo
+		for _, check := range skipped {
+			if required[check.Name] {
+				blocking = append(blocking, check)
+			}
+		}
+		skipped = blocking
+	}
+	if len(skipped) > 0 {
+		out.WriteString("### Skipped Checks\n")
+		out.WriteString("```\n")
+		for _, check := range skipped {
+			line := fmt.Sprintf("⏭️ %s (%s)", check.Name, strings.ToLower(check.Conclusion))
+			if required[check.Name] {
+				line += " - required"
+			}
+			out.WriteString(line + "\n")
+		}
+		out.WriteString("```\n")
+	}
+	return out.String()
+}
+
+// formatReviews renders the Reviews section body. With onlyFailing, only
+// reviews requesting changes are listed and the rest are counted.
+func formatReviews(reviews []ReviewInfo, comments []ReviewComment, onlyFailing bool) string {
+	var out strings.Builder
+
+	shown := reviews
+	if onlyFailing {
+		shown = nil
+		for _, review := range reviews {
+			if strings.ToUpper(review.State) == "CHANGES_REQUESTED" {
+				shown = append(shown, review)
+			}
+		}
+	}
+
+	if len(shown) == 0 && len(comments) == 0 {
+		if onlyFailing && len(reviews) > 0 {
+			out.WriteString(fmt.Sprintf("No changes requested or unresolved comments (%d other review(s) not shown).\n", len(reviews)))
+		} else {
+			out.WriteString("No reviews yet.\n")
+		}
+		return out.String()
+	}
+
+	// Show review states
+	for _, review := range shown {
+		emoji := getReviewEmoji(review.State)
+		out.WriteString(fmt.Sprintf("- %s **%s**: %s\n", emoji, review.Author, review.State))
+	}
+	if hidden := len(reviews) - len(shown); hidden > 0 {
+		out.WriteString(fmt.Sprintf("- %d other review(s) not shown\n", hidden))
+	}
+
+	// Show review comments
+	if len(comments) > 0 {
+		out.WriteString("\n### Review Comments\n")
+		for _, comment := range comments {
+			out.WriteString(fmt.Sprintf("\n**@%s** on `%s", comment.Author.Login, comment.Path))
+			if comment.Line > 0 {
+				out.WriteString(fmt.Sprintf(":%d", comment.Line))
+			}
+			out.WriteString("`:\n")
+			// Indent the comment body
+			lines := strings.Split(comment.Body, "\n")
+			for _, line := range lines {
+				out.WriteString(fmt.Sprintf("> %s\n", line))
+			}
+		}
+	}
+	return out.String()
+}
+
 // getReviews retrieves review information for the PR
 func getReviews(dir string, prNumber int, r runner.CommandRunner) []ReviewInfo {
 	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "view", fmt.Sprintf("%d", prNumber), "--json", "reviews")
@@ -375,7 +586,7 @@ func getReviewEmoji(state string) string {
 }
 
 // determineIssues analyzes the PR state and returns a list of issues to address
-func determineIssues(pr *PRInfo, failingChecks, pendingChecks []CheckInfo, reviews []ReviewInfo, comments []ReviewComment) []string {
+func determineIssues(pr *PRInfo, failingChecks, pendingChecks []CheckInfo, skippedRequired []string, reviews []ReviewInfo, comments []ReviewComment) []string {
 	var issues []string
 
 	// Merge conflicts
@@ -392,6 +603,11 @@ func determineIssues(pr *PRInfo, failingChecks, pendingChecks []CheckInfo, revie
 		issues = append(issues, fmt.Sprintf("**CI failures** - Fix: %s", strings.Join(checkNames, ", ")))
 	}
 
+	// Required checks that were skipped still block merging
+	for _, name := range skippedRequired {
+		issues = append(issues, fmt.Sprintf("⚠️ **Required check %s was skipped** - It blocks merging until it runs; check the workflow's conditions", name))
+	}
+
 	// Changes requested
 	for _, review := range reviews {
 		if strings.ToUpper(review.State) == "CHANGES_REQUESTED" {
@@ -413,7 +629,63 @@ func determineIssues(pr *PRInfo, failingChecks, pendingChecks []CheckInfo, revie
 	return issues
 }
 
-func getProtocol(pr *PRInfo, issues []string, verbose bool) string {
+// getBranchAuthors returns the distinct author emails of the PR's commits as
+// GitHub reports them, so forks and differently named remotes don't matter
+func getBranchAuthors(dir string, pr *PRInfo, r runner.CommandRunner) []string {
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "gh", "pr", "view", fmt.Sprintf("%d", pr.Number), "--json", "commits")
+	if err != nil || output == "" {
+		return nil
+	}
+
+	var result struct {
+		Commits []struct {
+			Authors []struct {
+				Email string `json:"email"`
+			} `json:"authors"`
+		} `json:"commits"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return nil
+	}
+
+	var authors []string
+	seen := map[string]bool{}
+	for _, commit := range result.Commits {
+		for _, author := range commit.Authors {
+			email := strings.ToLower(strings.TrimSpace(author.Email))
+			if email != "" && !seen[email] {
+				seen[email] = true
+				authors = append(authors, email)
+			}
+		}
+	}
+	return authors
+}
+
+// sharedBranchWarning explains why force-pushing the branch could clobber
+// someone else's work: other committers, or reviews in progress whose
+// comments are anchored to the current commits. Empty if there's no risk.
+func sharedBranchWarning(authors []string, reviews []ReviewInfo, comments []ReviewComment) string {
+	var reasons []string
+	if len(authors) > 1 {
+		reasons = append(reasons, fmt.Sprintf("%d people have pushed commits", len(authors)))
+	}
+	inReview := len(comments) > 0
+	for _, review := range reviews {
+		if state := strings.ToUpper(review.State); state == "PENDING" || state == "CHANGES_REQUESTED" || state == "COMMENTED" {
+			inReview = true
+		}
+	}
+	if inReview {
+		reasons = append(reasons, "reviews are in progress")
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ Shared branch (%s) — coordinate before force-pushing, and still use --force-with-lease", strings.Join(reasons, ", "))
+}
+
+func getProtocol(pr *PRInfo, issues []string, shared string, verbose bool) string {
 	if len(issues) == 0 {
 		// No issues - ready to merge
 		if verbose {
@@ -442,6 +714,11 @@ Proceed with merging when ready.
 `, pr.Number)
 	}
 
+	forcePush := ""
+	if shared != "" {
+		forcePush = "\n   " + shared
+	}
+
 	if verbose {
 		return fmt.Sprintf(`1. **Investigate failures**:
    `+"```bash"+`
@@ -457,7 +734,7 @@ Proceed with merging when ready.
    git add <resolved-files>
    git rebase --continue
    git push --force-with-lease
-   `+"```"+`
+   `+"```"+`%s
 
 3. **For CI failures**:
    - Check the logs at the details URL
@@ -481,16 +758,16 @@ Proceed with merging when ready.
    `+"```"+`
 
 Address the issues listed above.
-`, pr.Number, pr.Number, pr.BaseRef, pr.BaseRef, pr.Number)
+`, pr.Number, pr.Number, pr.BaseRef, pr.BaseRef, forcePush, pr.Number)
 	}
 
 	return fmt.Sprintf(`1. Investigate: `+"`gh pr checks %d`"+` and `+"`gh pr view %d --comments`"+`
-2. For conflicts: rebase on %s and resolve
+2. For conflicts: rebase on %s and resolve%s
 3. For CI failures: check logs, fix issues, push
 4. For review comments: address and reply
 5. Push fixes: `+"`git push`"+`
 6. Re-check: `+"`claude \"$(vibes pr-fix)\"`"+`
 
 Address the issues listed above.
-`, pr.Number, pr.Number, pr.BaseRef)
+`, pr.Number, pr.Number, pr.BaseRef, forcePush)
 }
@@ -1,6 +1,7 @@
 package prfix
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -38,7 +39,7 @@ func TestGetExistingPR(t *testing.T) {
 		mock := &MockRunner{
 			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
 				if command == "gh" && len(args) >= 2 && args[0] == "pr" && args[1] == "view" {
-					return `{"number":42,"title":"Test PR","url":"https://github.com/test/repo/pull/42","state":"OPEN","mergeable":"MERGEABLE","baseRefName":"main","headRefName":"feature/test"}`, nil
+					return `{"number":42,"title":"Test PR","url":"https://github.com/test/repo/pull/42","state":"OPEN","mergeable":"MERGEABLE","baseRefName":"main","headRefName":"feature/test","body":"Adds login.\r\n\r\nFixes #7"}`, nil
 				}
 				return "", nil
 			},
@@ -57,6 +58,9 @@ func TestGetExistingPR(t *testing.T) {
 		if result.Mergeable != "MERGEABLE" {
 			t.Errorf("expected mergeable 'MERGEABLE', got %s", result.Mergeable)
 		}
+		if result.Body != "Adds login.\r\n\r\nFixes #7" {
+			t.Errorf("expected PR body, got %q", result.Body)
+		}
 	})
 
 	t.Run("returns nil when no PR exists", func(t *testing.T) {
@@ -132,7 +136,7 @@ func TestCategorizeChecks(t *testing.T) {
 		{Name: "neutral", Status: "COMPLETED", Conclusion: "NEUTRAL"},
 	}
 
-	failing, passing, pending := categorizeChecks(checks)
+	failing, passing, pending, skipped := categorizeChecks(checks)
 
 	if len(failing) != 1 {
 		t.Errorf("expected 1 failing check, got %d", len(failing))
@@ -141,8 +145,12 @@ func TestCategorizeChecks(t *testing.T) {
 		t.Errorf("expected failing check name 'failing', got %s", failing[0].Name)
 	}
 
-	if len(passing) != 3 {
-		t.Errorf("expected 3 passing checks, got %d", len(passing))
+	if len(passing) != 1 {
+		t.Errorf("expected 1 passing check, got %d", len(passing))
+	}
+
+	if len(skipped) != 2 {
+		t.Errorf("expected skipped and neutral checks to be skipped, got %+v", skipped)
 	}
 
 	if len(pending) != 1 {
@@ -153,6 +161,81 @@ func TestCategorizeChecks(t *testing.T) {
 	}
 }
 
+func TestGetRequiredChecks(t *testing.T) {
+	t.Run("reads contexts and checks", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if args[1] != "repos/{owner}/{repo}/branches/develop/protection" {
+					t.Errorf("unexpected endpoint %q", args[1])
+				}
+				return `{"required_status_checks":{"contexts":["build"],"checks":[{"context":"build"},{"context":"lint"}]}}`, nil
+			},
+		}
+
+		required := getRequiredChecks("/test", "develop", mock)
+		skipped := []CheckInfo{{Name: "lint"}, {Name: "docs"}}
+		if names := skippedRequired(skipped, required); len(names) != 1 || names[0] != "lint" {
+			t.Errorf("expected only lint to be required, got %v", names)
+		}
+	})
+
+	t.Run("unprotected branch", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("HTTP 404: Branch not protected")
+			},
+		}
+
+		if required := getRequiredChecks("/test", "main", mock); required != nil {
+			t.Errorf("expected nil, got %v", required)
+		}
+	})
+}
+
+func TestSharedBranchWarning(t *testing.T) {
+	pr := &PRInfo{Number: 42, BaseRef: "main", HeadRef: "feature/auth"}
+
+	t.Run("multiple authors", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "gh" && args[1] == "view" && args[2] == "42" && args[4] == "commits" {
+					return `{"commits":[{"authors":[{"email":"alice@example.com"}]},{"authors":[{"email":"bob@example.com"},{"email":"Alice@example.com"}]}]}`, nil
+				}
+				return "", nil
+			},
+		}
+
+		authors := getBranchAuthors("/test", pr, mock)
+		if len(authors) != 2 {
+			t.Fatalf("expected 2 distinct authors, got %v", authors)
+		}
+
+		warning := sharedBranchWarning(authors, nil, nil)
+		if !strings.Contains(warning, "Shared branch") || !strings.Contains(warning, "2 people") {
+			t.Errorf("expected shared branch warning, got %q", warning)
+		}
+		for _, verbose := range []bool{false, true} {
+			result := getProtocol(pr, []string{"**Merge conflicts**"}, warning, verbose)
+			if !strings.Contains(result, "coordinate before force-pushing") || !strings.Contains(result, "--force-with-lease") {
+				t.Errorf("verbose=%v: expected warning alongside --force-with-lease, got:\n%s", verbose, result)
+			}
+		}
+	})
+
+	t.Run("review in progress", func(t *testing.T) {
+		warning := sharedBranchWarning([]string{"alice@example.com"}, []ReviewInfo{{Author: "bob", State: "CHANGES_REQUESTED"}}, nil)
+		if !strings.Contains(warning, "reviews are in progress") {
+			t.Errorf("expected review warning, got %q", warning)
+		}
+	})
+
+	t.Run("single author", func(t *testing.T) {
+		if warning := sharedBranchWarning([]string{"alice@example.com"}, []ReviewInfo{{State: "APPROVED"}}, nil); warning != "" {
+			t.Errorf("expected no warning, got %q", warning)
+		}
+	})
+}
+
 func TestGetMergeableStatus(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -196,7 +279,7 @@ func TestGetReviewEmoji(t *testing.T) {
 func TestDetermineIssues(t *testing.T) {
 	t.Run("detects merge conflicts", func(t *testing.T) {
 		pr := &PRInfo{Mergeable: "CONFLICTING"}
-		issues := determineIssues(pr, nil, nil, nil, nil)
+		issues := determineIssues(pr, nil, nil, nil, nil, nil)
 
 		found := false
 		for _, issue := range issues {
@@ -213,7 +296,7 @@ func TestDetermineIssues(t *testing.T) {
 	t.Run("detects CI failures", func(t *testing.T) {
 		pr := &PRInfo{Mergeable: "MERGEABLE"}
 		failingChecks := []CheckInfo{{Name: "test"}, {Name: "lint"}}
-		issues := determineIssues(pr, failingChecks, nil, nil, nil)
+		issues := determineIssues(pr, failingChecks, nil, nil, nil, nil)
 
 		found := false
 		for _, issue := range issues {
@@ -233,7 +316,7 @@ func TestDetermineIssues(t *testing.T) {
 	t.Run("detects changes requested", func(t *testing.T) {
 		pr := &PRInfo{Mergeable: "MERGEABLE"}
 		reviews := []ReviewInfo{{Author: "reviewer", State: "CHANGES_REQUESTED"}}
-		issues := determineIssues(pr, nil, nil, reviews, nil)
+		issues := determineIssues(pr, nil, nil, nil, reviews, nil)
 
 		found := false
 		for _, issue := range issues {
@@ -250,7 +333,7 @@ func TestDetermineIssues(t *testing.T) {
 	t.Run("detects review comments", func(t *testing.T) {
 		pr := &PRInfo{Mergeable: "MERGEABLE"}
 		comments := []ReviewComment{{Body: "fix this"}, {Body: "and this"}}
-		issues := determineIssues(pr, nil, nil, nil, comments)
+		issues := determineIssues(pr, nil, nil, nil, nil, comments)
 
 		found := false
 		for _, issue := range issues {
@@ -264,10 +347,19 @@ func TestDetermineIssues(t *testing.T) {
 		}
 	})
 
+	t.Run("detects skipped required checks", func(t *testing.T) {
+		pr := &PRInfo{Mergeable: "MERGEABLE"}
+		issues := determineIssues(pr, nil, nil, []string{"build"}, nil, nil)
+
+		if len(issues) != 1 || !strings.Contains(issues[0], "Required check build was skipped") {
+			t.Errorf("expected skipped required check issue, got %v", issues)
+		}
+	})
+
 	t.Run("returns empty when all good", func(t *testing.T) {
 		pr := &PRInfo{Mergeable: "MERGEABLE"}
 		reviews := []ReviewInfo{{Author: "reviewer", State: "APPROVED"}}
-		issues := determineIssues(pr, nil, nil, reviews, nil)
+		issues := determineIssues(pr, nil, nil, nil, reviews, nil)
 
 		if len(issues) != 0 {
 			t.Errorf("expected no issues, got %v", issues)
@@ -277,7 +369,7 @@ func TestDetermineIssues(t *testing.T) {
 	t.Run("mentions pending checks when no other issues", func(t *testing.T) {
 		pr := &PRInfo{Mergeable: "MERGEABLE"}
 		pendingChecks := []CheckInfo{{Name: "build"}}
-		issues := determineIssues(pr, nil, pendingChecks, nil, nil)
+		issues := determineIssues(pr, nil, pendingChecks, nil, nil, nil)
 
 		found := false
 		for _, issue := range issues {
@@ -292,11 +384,60 @@ func TestDetermineIssues(t *testing.T) {
 	})
 }
 
+func TestNotifySummary(t *testing.T) {
+	pr := &PRInfo{Number: 42}
+	checks := []CheckInfo{{Name: "test"}, {Name: "lint"}}
+
+	testCases := []struct {
+		name     string
+		failing  []CheckInfo
+		pending  []CheckInfo
+		issues   []string
+		expected string
+	}{
+		{"failed", checks, checks[:1], []string{"x"}, "PR #42: 2 check(s) failed"},
+		{"pending", nil, checks[:1], nil, "PR #42: 1 check(s) still running"},
+		{"ready", nil, nil, nil, "PR #42: checks passed, ready to merge"},
+		{"review issues", nil, nil, []string{"x"}, "PR #42: checks passed, 1 issue(s) to address"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := notifySummary(pr, tc.failing, tc.pending, tc.issues); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatDescription(t *testing.T) {
+	t.Run("collapsible block with normalized newlines", func(t *testing.T) {
+		result := formatDescription("Adds login.\r\n\r\nFixes #7\r\n")
+		expected := "<details>\n<summary>Author's description</summary>\n\nAdds login.\n\nFixes #7\n\n</details>\n\n"
+		if result != expected {
+			t.Errorf("unexpected description:\n%q", result)
+		}
+	})
+
+	t.Run("long body truncated", func(t *testing.T) {
+		body := strings.Repeat("line\n", maxDescriptionLines+10)
+		if result := formatDescription(body); !strings.Contains(result, "... (10 more lines)") {
+			t.Errorf("expected truncation note, got:\n%s", result)
+		}
+	})
+
+	t.Run("empty body omitted", func(t *testing.T) {
+		if result := formatDescription(" \n "); result != "" {
+			t.Errorf("expected empty output, got %q", result)
+		}
+	})
+}
+
 func TestGetProtocol(t *testing.T) {
 	pr := &PRInfo{Number: 42, HeadRef: "feature/test", BaseRef: "main"}
 
 	t.Run("no issues protocol", func(t *testing.T) {
-		result := getProtocol(pr, nil, false)
+		result := getProtocol(pr, nil, "", false)
 
 		if !strings.Contains(result, "ready to merge") {
 			t.Error("expected ready to merge message")
@@ -307,7 +448,7 @@ func TestGetProtocol(t *testing.T) {
 	})
 
 	t.Run("no issues verbose protocol", func(t *testing.T) {
-		result := getProtocol(pr, nil, true)
+		result := getProtocol(pr, nil, "", true)
 
 		if !strings.Contains(result, "**Final review**") {
 			t.Error("expected bold headers in verbose mode")
@@ -322,7 +463,7 @@ func TestGetProtocol(t *testing.T) {
 
 	t.Run("with issues protocol", func(t *testing.T) {
 		issues := []string{"CI failures"}
-		result := getProtocol(pr, issues, false)
+		result := getProtocol(pr, issues, "", false)
 
 		if !strings.Contains(result, "gh pr checks 42") {
 			t.Error("expected checks command")
@@ -334,7 +475,7 @@ func TestGetProtocol(t *testing.T) {
 
 	t.Run("with issues verbose protocol", func(t *testing.T) {
 		issues := []string{"Merge conflicts"}
-		result := getProtocol(pr, issues, true)
+		result := getProtocol(pr, issues, "", true)
 
 		if !strings.Contains(result, "**Investigate failures**") {
 			t.Error("expected bold headers")
@@ -441,6 +582,37 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("only failing consults unresolved threads", func(t *testing.T) {
+		for _, onlyFailing := range []bool{false, true} {
+			queried := false
+			mock := &MockRunner{
+				RunFunc: func(dir string, command string, args ...string) (string, error) {
+					if command == "git" && len(args) >= 2 && args[0] == "rev-parse" && args[1] == "--abbrev-ref" {
+						return "feature/test", nil
+					}
+					return "", nil
+				},
+				RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+					if command == "gh" && len(args) >= 2 && args[0] == "pr" && args[1] == "view" {
+						return `{"number":42,"title":"Test PR","state":"OPEN","mergeable":"MERGEABLE","baseRefName":"main","headRefName":"feature/test"}`, nil
+					}
+					if command == "gh" && len(args) >= 2 && args[0] == "api" && args[1] == "graphql" {
+						queried = true
+						return threadsResponse, nil
+					}
+					return "", nil
+				},
+			}
+
+			if err := Run(Options{Dir: t.TempDir(), OnlyFailing: onlyFailing, Runner: mock}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if queried != onlyFailing {
+				t.Errorf("onlyFailing=%v: expected thread query %v, got %v", onlyFailing, onlyFailing, queried)
+			}
+		}
+	})
+
 	t.Run("with nil runner uses default", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -453,3 +625,69 @@ func TestRun(t *testing.T) {
 		_ = Run(opts)
 	})
 }
+
+func TestFormatChecks(t *testing.T) {
+	failing := []CheckInfo{{Name: "lint", Conclusion: "FAILURE"}}
+	passing := []CheckInfo{{Name: "unit-tests", Conclusion: "SUCCESS"}, {Name: "build", Conclusion: "SUCCESS"}}
+	pending := []CheckInfo{{Name: "e2e"}}
+	skipped := []CheckInfo{{Name: "deploy", Conclusion: "SKIPPED"}, {Name: "gate", Conclusion: "SKIPPED"}}
+	required := map[string]bool{"gate": true}
+
+	t.Run("full report counts every category", func(t *testing.T) {
+		result := formatChecks(failing, passing, pending, skipped, required, false)
+		for _, want := range []string{"Passing: 2", "Failing: 1", "Pending: 1", "Skipped: 2", "deploy (skipped)", "gate (skipped) - required"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %q in:\n%s", want, result)
+			}
+		}
+	})
+
+	t.Run("only failing omits passing checks", func(t *testing.T) {
+		result := formatChecks(failing, passing, pending, skipped, required, true)
+		for _, want := range []string{"2 passing, 1 skipped (not shown)", "❌ lint", "⏳ e2e", "gate (skipped) - required"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %q in:\n%s", want, result)
+			}
+		}
+		for _, omitted := range []string{"unit-tests", "build", "deploy", "Passing:"} {
+			if strings.Contains(result, omitted) {
+				t.Errorf("expected %q to be omitted from:\n%s", omitted, result)
+			}
+		}
+	})
+}
+
+func TestFormatReviews(t *testing.T) {
+	reviews := []ReviewInfo{
+		{Author: "alice", State: "APPROVED"},
+		{Author: "bob", State: "CHANGES_REQUESTED"},
+		{Author: "carol", State: "COMMENTED"},
+	}
+	comments := threadComments([]ReviewThread{{Path: "main.go", Line: 12, Author: "bob", Body: "Handle the error"}})
+
+	t.Run("full report lists every review", func(t *testing.T) {
+		result := formatReviews(reviews, comments, false)
+		for _, want := range []string{"**alice**: APPROVED", "**bob**: CHANGES_REQUESTED", "**carol**: COMMENTED", "**@bob** on `main.go:12`"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %q in:\n%s", want, result)
+			}
+		}
+	})
+
+	t.Run("only failing keeps changes requested", func(t *testing.T) {
+		result := formatReviews(reviews, comments, true)
+		if !strings.Contains(result, "**bob**: CHANGES_REQUESTED") || !strings.Contains(result, "2 other review(s) not shown") {
+			t.Errorf("expected only changes requested, got:\n%s", result)
+		}
+		if strings.Contains(result, "alice") || strings.Contains(result, "carol") {
+			t.Errorf("expected approvals and comments-only reviews omitted, got:\n%s", result)
+		}
+	})
+
+	t.Run("only failing with nothing to act on", func(t *testing.T) {
+		result := formatReviews(reviews[:1], nil, true)
+		if !strings.HasPrefix(result, "No changes requested or unresolved comments") {
+			t.Errorf("unexpected result: %q", result)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package prfix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/vibes-project/vibes/internal/emoji"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// ErrCancelled is returned when the user declines to resolve any threads
+var ErrCancelled = errors.New("cancelled")
+
+// ReviewThread is an unresolved review thread on a pull request
+type ReviewThread struct {
+	ID     string // GraphQL node ID, needed to resolve the thread
+	Path   string
+	Line   int
+	Author string // Author of the thread's first comment
+	Body   string // Body of the thread's first comment
+}
+
+// Label describes the thread in one line for selection lists.
+func (t ReviewThread) Label() string {
+	location := t.Path
+	if t.Line > 0 {
+		location += ":" + strconv.Itoa(t.Line)
+	}
+	body, _, _ := strings.Cut(strings.TrimSpace(t.Body), "\n")
+	return fmt.Sprintf("@%s on %s: %s", t.Author, location, ansi.Truncate(body, 60, "..."))
+}
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100) {
+        nodes {
+          id
+          isResolved
+          path
+          line
+          comments(first: 1) {
+            nodes { author { login } body }
+          }
+        }
+      }
+    }
+  }
+}`
+
+const resolveThreadMutation = `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) { thread { isResolved } }
+}`
+
+// getReviewThreads lists the PR's unresolved review threads.
+func getReviewThreads(dir string, prNumber int, r runner.CommandRunner) ([]ReviewThread, error) {
+	output, err := r.RunWithTimeout(dir, 15*time.Second, "gh", "api", "graphql",
+		"-F", "owner={owner}", "-F", "name={repo}", "-F", fmt.Sprintf("number=%d", prNumber),
+		"-f", "query="+reviewThreadsQuery)
+	if err != nil {
+		if output := runner.ErrorOutput(err); output != "" {
+			return nil, fmt.Errorf("listing review threads: %w\n%s", err, output)
+		}
+		return nil, fmt.Errorf("listing review threads: %w", err)
+	}
+	return parseReviewThreads(output)
+}
+
+// parseReviewThreads extracts unresolved threads and their node IDs from
+// the reviewThreads GraphQL response.
+func parseReviewThreads(output string) ([]ReviewThread, error) {
+	var resp struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						Nodes []struct {
+							ID         string `json:"id"`
+							IsResolved bool   `json:"isResolved"`
+							Path       string `json:"path"`
+							Line       int    `json:"line"`
+							Comments   struct {
+								Nodes []struct {
+									Author ReviewAuthor `json:"author"`
+									Body   string       `json:"body"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(output), &resp); err != nil {
+		return nil, fmt.Errorf("parsing review threads: %w", err)
+	}
+
+	var threads []ReviewThread
+	for _, node := range resp.Data.Repository.PullRequest.ReviewThreads.Nodes {
+		if node.IsResolved || node.ID == "" {
+			continue
+		}
+		thread := ReviewThread{ID: node.ID, Path: node.Path, Line: node.Line}
+		if len(node.Comments.Nodes) > 0 {
+			thread.Author = node.Comments.Nodes[0].Author.Login
+			thread.Body = node.Comments.Nodes[0].Body
+		}
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
+
+// threadComments converts unresolved threads to their first comments.
+func threadComments(threads []ReviewThread) []ReviewComment {
+	comments := make([]ReviewComment, len(threads))
+	for i, thread := range threads {
+		comments[i] = ReviewComment{Author: ReviewAuthor{Login: thread.Author}, Body: thread.Body, Path: thread.Path, Line: thread.Line}
+	}
+	return comments
+}
+
+// resolveThread marks a review thread resolved.
+func resolveThread(dir string, id string, r runner.CommandRunner) error {
+	_, err := r.RunWithTimeout(dir, 15*time.Second, "gh", "api", "graphql",
+		"-f", "id="+id, "-f", "query="+resolveThreadMutation)
+	if err != nil {
+		if output := runner.ErrorOutput(err); output != "" {
+			return fmt.Errorf("resolving thread %s: %w\n%s", id, err, output)
+		}
+		return fmt.Errorf("resolving thread %s: %w", id, err)
+	}
+	return nil
+}
+
+// chooseThreads asks which threads were addressed and confirms before
+// anything is resolved. Replaced in tests.
+var chooseThreads = askThreads
+
+// resolveComments resolves the review threads the user picks. Nothing is
+// resolved without an explicit selection and confirmation.
+func resolveComments(dir string, pr *PRInfo, r runner.CommandRunner) error {
+	threads, err := getReviewThreads(dir, pr.Number, r)
+	if err != nil {
+		return err
+	}
+	if len(threads) == 0 {
+		fmt.Fprintf(os.Stderr, "No unresolved review threads on PR #%d\n", pr.Number)
+		return nil
+	}
+
+	selected, err := chooseThreads(threads)
+	if err != nil {
+		return err
+	}
+
+	resolved := 0
+	for _, thread := range selected {
+		if err := resolveThread(dir, thread.ID, r); err != nil {
+			fmt.Fprint(os.Stderr, emoji.Render(fmt.Sprintf("⚠️ %v\n", err)))
+			continue
+		}
+		resolved++
+	}
+	fmt.Fprintf(os.Stderr, "Resolved %d of %d review thread(s) on PR #%d\n", resolved, len(selected), pr.Number)
+	if resolved < len(selected) {
+		return fmt.Errorf("%d thread(s) could not be resolved", len(selected)-resolved)
+	}
+	return nil
+}
+
+// askThreads shows a multi-select of threads, none selected by default,
+// followed by a confirmation. Declining either step returns ErrCancelled.
+func askThreads(threads []ReviewThread) ([]ReviewThread, error) {
+	options := make([]huh.Option[int], len(threads))
+	for i, thread := range threads {
+		options[i] = huh.NewOption(thread.Label(), i)
+	}
+
+	var picked []int
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Which review threads have been addressed?").
+				Options(options...).
+				Value(&picked),
+		),
+	).WithOutput(os.Stderr)
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+	if len(picked) == 0 {
+		return nil, ErrCancelled
+	}
+
+	var ok bool
+	form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Resolve %d review thread(s)?", len(picked))).
+				Value(&ok),
+		),
+	).WithOutput(os.Stderr)
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCancelled
+	}
+
+	selected := make([]ReviewThread, len(picked))
+	for i, idx := range picked {
+		selected[i] = threads[idx]
+	}
+	return selected, nil
+}
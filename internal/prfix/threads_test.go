@@ -0,0 +1,140 @@
+package prfix
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const threadsResponse = `{"data":{"repository":{"pullRequest":{"reviewThreads":{"nodes":[
+  {"id":"PRRT_1","isResolved":false,"path":"main.go","line":12,"comments":{"nodes":[{"author":{"login":"alice"},"body":"Handle the error\nIt is dropped."}]}},
+  {"id":"PRRT_2","isResolved":true,"path":"main.go","line":40,"comments":{"nodes":[{"author":{"login":"bob"},"body":"Done already"}]}},
+  {"id":"PRRT_3","isResolved":false,"path":"README.md","line":0,"comments":{"nodes":[]}}
+]}}}}}`
+
+func TestParseReviewThreads(t *testing.T) {
+	t.Run("keeps unresolved threads with node IDs", func(t *testing.T) {
+		threads, err := parseReviewThreads(threadsResponse)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(threads) != 2 {
+			t.Fatalf("expected 2 unresolved threads, got %+v", threads)
+		}
+		if threads[0].ID != "PRRT_1" || threads[0].Author != "alice" || threads[0].Line != 12 {
+			t.Errorf("unexpected first thread: %+v", threads[0])
+		}
+		if threads[1].ID != "PRRT_3" || threads[1].Author != "" {
+			t.Errorf("unexpected second thread: %+v", threads[1])
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		if _, err := parseReviewThreads("not json"); err == nil {
+			t.Error("expected parse error")
+		}
+	})
+}
+
+func TestReviewThreadLabel(t *testing.T) {
+	thread := ReviewThread{Path: "main.go", Line: 12, Author: "alice", Body: "Handle the error\nIt is dropped."}
+	if got := thread.Label(); got != "@alice on main.go:12: Handle the error" {
+		t.Errorf("unexpected label: %q", got)
+	}
+
+	thread = ReviewThread{Path: "README.md", Author: "bob", Body: strings.Repeat("x", 80)}
+	if got := thread.Label(); !strings.HasPrefix(got, "@bob on README.md: ") || !strings.HasSuffix(got, "...") {
+		t.Errorf("expected truncated label without line, got %q", got)
+	}
+
+	// Truncation must not split a multi-byte character
+	thread = ReviewThread{Path: "main.go", Author: "carol", Body: strings.Repeat("é", 80)}
+	got := thread.Label()
+	if !utf8.ValidString(got) || !strings.HasSuffix(got, "...") {
+		t.Errorf("expected valid UTF-8 truncated label, got %q", got)
+	}
+	if body := strings.TrimPrefix(got, "@carol on main.go: "); ansi.StringWidth(body) > 60 {
+		t.Errorf("expected body at most 60 cells, got %d: %q", ansi.StringWidth(body), body)
+	}
+}
+
+func TestResolveComments(t *testing.T) {
+	pr := &PRInfo{Number: 42}
+
+	stubChoice := func(t *testing.T, choose func([]ReviewThread) ([]ReviewThread, error)) {
+		t.Helper()
+		orig := chooseThreads
+		chooseThreads = choose
+		t.Cleanup(func() { chooseThreads = orig })
+	}
+
+	newMock := func(resolved *[]string, failID string) *MockRunner {
+		return &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				for _, arg := range args {
+					if strings.HasPrefix(arg, "id=") {
+						if arg == "id="+failID {
+							return "", errors.New("exit status 1")
+						}
+						*resolved = append(*resolved, strings.TrimPrefix(arg, "id="))
+						return `{"data":{}}`, nil
+					}
+				}
+				return threadsResponse, nil
+			},
+		}
+	}
+
+	t.Run("resolves only the selected threads", func(t *testing.T) {
+		var offered []ReviewThread
+		stubChoice(t, func(threads []ReviewThread) ([]ReviewThread, error) {
+			offered = threads
+			return threads[1:], nil
+		})
+
+		var resolved []string
+		if err := resolveComments("/repo", pr, newMock(&resolved, "")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(offered) != 2 {
+			t.Errorf("expected only unresolved threads offered, got %+v", offered)
+		}
+		if strings.Join(resolved, ",") != "PRRT_3" {
+			t.Errorf("expected only PRRT_3 resolved, got %v", resolved)
+		}
+	})
+
+	t.Run("cancelling resolves nothing", func(t *testing.T) {
+		stubChoice(t, func(threads []ReviewThread) ([]ReviewThread, error) {
+			return nil, ErrCancelled
+		})
+
+		var resolved []string
+		err := resolveComments("/repo", pr, newMock(&resolved, ""))
+		if !errors.Is(err, ErrCancelled) {
+			t.Errorf("expected ErrCancelled, got %v", err)
+		}
+		if len(resolved) != 0 {
+			t.Errorf("expected nothing resolved, got %v", resolved)
+		}
+	})
+
+	t.Run("reports threads that fail to resolve", func(t *testing.T) {
+		stubChoice(t, func(threads []ReviewThread) ([]ReviewThread, error) {
+			return threads, nil
+		})
+
+		var resolved []string
+		err := resolveComments("/repo", pr, newMock(&resolved, "PRRT_1"))
+		if err == nil || !strings.Contains(err.Error(), "1 thread(s) could not be resolved") {
+			t.Errorf("expected partial failure, got %v", err)
+		}
+		if strings.Join(resolved, ",") != "PRRT_3" {
+			t.Errorf("expected remaining thread resolved, got %v", resolved)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// ResultFile is the repo-relative location of the last test result.
+const ResultFile = ".vibes/last-test.json"
+
+// CachedResult is a test result saved for later commands such as resume.
+type CachedResult struct {
+	VerifyResult
+	Time   time.Time `json:"time"`
+	Commit string    `json:"commit,omitempty"` // HEAD when the tests ran
+	Source string    `json:"source,omitempty"` // Command that ran the tests, e.g. "vibes done --verify"
+}
+
+// ResultPath returns the cached result file for the repository containing
+// dir, falling back to dir itself outside a git repository.
+func ResultPath(dir string, r runner.CommandRunner) string {
+	root := git.GetRepoRoot(dir, r)
+	if root == "" {
+		root = dir
+	}
+	return filepath.Join(root, ResultFile)
+}
+
+// SaveResult records a test result along with the current time, HEAD, and
+// the command that produced it.
+func SaveResult(dir string, result VerifyResult, source string, r runner.CommandRunner) error {
+	cached := CachedResult{VerifyResult: result, Time: time.Now(), Commit: headCommit(dir, r), Source: source}
+	data, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding test result: %w", err)
+	}
+
+	path := ResultPath(dir, r)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadResult reads the last saved test result. The error satisfies
+// errors.Is(err, os.ErrNotExist) when no result has been saved.
+func LoadResult(dir string, r runner.CommandRunner) (CachedResult, error) {
+	path := ResultPath(dir, r)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CachedResult{}, err
+	}
+	var cached CachedResult
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return CachedResult{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cached, nil
+}
+
+// StaleReason explains why the result may not reflect the current code:
+// HEAD has moved since it was recorded, or tracked files have uncommitted
+// changes. Empty when the result is current. Results without a recorded
+// commit are assumed current.
+func (c CachedResult) StaleReason(dir string, r runner.CommandRunner) string {
+	if c.Commit == "" {
+		return ""
+	}
+	if headCommit(dir, r) != c.Commit {
+		return "HEAD has moved since"
+	}
+	if counts := git.GetStatusCounts(dir, r); counts.Staged+counts.Modified > 0 {
+		return "the working tree has uncommitted changes"
+	}
+	return ""
+}
+
+// ShortCommit returns the recorded commit abbreviated for display.
+func (c CachedResult) ShortCommit() string {
+	if len(c.Commit) > 7 {
+		return c.Commit[:7]
+	}
+	return c.Commit
+}
+
+// headCommit returns the full HEAD commit hash, or empty string outside git.
+func headCommit(dir string, r runner.CommandRunner) string {
+	output, err := r.Run(dir, "git", "rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
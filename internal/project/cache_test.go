@@ -0,0 +1,77 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestResultCache(t *testing.T) {
+	// headMock reports head as the current commit; the repo root lookup fails
+	headMock := func(head *string) *MockRunner {
+		return &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if args[0] == "rev-parse" && args[1] == "HEAD" {
+					return *head + "\n", nil
+				}
+				return "", errors.New("not a git repository")
+			},
+		}
+	}
+
+	t.Run("nothing cached", func(t *testing.T) {
+		head := "abc123def4567"
+		if _, err := LoadResult(t.TempDir(), headMock(&head)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected ErrNotExist, got %v", err)
+		}
+	})
+
+	t.Run("round trip records HEAD", func(t *testing.T) {
+		dir := t.TempDir()
+		head := "abc123def4567"
+		mock := headMock(&head)
+
+		result := VerifyResult{Command: "go test ./...", Output: "FAIL: TestLogin"}
+		if err := SaveResult(dir, result, "vibes done --verify", mock); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		cached, err := LoadResult(dir, mock)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cached.VerifyResult != result || cached.Commit != "abc123def4567" || cached.Source != "vibes done --verify" || cached.Time.IsZero() {
+			t.Errorf("unexpected cached result: %+v", cached)
+		}
+		if reason := cached.StaleReason(dir, mock); reason != "" {
+			t.Errorf("expected result to be current at the same HEAD, got %q", reason)
+		}
+		if short := cached.ShortCommit(); short != "abc123d" {
+			t.Errorf("expected abbreviated commit, got %q", short)
+		}
+
+		head = "def456abc7890"
+		if reason := cached.StaleReason(dir, mock); reason != "HEAD has moved since" {
+			t.Errorf("expected result to be out of date after HEAD moved, got %q", reason)
+		}
+	})
+
+	t.Run("uncommitted changes make the result stale", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				switch args[0] {
+				case "rev-parse":
+					return "abc123def4567", nil
+				case "status":
+					return " M main.go\n?? notes.txt", nil
+				}
+				return "", nil
+			},
+		}
+
+		cached := CachedResult{Commit: "abc123def4567"}
+		if reason := cached.StaleReason("/repo", mock); reason != "the working tree has uncommitted changes" {
+			t.Errorf("expected dirty tree to be stale, got %q", reason)
+		}
+	})
+}
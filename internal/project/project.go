@@ -0,0 +1,261 @@
+// Package project detects project ecosystems and the commands used to test,
+// build, and check them.
+package project
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// VerifyTimeout bounds how long a test command may run during verification.
+const VerifyTimeout = 5 * time.Minute
+
+// VerifyResult holds the outcome of running a project's test command.
+type VerifyResult struct {
+	Command string `json:"command"`
+	Passed  bool   `json:"passed"`
+	Output  string `json:"output,omitempty"`
+}
+
+// Ecosystem identifies a language or build tool detected in a project.
+type Ecosystem string
+
+// Supported ecosystems, listed in detection precedence order.
+const (
+	Go     Ecosystem = "go"
+	Node   Ecosystem = "node"
+	Python Ecosystem = "python"
+	Rust   Ecosystem = "rust"
+	Make   Ecosystem = "make"
+)
+
+// Cmd is a command that surfaces build or lint errors when it fails.
+type Cmd struct {
+	Label   string        // Heading for the error output, e.g. "Go build errors"
+	Rule    string        // Stable identifier for the check, e.g. "go-build"
+	Name    string        // Executable to run
+	Args    []string      // Arguments to the executable
+	Timeout time.Duration // Maximum run time
+	// ChangedFiles, when set, is a pathspec (e.g. "*.py"). The command is run
+	// once per modified file matching it, with the file appended to Args.
+	ChangedFiles string
+}
+
+// ecosystemSpec describes how to detect an ecosystem and the commands it uses.
+type ecosystemSpec struct {
+	ecosystem Ecosystem
+	markers   []string // Any of these files present at the project root
+	fallback  bool     // Test command only used when no other ecosystem is detected
+	test      func(dir string) string
+	build     func(dir string) string
+	errors    func(dir string) []Cmd
+}
+
+// ecosystems is the single list of supported ecosystems. Add new languages here.
+var ecosystems = []ecosystemSpec{
+	{
+		ecosystem: Go,
+		markers:   []string{"go.mod"},
+		test:      func(dir string) string { return "go test ./... && go build ./..." },
+		build:     func(dir string) string { return "go build ./..." },
+		errors: func(dir string) []Cmd {
+			return []Cmd{
+				{Label: "Go build errors", Rule: "go-build", Name: "go", Args: []string{"build", "./..."}, Timeout: 30 * time.Second},
+				{Label: "Go vet issues", Rule: "go-vet", Name: "go", Args: []string{"vet", "./..."}, Timeout: 30 * time.Second},
+			}
+		},
+	},
+	{
+		ecosystem: Node,
+		markers:   []string{"package.json"},
+		test: func(dir string) string {
+			return nodePackageManager(dir) + " test"
+		},
+		build: func(dir string) string {
+			return nodePackageManager(dir) + " run build"
+		},
+		errors: func(dir string) []Cmd {
+			// Check for TypeScript errors
+			if !fileExists(filepath.Join(dir, "tsconfig.json")) {
+				return nil
+			}
+			return []Cmd{
+				{Label: "TypeScript errors", Rule: "tsc", Name: "npx", Args: []string{"tsc", "--noEmit"}, Timeout: 30 * time.Second},
+			}
+		},
+	},
+	{
+		ecosystem: Python,
+		markers:   []string{"pyproject.toml", "setup.py"},
+		test:      func(dir string) string { return "pytest" },
+		build:     func(dir string) string { return "" },
+		errors: func(dir string) []Cmd {
+			return []Cmd{
+				{Label: "Python syntax error", Rule: "py-compile", Name: "python", Args: []string{"-m", "py_compile"}, Timeout: 10 * time.Second, ChangedFiles: "*.py"},
+			}
+		},
+	},
+	{
+		ecosystem: Rust,
+		markers:   []string{"Cargo.toml"},
+		test:      func(dir string) string { return "cargo test && cargo build" },
+		build:     func(dir string) string { return "cargo build" },
+		errors:    func(dir string) []Cmd { return nil },
+	},
+	{
+		ecosystem: Make,
+		markers:   []string{"Makefile"},
+		fallback:  true, // Makefiles usually wrap the language tooling
+		test:      func(dir string) string { return "make test" },
+		build:     func(dir string) string { return "make" },
+		errors:    func(dir string) []Cmd { return nil },
+	},
+}
+
+// Detect returns every ecosystem found in dir, in precedence order.
+func Detect(dir string) []Ecosystem {
+	var found []Ecosystem
+	for _, spec := range detectSpecs(dir) {
+		found = append(found, spec.ecosystem)
+	}
+	return found
+}
+
+// ParseEcosystem validates an ecosystem name such as "go" or "node".
+func ParseEcosystem(name string) (Ecosystem, error) {
+	var names []string
+	for _, spec := range ecosystems {
+		if string(spec.ecosystem) == strings.ToLower(name) {
+			return spec.ecosystem, nil
+		}
+		names = append(names, string(spec.ecosystem))
+	}
+	return "", fmt.Errorf("unknown ecosystem %q (expected one of: %s)", name, strings.Join(names, ", "))
+}
+
+// TestCommand auto-detects the appropriate test/build commands for the project.
+// In polyglot repos the commands of every detected ecosystem are chained with &&.
+// Returns empty string if no test runner is recognized.
+func TestCommand(dir string) string {
+	var cmds, fallbacks []string
+	for _, spec := range detectSpecs(dir) {
+		testCmd := spec.test(dir)
+		switch {
+		case testCmd == "":
+		case spec.fallback:
+			fallbacks = append(fallbacks, testCmd)
+		default:
+			cmds = append(cmds, testCmd)
+		}
+	}
+	if len(cmds) == 0 {
+		cmds = fallbacks
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// TestCommandFor returns the test command for a single ecosystem, if detected in dir.
+func TestCommandFor(dir string, ecosystem Ecosystem) string {
+	for _, spec := range detectSpecs(dir) {
+		if spec.ecosystem == ecosystem {
+			return spec.test(dir)
+		}
+	}
+	return ""
+}
+
+// ResolveTestCommand picks the test command to run: an explicit override wins,
+// then the primary ecosystem's command, then the composed auto-detected command.
+func ResolveTestCommand(dir string, override string, primary Ecosystem) string {
+	if override != "" {
+		slog.Info("test command", "cmd", override, "via", "override")
+		return override
+	}
+
+	detected := Detect(dir)
+	if primary != "" {
+		cmd := TestCommandFor(dir, primary)
+		slog.Info("test command", "cmd", cmd, "via", "primary ecosystem", "primary", primary, "detected", detected)
+		return cmd
+	}
+
+	cmd := TestCommand(dir)
+	slog.Info("test command", "cmd", cmd, "via", "auto-detection", "detected", detected)
+	return cmd
+}
+
+// BuildCommand auto-detects the build command for the project.
+// Returns empty string if no build step is recognized.
+func BuildCommand(dir string) string {
+	for _, spec := range detectSpecs(dir) {
+		if buildCmd := spec.build(dir); buildCmd != "" {
+			return buildCmd
+		}
+	}
+	return ""
+}
+
+// ErrorDetectionCommands returns the commands that surface build/lint errors
+// for every ecosystem detected in dir.
+func ErrorDetectionCommands(dir string) []Cmd {
+	var cmds []Cmd
+	for _, spec := range detectSpecs(dir) {
+		cmds = append(cmds, spec.errors(dir)...)
+	}
+	slog.Info("error detection", "detected", Detect(dir), "checks", len(cmds))
+	return cmds
+}
+
+func detectSpecs(dir string) []ecosystemSpec {
+	var found []ecosystemSpec
+	for _, spec := range ecosystems {
+		for _, marker := range spec.markers {
+			if fileExists(filepath.Join(dir, marker)) {
+				found = append(found, spec)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// nodePackageManager picks the package manager from the lockfile present.
+func nodePackageManager(dir string) string {
+	if fileExists(filepath.Join(dir, "yarn.lock")) {
+		return "yarn"
+	}
+	if fileExists(filepath.Join(dir, "pnpm-lock.yaml")) {
+		return "pnpm"
+	}
+	return "npm"
+}
+
+// Verify runs the test command through the shell and reports whether it passed.
+// Output is only captured for failures.
+func Verify(dir string, command string, r runner.CommandRunner) VerifyResult {
+	result := VerifyResult{Command: command}
+
+	_, err := r.RunWithTimeout(dir, VerifyTimeout, "sh", "-c", command)
+	if err != nil {
+		result.Output = runner.ErrorOutput(err)
+		if result.Output == "" {
+			result.Output = err.Error()
+		}
+		return result
+	}
+
+	result.Passed = true
+	return result
+}
+
+// fileExists checks if a file exists at the given path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
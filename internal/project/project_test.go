@@ -0,0 +1,334 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// MockRunner is a mock implementation of runner.CommandRunner for testing
+type MockRunner struct {
+	RunFunc            func(dir string, command string, args ...string) (string, error)
+	RunWithTimeoutFunc func(dir string, timeout time.Duration, command string, args ...string) (string, error)
+}
+
+func (m *MockRunner) Run(dir string, command string, args ...string) (string, error) {
+	if m.RunFunc != nil {
+		return m.RunFunc(dir, command, args...)
+	}
+	return "", nil
+}
+
+func (m *MockRunner) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+	if m.RunWithTimeoutFunc != nil {
+		return m.RunWithTimeoutFunc(dir, timeout, command, args...)
+	}
+	return "", nil
+}
+
+func TestTestCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(dir string)
+		expected string
+	}{
+		{
+			name: "Go project",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+			},
+			expected: "go test ./... && go build ./...",
+		},
+		{
+			name: "Node project with npm",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
+			},
+			expected: "npm test",
+		},
+		{
+			name: "Node project with yarn",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
+				os.WriteFile(filepath.Join(dir, "yarn.lock"), []byte(""), 0644)
+			},
+			expected: "yarn test",
+		},
+		{
+			name: "Node project with pnpm",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
+				os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), []byte(""), 0644)
+			},
+			expected: "pnpm test",
+		},
+		{
+			name: "Python project with pyproject.toml",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(""), 0644)
+			},
+			expected: "pytest",
+		},
+		{
+			name: "Python project with setup.py",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "setup.py"), []byte(""), 0644)
+			},
+			expected: "pytest",
+		},
+		{
+			name: "Rust project",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(""), 0644)
+			},
+			expected: "cargo test && cargo build",
+		},
+		{
+			name: "Makefile project",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "Makefile"), []byte(""), 0644)
+			},
+			expected: "make test",
+		},
+		{
+			name: "No recognized project type",
+			setup: func(dir string) {
+				// Empty directory
+			},
+			expected: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			tc.setup(tmpDir)
+
+			result := TestCommand(tmpDir)
+
+			if result != tc.expected {
+				t.Errorf("expected %q, got: %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("passing command", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command != "sh" || len(args) != 2 || args[1] != "go test ./..." {
+					t.Errorf("unexpected command: %s %v", command, args)
+				}
+				return "ok", nil
+			},
+		}
+
+		result := Verify("/test/dir", "go test ./...", mock)
+
+		if !result.Passed {
+			t.Error("expected verification to pass")
+		}
+		if result.Command != "go test ./..." {
+			t.Errorf("expected command to be recorded, got %q", result.Command)
+		}
+	})
+
+	t.Run("failing command captures output", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", &runner.CommandError{Err: errors.New("exit status 1"), Output: "--- FAIL: TestThing"}
+			},
+		}
+
+		result := Verify("/test/dir", "go test ./...", mock)
+
+		if result.Passed {
+			t.Error("expected verification to fail")
+		}
+		if !strings.Contains(result.Output, "FAIL: TestThing") {
+			t.Errorf("expected captured output, got %q", result.Output)
+		}
+	})
+
+	t.Run("failure without output falls back to error", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("executable file not found")
+			},
+		}
+
+		result := Verify("/test/dir", "go test ./...", mock)
+
+		if result.Output != "executable file not found" {
+			t.Errorf("expected error message as output, got %q", result.Output)
+		}
+	})
+}
+
+func TestFileExists(t *testing.T) {
+	t.Run("existing file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		filePath := filepath.Join(tmpDir, "test.txt")
+		os.WriteFile(filePath, []byte("test"), 0644)
+
+		if !fileExists(filePath) {
+			t.Error("expected fileExists to return true for existing file")
+		}
+	})
+
+	t.Run("non-existing file", func(t *testing.T) {
+		if fileExists("/nonexistent/path/to/file.txt") {
+			t.Error("expected fileExists to return false for non-existing file")
+		}
+	})
+}
+
+func TestDetect(t *testing.T) {
+	t.Run("empty directory", func(t *testing.T) {
+		if found := Detect(t.TempDir()); len(found) != 0 {
+			t.Errorf("expected no ecosystems, got %v", found)
+		}
+	})
+
+	t.Run("multiple ecosystems in precedence order", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte(""), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "setup.py"), []byte(""), 0644)
+
+		found := Detect(tmpDir)
+
+		expected := []Ecosystem{Go, Python, Make}
+		if len(found) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, found)
+		}
+		for i := range expected {
+			if found[i] != expected[i] {
+				t.Errorf("expected %v, got %v", expected, found)
+			}
+		}
+	})
+}
+
+func TestBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{"Go project", []string{"go.mod"}, "go build ./..."},
+		{"Node project with yarn", []string{"package.json", "yarn.lock"}, "yarn run build"},
+		{"Rust project", []string{"Cargo.toml"}, "cargo build"},
+		{"Python falls through to Make", []string{"pyproject.toml", "Makefile"}, "make"},
+		{"Python only", []string{"pyproject.toml"}, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, f := range tc.files {
+				os.WriteFile(filepath.Join(tmpDir, f), []byte(""), 0644)
+			}
+
+			if result := BuildCommand(tmpDir); result != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestErrorDetectionCommands(t *testing.T) {
+	t.Run("Go project runs build and vet", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644)
+
+		cmds := ErrorDetectionCommands(tmpDir)
+
+		if len(cmds) != 2 || cmds[0].Label != "Go build errors" || cmds[1].Label != "Go vet issues" {
+			t.Errorf("unexpected commands: %+v", cmds)
+		}
+	})
+
+	t.Run("Node project without TypeScript has no checks", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644)
+
+		if cmds := ErrorDetectionCommands(tmpDir); len(cmds) != 0 {
+			t.Errorf("expected no commands, got %+v", cmds)
+		}
+	})
+
+	t.Run("TypeScript project runs tsc", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "tsconfig.json"), []byte("{}"), 0644)
+
+		cmds := ErrorDetectionCommands(tmpDir)
+
+		if len(cmds) != 1 || cmds[0].Name != "npx" {
+			t.Errorf("expected tsc command, got %+v", cmds)
+		}
+	})
+
+	t.Run("Python project checks changed files", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "pyproject.toml"), []byte(""), 0644)
+
+		cmds := ErrorDetectionCommands(tmpDir)
+
+		if len(cmds) != 1 || cmds[0].ChangedFiles != "*.py" {
+			t.Errorf("expected per-file python check, got %+v", cmds)
+		}
+	})
+}
+
+func TestPolyglotTestCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "Makefile"), []byte(""), 0644)
+
+	t.Run("composes every detected ecosystem", func(t *testing.T) {
+		result := TestCommand(tmpDir)
+
+		if result != "go test ./... && go build ./... && npm test" {
+			t.Errorf("expected go and npm commands, got %q", result)
+		}
+		if strings.Contains(result, "make test") {
+			t.Errorf("expected Makefile to be ignored alongside language ecosystems, got %q", result)
+		}
+	})
+
+	t.Run("primary ecosystem narrows to one", func(t *testing.T) {
+		if result := ResolveTestCommand(tmpDir, "", Node); result != "npm test" {
+			t.Errorf("expected only npm test, got %q", result)
+		}
+	})
+
+	t.Run("override beats primary ecosystem", func(t *testing.T) {
+		if result := ResolveTestCommand(tmpDir, "make ci", Node); result != "make ci" {
+			t.Errorf("expected override, got %q", result)
+		}
+	})
+
+	t.Run("primary ecosystem not present", func(t *testing.T) {
+		if result := ResolveTestCommand(tmpDir, "", Rust); result != "" {
+			t.Errorf("expected no command, got %q", result)
+		}
+	})
+}
+
+func TestParseEcosystem(t *testing.T) {
+	if eco, err := ParseEcosystem("Node"); err != nil || eco != Node {
+		t.Errorf("expected node, got %q (%v)", eco, err)
+	}
+	if _, err := ParseEcosystem("cobol"); err == nil {
+		t.Error("expected error for unknown ecosystem")
+	}
+}
@@ -0,0 +1,440 @@
+// Package prompt models generated prompts as ordered sections so they can be
+// rendered as plain markdown or as a PR/issue comment.
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/emoji"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// Kind classifies a section for alternate renderings.
+type Kind int
+
+const (
+	Summary  Kind = iota // Concise status, always shown
+	Context              // Supporting detail, collapsed in comments
+	Protocol             // Agent instructions, collapsed in comments
+)
+
+// Section is a titled block of a prompt. Body is the markdown that follows the
+// "## Title" heading, including any trailing blank line.
+type Section struct {
+	Title string
+	Body  string
+	Kind  Kind
+}
+
+// kindNames are the names a section order uses to refer to all sections of a kind.
+var kindNames = map[string]Kind{
+	"summary":  Summary,
+	"context":  Context,
+	"protocol": Protocol,
+}
+
+// Document is a prompt: a top-level title followed by sections.
+type Document struct {
+	Title    string
+	Sections []Section
+	Known    []string // Every section title the command may add, present or not
+	Task     string   // Bead the prompt is about, where Output.TaskComment posts
+}
+
+// New creates an empty document with the given title. known lists the
+// section titles the command can produce, so a section order naming one that
+// was skipped this run isn't reported as unknown.
+func New(title string, known ...string) *Document {
+	return &Document{Title: title, Known: known}
+}
+
+// Add appends a section to the document.
+func (d *Document) Add(kind Kind, title, body string) {
+	d.Sections = append(d.Sections, Section{Title: title, Body: body, Kind: kind})
+}
+
+// WithoutProtocol returns a copy of the document with protocol sections removed.
+func (d *Document) WithoutProtocol() *Document {
+	ctx := New(d.Title, d.Known...)
+	ctx.Task = d.Task
+	for _, s := range d.Sections {
+		if s.Kind != Protocol {
+			ctx.Sections = append(ctx.Sections, s)
+		}
+	}
+	return ctx
+}
+
+// TaskComment renders the summary sections as a progress note for the task,
+// leaving out gathered detail and the protocol.
+func (d *Document) TaskComment() string {
+	note := New(d.Title)
+	for _, s := range d.Sections {
+		if s.Kind == Summary {
+			note.Sections = append(note.Sections, s)
+		}
+	}
+	return strings.TrimRight(note.Markdown(), "\n") + "\n"
+}
+
+// Section order presets accepted by ParseOrder.
+const (
+	OrderContextFirst  = "context-first"  // As each command builds it: context, then protocol
+	OrderProtocolFirst = "protocol-first" // Instructions before the gathered context
+)
+
+// ParseOrder converts a --section-order value into section names. It accepts
+// a preset or a comma-separated list of section titles and kinds (summary,
+// context, protocol), where "*" stands for every section not listed.
+// Unlisted sections follow the listed ones when "*" is omitted.
+func ParseOrder(s string) []string {
+	switch strings.TrimSpace(s) {
+	case "", OrderContextFirst:
+		return nil
+	case OrderProtocolFirst:
+		return []string{"protocol", "*"}
+	}
+
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Reorder returns a copy of the document with sections arranged per order
+// (see ParseOrder). Names matching no known section or kind are ignored with
+// a warning.
+func (d *Document) Reorder(order []string) *Document {
+	if len(order) == 0 {
+		return d
+	}
+
+	placed := make([]bool, len(d.Sections))
+	var head, tail []Section
+	afterRest := false
+	for _, name := range order {
+		if name == "*" {
+			afterRest = true
+			continue
+		}
+		if !d.knows(name) {
+			slog.Warn("ignoring unknown section in section order", "section", name)
+			continue
+		}
+		for i, s := range d.Sections {
+			if placed[i] || !s.matches(name) {
+				continue
+			}
+			placed[i] = true
+			if afterRest {
+				tail = append(tail, s)
+			} else {
+				head = append(head, s)
+			}
+		}
+	}
+
+	out := New(d.Title, d.Known...)
+	out.Task = d.Task
+	out.Sections = append(out.Sections, head...)
+	for i, s := range d.Sections {
+		if !placed[i] {
+			out.Sections = append(out.Sections, s)
+		}
+	}
+	out.Sections = append(out.Sections, tail...)
+	return out
+}
+
+// knows reports whether name refers to a kind or a section the command can produce.
+func (d *Document) knows(name string) bool {
+	if _, ok := kindNames[strings.ToLower(name)]; ok {
+		return true
+	}
+	for _, title := range d.Known {
+		if strings.EqualFold(title, name) {
+			return true
+		}
+	}
+	for _, s := range d.Sections {
+		if strings.EqualFold(s.Title, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether name selects this section by title or kind.
+func (s Section) matches(name string) bool {
+	if kind, ok := kindNames[strings.ToLower(name)]; ok && kind == s.Kind {
+		return true
+	}
+	return strings.EqualFold(s.Title, name)
+}
+
+// Markdown renders the document as the default prompt output.
+func (d *Document) Markdown() string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("# %s\n\n", d.Title))
+	for _, s := range d.Sections {
+		out.WriteString(fmt.Sprintf("## %s\n", s.Title))
+		out.WriteString(s.Body)
+	}
+	return out.String()
+}
+
+// Comment renders the document for a GitHub PR or issue comment. Summary
+// sections stay visible at the top; everything else is collapsed.
+func (d *Document) Comment() string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("### %s\n\n", d.Title))
+
+	for _, s := range d.Sections {
+		if s.Kind != Summary {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("**%s**\n", s.Title))
+		out.WriteString(strings.TrimRight(s.Body, "\n"))
+		out.WriteString("\n\n")
+	}
+
+	for _, s := range d.Sections {
+		if s.Kind == Summary {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("<details>\n<summary>%s</summary>\n\n", s.Title))
+		out.WriteString(strings.TrimRight(s.Body, "\n"))
+		out.WriteString("\n\n</details>\n\n")
+	}
+
+	return strings.TrimRight(out.String(), "\n") + "\n"
+}
+
+// TruncateLines limits s to maxLines lines, noting how many were cut.
+func TruncateLines(s string, maxLines int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= maxLines {
+		return s
+	}
+	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-maxLines)
+}
+
+// TailLines keeps the last maxLines lines of s, noting how many were cut.
+// Test and build output reports failures at the end.
+func TailLines(s string, maxLines int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= maxLines {
+		return s
+	}
+	return fmt.Sprintf("... (%d earlier lines)\n", len(lines)-maxLines) + strings.Join(lines[len(lines)-maxLines:], "\n")
+}
+
+// DefaultReservationTTL is the lifetime, in seconds, of the Agent Mail
+// file reservations suggested in protocols.
+const DefaultReservationTTL = 3600
+
+// ReservationTTL returns seconds, or DefaultReservationTTL when it isn't positive.
+func ReservationTTL(seconds int) int {
+	if seconds <= 0 {
+		return DefaultReservationTTL
+	}
+	return seconds
+}
+
+// Messages is a document split into chat API messages.
+type Messages struct {
+	System string `json:"system"` // Protocol sections: how the agent should work
+	User   string `json:"user"`   // Everything else: the gathered state and task
+}
+
+// Split divides the document for chat APIs: protocol sections become the
+// system message, and the title with every other section the user message.
+func (d *Document) Split() Messages {
+	var system strings.Builder
+	for _, s := range d.Sections {
+		if s.Kind == Protocol {
+			system.WriteString(fmt.Sprintf("## %s\n", s.Title))
+			system.WriteString(s.Body)
+		}
+	}
+	return Messages{System: system.String(), User: d.WithoutProtocol().Markdown()}
+}
+
+// Split file names written to Output.OutputDir
+const (
+	SystemFile = "system.md"
+	UserFile   = "user.md"
+)
+
+// Output selects how a command emits its document.
+type Output struct {
+	AsComment    bool   // Render as a PR/issue comment instead of a prompt
+	PostComment  int    // Post the comment to this PR number via gh (0 = print only)
+	NoProtocol   bool   // Omit protocol sections, leaving only gathered context
+	SectionOrder string // Section order preset or list (see ParseOrder)
+	Split        bool   // Emit system and user messages as JSON instead of one prompt
+	OutputDir    string // Write split messages to system.md and user.md here (implies Split)
+	TaskComment  bool   // Also post the summary sections as a comment on the document's task via bd
+	ShowCommand  bool   // After the prompt, explain on stderr how to run it with claude
+	Width        int    // Soft-wrap prose to this many columns (0 = no wrapping)
+}
+
+// Emit prints the document, or posts it as a comment when requested.
+func Emit(dir string, doc *Document, o Output, r runner.CommandRunner) error {
+	if o.TaskComment {
+		if err := commentOnTask(dir, doc, r); err != nil {
+			return err
+		}
+	}
+
+	doc = doc.Reorder(ParseOrder(o.SectionOrder))
+	if o.NoProtocol {
+		doc = doc.WithoutProtocol()
+	}
+	if o.PostComment > 0 {
+		return Post(dir, o.PostComment, doc.Comment(), r)
+	}
+	if o.AsComment {
+		fmt.Print(emoji.Render(doc.Comment()))
+		return nil
+	}
+	if o.Split || o.OutputDir != "" {
+		m := doc.Split()
+		m.System, m.User = emoji.Render(m.System), emoji.Render(m.User)
+		return emitSplit(m, o.OutputDir)
+	}
+	fmt.Print(emoji.Render(Wrap(doc.Markdown(), o.Width)))
+	if o.ShowCommand {
+		_, err := exec.LookPath("claude")
+		fmt.Fprint(os.Stderr, "\n"+CommandHint(os.Args[1:], err == nil))
+	}
+	return nil
+}
+
+// CommandHint explains how to hand the prompt printed by `vibes <args>` to
+// claude. claudeFound reports whether claude is on PATH.
+func CommandHint(args []string, claudeFound bool) string {
+	words := []string{"vibes"}
+	for _, arg := range args {
+		if arg == "--show-command" || strings.HasPrefix(arg, "--show-command=") {
+			continue
+		}
+		words = append(words, shellQuote(arg))
+	}
+	vibes := strings.Join(words, " ")
+
+	var out strings.Builder
+	if claudeFound {
+		out.WriteString("Run this prompt with Claude:\n")
+	} else {
+		out.WriteString("claude is not on your PATH. Install Claude Code, then run this prompt with:\n")
+	}
+	fmt.Fprintf(&out, "  claude \"$(%s)\"\n", vibes)
+	fmt.Fprintf(&out, "or non-interactively:\n  %s | claude -p\n", vibes)
+	return out.String()
+}
+
+// shellQuote single-quotes arg unless it is made only of characters the
+// shell leaves alone.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// emitSplit writes the messages to files in outputDir, or prints them as
+// JSON when outputDir is empty.
+func emitSplit(m Messages, outputDir string) error {
+	if outputDir == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false) // Prompts are full of <placeholders>
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	for name, body := range map[string]string{SystemFile: m.System, UserFile: m.User} {
+		if err := os.WriteFile(filepath.Join(outputDir, name), []byte(body), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s and %s to %s\n", SystemFile, UserFile, outputDir)
+	return nil
+}
+
+// confirmTaskComment asks before posting to the task. Replaced in tests.
+var confirmTaskComment = func(question string) (bool, error) {
+	var ok bool
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(question).
+				Value(&ok),
+		),
+	).WithOutput(os.Stderr)
+	if err := form.Run(); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// commentOnTask shows the task comment on stderr and posts it with bd once
+// confirmed. A bd without comment support only earns a warning.
+func commentOnTask(dir string, doc *Document, r runner.CommandRunner) error {
+	if doc.Task == "" {
+		return fmt.Errorf("no bead detected to comment on")
+	}
+	if beads.ExtractIDFromBranch(doc.Task) != doc.Task {
+		return fmt.Errorf("%s is not a bead, so bd can't comment on it", doc.Task)
+	}
+
+	body := doc.TaskComment()
+	fmt.Fprintf(os.Stderr, "%s\n", emoji.Render(body))
+	ok, err := confirmTaskComment(fmt.Sprintf("Post this as a comment on %s?", doc.Task))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Skipped comment on %s\n", doc.Task)
+		return nil
+	}
+
+	if err := beads.AddComment(dir, doc.Task, body, r); err != nil {
+		if errors.Is(err, beads.ErrCommentsUnsupported) {
+			fmt.Fprint(os.Stderr, emoji.Render(fmt.Sprintf("⚠️ %v\n", err)))
+			return nil
+		}
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Commented on %s\n", doc.Task)
+	return nil
+}
+
+// Post adds body as a comment on the given pull request using gh.
+func Post(dir string, number int, body string, r runner.CommandRunner) error {
+	_, err := r.RunWithTimeout(dir, 30*time.Second, "gh", "pr", "comment", fmt.Sprintf("%d", number), "--body", body)
+	if err != nil {
+		if output := runner.ErrorOutput(err); output != "" {
+			return fmt.Errorf("posting comment to PR #%d: %w\n%s", number, err, output)
+		}
+		return fmt.Errorf("posting comment to PR #%d: %w", number, err)
+	}
+	fmt.Fprintf(os.Stderr, "Posted comment to PR #%d\n", number)
+	return nil
+}
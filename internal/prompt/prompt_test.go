@@ -0,0 +1,342 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// MockRunner is a mock implementation of runner.CommandRunner for testing
+type MockRunner struct {
+	RunFunc            func(dir string, command string, args ...string) (string, error)
+	RunWithTimeoutFunc func(dir string, timeout time.Duration, command string, args ...string) (string, error)
+}
+
+func (m *MockRunner) Run(dir string, command string, args ...string) (string, error) {
+	if m.RunFunc != nil {
+		return m.RunFunc(dir, command, args...)
+	}
+	return "", nil
+}
+
+func (m *MockRunner) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+	if m.RunWithTimeoutFunc != nil {
+		return m.RunWithTimeoutFunc(dir, timeout, command, args...)
+	}
+	return "", nil
+}
+
+func testDocument() *Document {
+	doc := New("Complete Current Work in my-project")
+	doc.Add(Summary, "Work Summary", "- **Branch**: feature/x\n\n")
+	doc.Add(Context, "Recent Commits", "```\nabc123 first\n```\n\n")
+	doc.Add(Protocol, "Completion Protocol", "1. Close the task\n")
+	return doc
+}
+
+func TestMarkdown(t *testing.T) {
+	expected := "# Complete Current Work in my-project\n\n" +
+		"## Work Summary\n- **Branch**: feature/x\n\n" +
+		"## Recent Commits\n```\nabc123 first\n```\n\n" +
+		"## Completion Protocol\n1. Close the task\n"
+
+	if result := testDocument().Markdown(); result != expected {
+		t.Errorf("unexpected markdown:\n%s", result)
+	}
+}
+
+func TestWithoutProtocol(t *testing.T) {
+	doc := testDocument()
+	ctx := doc.WithoutProtocol()
+
+	result := ctx.Markdown()
+	if strings.Contains(result, "Completion Protocol") || strings.Contains(result, "Close the task") {
+		t.Errorf("expected protocol to be removed, got:\n%s", result)
+	}
+	if !strings.Contains(result, "## Work Summary") || !strings.Contains(result, "## Recent Commits") {
+		t.Errorf("expected context sections to remain, got:\n%s", result)
+	}
+	if len(doc.Sections) != 3 {
+		t.Errorf("expected original document to be unchanged, got %d sections", len(doc.Sections))
+	}
+}
+
+func TestSplit(t *testing.T) {
+	m := testDocument().Split()
+
+	if m.System != "## Completion Protocol\n1. Close the task\n" {
+		t.Errorf("unexpected system message:\n%s", m.System)
+	}
+	if !strings.HasPrefix(m.User, "# Complete Current Work in my-project\n") || !strings.Contains(m.User, "## Recent Commits") {
+		t.Errorf("expected title and context in user message, got:\n%s", m.User)
+	}
+	if strings.Contains(m.User, "Close the task") {
+		t.Errorf("expected protocol to be left out of the user message, got:\n%s", m.User)
+	}
+
+	t.Run("writes files to output dir", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "out")
+		if err := Emit("/repo", testDocument(), Output{OutputDir: dir}, &MockRunner{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		system, err := os.ReadFile(filepath.Join(dir, SystemFile))
+		if err != nil || string(system) != m.System {
+			t.Errorf("expected system.md to hold the system message, got %q (%v)", system, err)
+		}
+		user, err := os.ReadFile(filepath.Join(dir, UserFile))
+		if err != nil || string(user) != m.User {
+			t.Errorf("expected user.md to hold the user message, got %q (%v)", user, err)
+		}
+	})
+}
+
+func TestReorder(t *testing.T) {
+	titles := func(doc *Document) string {
+		var names []string
+		for _, s := range doc.Sections {
+			names = append(names, s.Title)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	testCases := []struct {
+		name     string
+		order    string
+		expected string
+	}{
+		{"default preset keeps order", "context-first", "Work Summary, Recent Commits, Completion Protocol"},
+		{"protocol first", "protocol-first", "Completion Protocol, Work Summary, Recent Commits"},
+		{"section last", "*, recent commits", "Work Summary, Completion Protocol, Recent Commits"},
+		{"unlisted sections follow", "Completion Protocol", "Completion Protocol, Work Summary, Recent Commits"},
+		{"kinds and titles", "context, protocol, summary", "Recent Commits, Completion Protocol, Work Summary"},
+		{"unknown names ignored", "Bogus, Recent Commits", "Recent Commits, Work Summary, Completion Protocol"},
+		{"known but absent", "Verification, protocol", "Completion Protocol, Work Summary, Recent Commits"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := testDocument()
+			doc.Known = []string{"Verification"}
+			if result := titles(doc.Reorder(ParseOrder(tc.order))); result != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+
+	t.Run("known names are not unknown", func(t *testing.T) {
+		doc := New("Title", "Verification")
+		if !doc.knows("verification") || !doc.knows("protocol") || doc.knows("Bogus") {
+			t.Error("unexpected known section names")
+		}
+	})
+}
+
+func TestTruncateLines(t *testing.T) {
+	t.Run("short output unchanged", func(t *testing.T) {
+		input := "line1\nline2\nline3"
+		result := TruncateLines(input, 10)
+		if result != input {
+			t.Errorf("expected unchanged output, got %s", result)
+		}
+	})
+
+	t.Run("long output truncated", func(t *testing.T) {
+		lines := make([]string, 20)
+		for i := range lines {
+			lines[i] = "line"
+		}
+		input := strings.Join(lines, "\n")
+		result := TruncateLines(input, 5)
+
+		if !strings.Contains(result, "... (15 more lines)") {
+			t.Error("expected truncation message")
+		}
+		outputLines := strings.Split(result, "\n")
+		if len(outputLines) != 6 { // 5 lines + truncation message
+			t.Errorf("expected 6 lines, got %d", len(outputLines))
+		}
+	})
+}
+
+func TestTailLines(t *testing.T) {
+	if result := TailLines("a\nb", 5); result != "a\nb" {
+		t.Errorf("expected unchanged output, got %q", result)
+	}
+	if result := TailLines("1\n2\n3\n4\n5", 2); result != "... (3 earlier lines)\n4\n5" {
+		t.Errorf("expected last two lines, got %q", result)
+	}
+}
+
+func TestComment(t *testing.T) {
+	result := testDocument().Comment()
+
+	t.Run("summary is visible at the top", func(t *testing.T) {
+		if !strings.HasPrefix(result, "### Complete Current Work in my-project\n\n**Work Summary**\n- **Branch**: feature/x\n") {
+			t.Errorf("expected summary first, got:\n%s", result)
+		}
+	})
+
+	t.Run("context and protocol are collapsed", func(t *testing.T) {
+		for _, title := range []string{"Recent Commits", "Completion Protocol"} {
+			if !strings.Contains(result, "<details>\n<summary>"+title+"</summary>") {
+				t.Errorf("expected %s to be collapsed, got:\n%s", title, result)
+			}
+		}
+		if strings.Count(result, "<details>") != strings.Count(result, "</details>") {
+			t.Errorf("unbalanced details tags:\n%s", result)
+		}
+	})
+
+	t.Run("no markdown section headings", func(t *testing.T) {
+		if strings.Contains(result, "\n## ") {
+			t.Errorf("unexpected section heading:\n%s", result)
+		}
+	})
+}
+
+func TestPost(t *testing.T) {
+	t.Run("posts via gh", func(t *testing.T) {
+		var gotArgs []string
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				gotArgs = append([]string{command}, args...)
+				return "", nil
+			},
+		}
+
+		if err := Post("/tmp", 42, "hello", mock); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := "gh pr comment 42 --body hello"
+		if strings.Join(gotArgs, " ") != expected {
+			t.Errorf("expected %q, got %q", expected, strings.Join(gotArgs, " "))
+		}
+	})
+
+	t.Run("surfaces gh output on failure", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", &runner.CommandError{Err: errors.New("exit status 1"), Output: "no pull requests found"}
+			},
+		}
+
+		err := Post("/tmp", 7, "hello", mock)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "PR #7") || !strings.Contains(err.Error(), "no pull requests found") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestTaskComment(t *testing.T) {
+	expected := "# Complete Current Work in my-project\n\n" +
+		"## Work Summary\n- **Branch**: feature/x\n"
+
+	if result := testDocument().TaskComment(); result != expected {
+		t.Errorf("expected only summary sections, got:\n%s", result)
+	}
+}
+
+func TestEmitTaskComment(t *testing.T) {
+	stubConfirm := func(t *testing.T, answer bool) {
+		t.Helper()
+		orig := confirmTaskComment
+		confirmTaskComment = func(string) (bool, error) { return answer, nil }
+		t.Cleanup(func() { confirmTaskComment = orig })
+	}
+
+	commentMock := func(posted *[]string, err error) *MockRunner {
+		return &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				*posted = append([]string{command}, args...)
+				return "", err
+			},
+		}
+	}
+
+	t.Run("posts summary to the task once confirmed", func(t *testing.T) {
+		stubConfirm(t, true)
+		doc := testDocument()
+		doc.Task = "bd-42"
+
+		var posted []string
+		if err := Emit("/repo", doc, Output{TaskComment: true, NoProtocol: true}, commentMock(&posted, nil)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(posted) != 5 || strings.Join(posted[:4], " ") != "bd comment add bd-42" || posted[4] != doc.TaskComment() {
+			t.Errorf("unexpected bd call: %q", posted)
+		}
+	})
+
+	t.Run("declined confirmation posts nothing", func(t *testing.T) {
+		stubConfirm(t, false)
+		doc := testDocument()
+		doc.Task = "bd-42"
+
+		var posted []string
+		if err := Emit("/repo", doc, Output{TaskComment: true, NoProtocol: true}, commentMock(&posted, nil)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if posted != nil {
+			t.Errorf("expected no bd call, got %q", posted)
+		}
+	})
+
+	t.Run("requires a bead", func(t *testing.T) {
+		stubConfirm(t, true)
+		for _, task := range []string{"", "ENG-7"} {
+			doc := testDocument()
+			doc.Task = task
+			var posted []string
+			if err := Emit("/repo", doc, Output{TaskComment: true}, commentMock(&posted, nil)); err == nil {
+				t.Errorf("expected error for task %q", task)
+			}
+		}
+	})
+
+	t.Run("bd without comments only warns", func(t *testing.T) {
+		stubConfirm(t, true)
+		doc := testDocument()
+		doc.Task = "bd-42"
+
+		var posted []string
+		unsupported := &runner.CommandError{Err: errors.New("exit status 1"), Output: `Error: unknown command "comment" for "bd"`}
+		if err := Emit("/repo", doc, Output{TaskComment: true, NoProtocol: true}, commentMock(&posted, unsupported)); err != nil {
+			t.Errorf("expected unsupported bd to be tolerated, got %v", err)
+		}
+	})
+}
+
+func TestCommandHint(t *testing.T) {
+	t.Run("drops the flag and quotes arguments", func(t *testing.T) {
+		hint := CommandHint([]string{"stuck", "--show-command", "tests fail on CI", "-v"}, true)
+		if !strings.Contains(hint, `claude "$(vibes stuck 'tests fail on CI' -v)"`) {
+			t.Errorf("expected $(...) form, got:\n%s", hint)
+		}
+		if !strings.Contains(hint, "vibes stuck 'tests fail on CI' -v | claude -p") {
+			t.Errorf("expected pipe form, got:\n%s", hint)
+		}
+		if strings.Contains(hint, "show-command") {
+			t.Errorf("expected --show-command removed, got:\n%s", hint)
+		}
+	})
+
+	t.Run("single quotes are escaped", func(t *testing.T) {
+		if got := shellQuote("it's"); got != `'it'\''s'` {
+			t.Errorf("unexpected quoting: %s", got)
+		}
+	})
+
+	t.Run("claude missing", func(t *testing.T) {
+		if hint := CommandHint([]string{"next"}, false); !strings.Contains(hint, "claude is not on your PATH") {
+			t.Errorf("expected install note, got:\n%s", hint)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package prompt
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// listMarker matches the start of a list item, whose wrapped lines are
+// indented to line up with the item's text.
+var listMarker = regexp.MustCompile(`^\s*([-*+]|\d+\.)\s+`)
+
+// TerminalWidth returns the width of the terminal stdout is attached to,
+// or 0 when stdout is piped or redirected.
+func TerminalWidth() int {
+	fd := os.Stdout.Fd()
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// Wrap soft-wraps the prose of a markdown document to width columns.
+// Code fences, tables, and headings are left intact. A width of 0 or less
+// returns the document unchanged.
+func Wrap(markdown string, width int) string {
+	if width <= 0 {
+		return markdown
+	}
+
+	lines := strings.Split(markdown, "\n")
+	out := make([]string, 0, len(lines))
+	inFence := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence || ansi.StringWidth(line) <= width ||
+			strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "#") {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, wrapLine(line, width))
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrapLine wraps one line, keeping its indentation (or, for list items,
+// the indentation of the item's text) on continuation lines.
+func wrapLine(line string, width int) string {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if m := listMarker.FindString(line); m != "" {
+		indent = len(m)
+	}
+	if indent >= width/2 {
+		indent = 0
+	}
+
+	first := ansi.Wordwrap(line, width, "")
+	head, rest, found := strings.Cut(first, "\n")
+	if !found || indent == 0 {
+		return first
+	}
+
+	pad := strings.Repeat(" ", indent)
+	rest = strings.Join(strings.Fields(strings.ReplaceAll(rest, "\n", " ")), " ")
+	wrapped := strings.Split(ansi.Wordwrap(rest, width-indent, ""), "\n")
+	for i, l := range wrapped {
+		wrapped[i] = pad + l
+	}
+	return head + "\n" + strings.Join(wrapped, "\n")
+}
@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	long := "This sentence is long enough that it has to be wrapped at thirty columns."
+
+	t.Run("zero width leaves output alone", func(t *testing.T) {
+		if got := Wrap(long, 0); got != long {
+			t.Errorf("expected unchanged output, got %q", got)
+		}
+	})
+
+	t.Run("wraps prose to width", func(t *testing.T) {
+		got := Wrap(long, 30)
+		for _, line := range strings.Split(got, "\n") {
+			if len(line) > 30 {
+				t.Errorf("line exceeds width: %q", line)
+			}
+		}
+		if strings.Join(strings.Fields(got), " ") != long {
+			t.Errorf("expected words preserved, got %q", got)
+		}
+	})
+
+	t.Run("code fences, tables, and headings stay intact", func(t *testing.T) {
+		fenced := "   ```bash\n   " + long + "\n   ```"
+		table := "| " + long + " |"
+		heading := "## " + long
+		for _, block := range []string{fenced, table, heading} {
+			if got := Wrap(block, 30); got != block {
+				t.Errorf("expected block unchanged, got:\n%s", got)
+			}
+		}
+	})
+
+	t.Run("list items keep a hanging indent", func(t *testing.T) {
+		got := Wrap("- "+long, 30)
+		lines := strings.Split(got, "\n")
+		if len(lines) < 2 {
+			t.Fatalf("expected wrapping, got %q", got)
+		}
+		for _, line := range lines[1:] {
+			if !strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "   ") {
+				t.Errorf("expected two-space continuation indent, got %q", line)
+			}
+		}
+	})
+
+	t.Run("long words are not broken", func(t *testing.T) {
+		url := "See https://example.com/a/very/long/path/that/cannot/be/split"
+		if got := Wrap(url, 30); !strings.Contains(got, "https://example.com/a/very/long/path/that/cannot/be/split") {
+			t.Errorf("expected URL kept whole, got %q", got)
+		}
+	})
+}
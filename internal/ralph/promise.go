@@ -0,0 +1,32 @@
+package ralph
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CompletionPromise is the value agents emit in a <promise> tag when the
+// Ralph objective is fully complete.
+const CompletionPromise = "COMPLETE"
+
+// promiseTag matches <promise>...</promise>, tolerating tag case and spacing
+var promiseTag = regexp.MustCompile(`(?is)<\s*promise\s*>(.*?)<\s*/\s*promise\s*>`)
+
+// ExtractPromise returns the trimmed contents of the last <promise> tag in
+// agent output. Tag names are matched case-insensitively and may contain
+// spaces; ok is false if no complete tag is present.
+func ExtractPromise(agentOutput string) (promise string, ok bool) {
+	matches := promiseTag.FindAllStringSubmatch(agentOutput, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return strings.TrimSpace(matches[len(matches)-1][1]), true
+}
+
+// IsComplete reports whether agent output ends the Ralph loop: its last
+// promise must be exactly CompletionPromise. Variants such as "complete"
+// or "COMPLETE?" don't count.
+func IsComplete(agentOutput string) bool {
+	promise, ok := ExtractPromise(agentOutput)
+	return ok && promise == CompletionPromise
+}
@@ -0,0 +1,52 @@
+package ralph
+
+import "testing"
+
+func TestExtractPromise(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected string
+		ok       bool
+	}{
+		{"present", "All tests pass.\n<promise>COMPLETE</promise>\n", "COMPLETE", true},
+		{"padded and mixed case tag", "< Promise > COMPLETE\n</PROMISE >", "COMPLETE", true},
+		{"last tag wins", "<promise>BLOCKED</promise> ... <promise>COMPLETE</promise>", "COMPLETE", true},
+		{"absent", "Still working on the parser.", "", false},
+		{"unclosed", "<promise>COMPLETE", "", false},
+		{"mismatched tag", "<promise>COMPLETE</promises>", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			promise, ok := ExtractPromise(tc.output)
+			if promise != tc.expected || ok != tc.ok {
+				t.Errorf("ExtractPromise(%q) = %q, %v; want %q, %v", tc.output, promise, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestIsComplete(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected bool
+	}{
+		{"Done. <promise>COMPLETE</promise>", true},
+		{"<promise>\n  COMPLETE\n</promise>", true},
+		{"<promise>complete</promise>", false},
+		{"<promise>COMPLETE?</promise>", false},
+		{"<promise>NOT COMPLETE</promise>", false},
+		{"<promise>COMPLETE</promise> then <promise>BLOCKED</promise>", false},
+		{"I will output COMPLETE when done", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.output, func(t *testing.T) {
+			if got := IsComplete(tc.output); got != tc.expected {
+				t.Errorf("IsComplete(%q) = %v, want %v", tc.output, got, tc.expected)
+			}
+		})
+	}
+}
@@ -2,13 +2,18 @@ package ralph
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/emoji"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/history"
+	"github.com/vibes-project/vibes/internal/project"
 	"github.com/vibes-project/vibes/internal/runner"
 )
 
@@ -31,6 +36,12 @@ type Options struct {
 	Mode          Mode                 // Operation mode
 	Goal          string               // For ModeGoal: the goal to work toward
 	MaxIterations int                  // Suggested iteration limit (0 = unlimited)
+	Iteration     int                  // Current iteration number (0 = detect from checkpoint commits)
+	MaxTasks      int                  // Max tasks kept from triage output (0 = unlimited)
+	TestCommand   string               // Test command override (defaults to auto-detection)
+	Ecosystem     project.Ecosystem    // Only use this ecosystem's test command (defaults to all detected)
+	Record        bool                 // Append the targeted task to .vibes/history.jsonl
+	Strict        bool                 // Fail instead of degrading when beads tools are missing or fail
 	Runner        runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
@@ -49,6 +60,14 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
+	r = git.NewCache(r)
+
+	if opts.Iteration < 0 {
+		return fmt.Errorf("invalid iteration %d (must be 1 or more)", opts.Iteration)
+	}
+	if opts.Iteration == 0 {
+		opts.Iteration = detectIteration(dir, r)
+	}
 
 	var out strings.Builder
 
@@ -66,27 +85,55 @@ func Run(opts Options) error {
 
 	// Current objective based on mode
 	out.WriteString("## Current Objective\n")
-	out.WriteString(buildTaskSection(dir, opts, r))
+	objective, task, err := buildTaskSection(dir, opts, r)
+	if err != nil {
+		return err
+	}
+	out.WriteString(objective)
 	out.WriteString("\n")
 
+	if opts.Record {
+		if err := history.Append(dir, historyEntry(opts, task, git.GetCurrentBranch(dir, r)), r); err != nil {
+			slog.Warn("could not record history", "err", err)
+		}
+	}
+
 	// Completion requirements
 	out.WriteString("## Completion Requirements (CRITICAL)\n")
-	out.WriteString(buildCompletionRequirements(dir, opts.Verbose))
+	out.WriteString(buildCompletionRequirements(detectTestCommand(dir, opts.TestCommand, opts.Ecosystem), opts.Verbose))
 	out.WriteString("\n")
 
 	// Checkpoint protocol
 	out.WriteString("## Checkpoint Commits\n")
-	out.WriteString(buildCheckpointProtocol(opts.Verbose))
+	out.WriteString(buildCheckpointProtocol(opts.Iteration, opts.Verbose))
 	out.WriteString("\n")
 
 	// Iteration protocol
 	out.WriteString("## Iteration Protocol\n")
 	out.WriteString(buildIterationProtocol(opts.Verbose))
 
-	fmt.Print(out.String())
+	fmt.Print(emoji.Render(out.String()))
 	return nil
 }
 
+// historyEntry describes what this invocation targeted. Outside goal mode the
+// task is the top open task of the triage listing.
+func historyEntry(opts Options, task string, branch string) history.Entry {
+	entry := history.Entry{Command: "ralph", Branch: branch}
+	switch opts.Mode {
+	case ModeGoal:
+		entry.Mode = "goal"
+		entry.Goal = opts.Goal
+	case ModeAutopilot:
+		entry.Mode = "autopilot"
+		entry.Task = task
+	default:
+		entry.Mode = "single-task"
+		entry.Task = task
+	}
+	return entry
+}
+
 func buildModeSection(opts Options) string {
 	var mode string
 	switch opts.Mode {
@@ -103,6 +150,9 @@ func buildModeSection(opts Options) string {
 	if opts.MaxIterations > 0 {
 		out.WriteString(fmt.Sprintf("- Max iterations: %d [suggested limit]\n", opts.MaxIterations))
 	}
+	if opts.Iteration > 0 {
+		out.WriteString(fmt.Sprintf("- Current iteration: %d\n", opts.Iteration))
+	}
 	out.WriteString("\n")
 	return out.String()
 }
@@ -147,34 +197,48 @@ func sanitizeForShell(s string) string {
 	return s
 }
 
-func buildTaskSection(dir string, opts Options, r runner.CommandRunner) string {
+// buildTaskSection describes the objective for the mode, along with the first
+// open task in the triage listing, if any. In strict mode a missing task
+// graph, failed triage, or empty listing is an error instead of a note.
+func buildTaskSection(dir string, opts Options, r runner.CommandRunner) (string, string, error) {
 	switch opts.Mode {
 	case ModeGoal:
-		return buildGoalSection(opts.Goal)
+		return buildGoalSection(opts.Goal), "", nil
 	case ModeAutopilot:
-		return buildAutopilotSection(dir, r)
+		return buildAutopilotSection(dir, r, opts.MaxTasks, opts.Strict)
 	default:
-		return buildSingleTaskSection(dir, r)
+		return buildSingleTaskSection(dir, r, opts.MaxTasks, opts.Strict)
 	}
 }
 
-func buildSingleTaskSection(dir string, r runner.CommandRunner) string {
+func buildSingleTaskSection(dir string, r runner.CommandRunner, maxTasks int, strict bool) (string, string, error) {
 	// Check if beads is initialized
 	if !beads.IsInitialized(dir) {
-		return "No beads task graph found. Work on immediate project needs or run `bd init` to initialize Beads.\n"
+		if strict {
+			return "", "", beads.ErrNotInitialized
+		}
+		return "No beads task graph found. Work on immediate project needs or run `bd init` to initialize Beads.\n", "", nil
 	}
 
-	// Try bv --robot-triage first (more intelligent recommendations)
-	if output, err := r.RunWithTimeout(dir, 10*time.Second, "bv", "--robot-triage"); err == nil && output != "" {
-		return output + "\n\nFocus on completing the highest priority task above.\n"
+	triage := beads.GetTriage(dir, r, maxTasks)
+	if strict {
+		if err := triage.Require(); err != nil {
+			return "", "", err
+		}
 	}
-
-	// Fall back to bd ready
-	if output, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "ready"); err == nil && output != "" {
-		return output + "\n\nSelect and complete the most appropriate task from above.\n"
+	warning := ""
+	if triage.Warning != "" {
+		warning = triage.Warning + "\n"
+	}
+	task, _ := beads.FirstOpenTask(dir, triage.Output, r)
+	switch triage.Source {
+	case "bv":
+		return warning + triage.Output + "\n\nFocus on completing the highest priority task above.\n", task, nil
+	case "bd":
+		return warning + triage.Output + "\n\nSelect and complete the most appropriate task from above.\n", task, nil
 	}
 
-	return "Beads initialized but no ready tasks found. Work on immediate project needs or create tasks with `bd create \"Task name\" -p 1`.\n"
+	return warning + "Beads initialized but no ready tasks found. Work on immediate project needs or create tasks with `bd create \"Task name\" -p 1`.\n", "", nil
 }
 
 func buildGoalSection(goal string) string {
@@ -185,25 +249,38 @@ func buildGoalSection(goal string) string {
 	return out.String()
 }
 
-func buildAutopilotSection(dir string, r runner.CommandRunner) string {
+func buildAutopilotSection(dir string, r runner.CommandRunner, maxTasks int, strict bool) (string, string, error) {
 	var out strings.Builder
 
 	// Check if beads is initialized
 	if !beads.IsInitialized(dir) {
+		if strict {
+			return "", "", beads.ErrNotInitialized
+		}
 		out.WriteString("No beads task graph found. Run 'bd init' to initialize Beads for autopilot mode.\n")
-		return out.String()
+		return out.String(), "", nil
 	}
 
-	out.WriteString("Work through the entire task graph autonomously.\n\n")
-
 	// Get task graph overview
-	if output, err := r.RunWithTimeout(dir, 10*time.Second, "bv", "--robot-triage"); err == nil && output != "" {
+	triage := beads.GetTriage(dir, r, maxTasks)
+	if strict {
+		if err := triage.Require(); err != nil {
+			return "", "", err
+		}
+	}
+
+	out.WriteString("Work through the entire task graph autonomously.\n\n")
+	if triage.Warning != "" {
+		out.WriteString(triage.Warning + "\n")
+	}
+	switch triage.Source {
+	case "bv":
 		out.WriteString("### Task Overview\n")
-		out.WriteString(output)
-		out.WriteString("\n\n")
-	} else if output, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "ready"); err == nil && output != "" {
+	case "bd":
 		out.WriteString("### Ready Tasks\n")
-		out.WriteString(output)
+	}
+	if triage.Output != "" {
+		out.WriteString(triage.Output)
 		out.WriteString("\n\n")
 	}
 
@@ -211,14 +288,13 @@ func buildAutopilotSection(dir string, r runner.CommandRunner) string {
 	out.WriteString("1. Mark it closed: `bd update <id> --status closed`\n")
 	out.WriteString("2. Check for newly unblocked tasks\n")
 	out.WriteString("3. Continue with the next highest priority task\n")
-	return out.String()
+	task, _ := beads.FirstOpenTask(dir, triage.Output, r)
+	return out.String(), task, nil
 }
 
-func buildCompletionRequirements(dir string, verbose bool) string {
+func buildCompletionRequirements(testCmd string, verbose bool) string {
 	var out strings.Builder
 
-	testCmd := detectTestCommand(dir)
-
 	out.WriteString("Both conditions must be met for completion:\n\n")
 
 	out.WriteString("1. Verification signals must pass:\n")
@@ -239,57 +315,72 @@ func buildCompletionRequirements(dir string, verbose bool) string {
 	return out.String()
 }
 
-// detectTestCommand auto-detects the appropriate test/build commands for the project.
-func detectTestCommand(dir string) string {
-	// Check for Go projects
-	if fileExists(filepath.Join(dir, "go.mod")) {
-		return "go test ./... && go build ./..."
-	}
-
-	// Check for Node.js projects
-	if fileExists(filepath.Join(dir, "package.json")) {
-		// Check for yarn
-		if fileExists(filepath.Join(dir, "yarn.lock")) {
-			return "yarn test"
-		}
-		// Check for pnpm
-		if fileExists(filepath.Join(dir, "pnpm-lock.yaml")) {
-			return "pnpm test"
-		}
-		return "npm test"
+// detectTestCommand returns the configured override, or the auto-detected
+// test/build command for the project.
+func detectTestCommand(dir string, override string, primary project.Ecosystem) string {
+	if testCmd := project.ResolveTestCommand(dir, override, primary); testCmd != "" {
+		return testCmd
 	}
+	// Default: just verify build artifacts or skip
+	return "# No test runner detected - verify manually or add tests"
+}
 
-	// Check for Python projects
-	if fileExists(filepath.Join(dir, "pyproject.toml")) {
-		return "pytest"
-	}
-	if fileExists(filepath.Join(dir, "setup.py")) {
-		return "pytest"
+// iterationSubject matches ralph checkpoint commit subjects, capturing the iteration number
+var iterationSubject = regexp.MustCompile(`^ralph: iteration (\d+)\b`)
+
+// recentSubjects bounds how many commits detectIteration scans when ralph
+// runs directly on the base branch.
+const recentSubjects = 50
+
+// detectIteration derives the current iteration from checkpoint commits on
+// the branch, or from recent commits when HEAD is the base branch itself.
+// Returns 0 if the commits can't be listed.
+func detectIteration(dir string, r runner.CommandRunner) int {
+	base := git.BaseBranch(dir, r)
+	args := []string{"log", "--format=%s", base + "..HEAD"}
+	if branch := git.GetCurrentBranch(dir, r); branch == base || branch == git.TrimRemote(base) {
+		args = []string{"log", "--format=%s", "-n", strconv.Itoa(recentSubjects)}
 	}
-
-	// Check for Rust projects
-	if fileExists(filepath.Join(dir, "Cargo.toml")) {
-		return "cargo test && cargo build"
+	output, err := r.Run(dir, "git", args...)
+	if err != nil {
+		return 0
 	}
+	return nextIteration(output)
+}
 
-	// Check for Make projects
-	if fileExists(filepath.Join(dir, "Makefile")) {
-		return "make test"
+// nextIteration returns one past the highest iteration among commit subjects,
+// or 1 if there are no checkpoint commits yet.
+func nextIteration(subjects string) int {
+	last := 0
+	for _, subject := range strings.Split(subjects, "\n") {
+		if m := iterationSubject.FindStringSubmatch(strings.TrimSpace(subject)); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > last {
+				last = n
+			}
+		}
 	}
-
-	// Default: just verify build artifacts or skip
-	return "# No test runner detected - verify manually or add tests"
+	return last + 1
 }
 
-func buildCheckpointProtocol(verbose bool) string {
+// buildCheckpointProtocol explains checkpoint commits. A known iteration
+// replaces the N placeholder in the commit message.
+func buildCheckpointProtocol(iteration int, verbose bool) string {
 	var out strings.Builder
 
+	n := "N"
+	if iteration > 0 {
+		n = strconv.Itoa(iteration)
+	}
 	out.WriteString("After each successful iteration [tests pass], create a checkpoint commit:\n")
-	out.WriteString("   git add -A && git commit -m \"ralph: iteration N - [brief summary]\"\n")
+	out.WriteString(fmt.Sprintf("   git add -A && git commit -m \"ralph: iteration %s - [brief summary]\"\n", n))
 
 	if verbose {
 		out.WriteString("\nCommit Guidelines:\n")
-		out.WriteString("- Replace N with the iteration number [1, 2, 3, ...]\n")
+		if iteration > 0 {
+			out.WriteString(fmt.Sprintf("- This is iteration %d; number later checkpoints %d, %d, ...\n", iteration, iteration+1, iteration+2))
+		} else {
+			out.WriteString("- Replace N with the iteration number [1, 2, 3, ...]\n")
+		}
 		out.WriteString("- Keep summary brief [under 50 chars]\n")
 		out.WriteString("- Examples:\n")
 		out.WriteString("  - ralph: iteration 1 - add user model\n")
@@ -343,9 +434,3 @@ Important: Do not skip steps. Each iteration must verify before checkpointing.
 Begin working now.
 `
 }
-
-// fileExists checks if a file exists at the given path.
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
-}
@@ -1,6 +1,7 @@
 package ralph
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -103,98 +104,52 @@ func TestBuildModeSection(t *testing.T) {
 }
 
 func TestDetectTestCommand(t *testing.T) {
-	tests := []struct {
-		name     string
-		setup    func(dir string)
-		expected string
-	}{
-		{
-			name: "Go project",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
-			},
-			expected: "go test ./... && go build ./...",
-		},
-		{
-			name: "Node project with npm",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
-			},
-			expected: "npm test",
-		},
-		{
-			name: "Node project with yarn",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
-				os.WriteFile(filepath.Join(dir, "yarn.lock"), []byte(""), 0644)
-			},
-			expected: "yarn test",
-		},
-		{
-			name: "Node project with pnpm",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
-				os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), []byte(""), 0644)
-			},
-			expected: "pnpm test",
-		},
-		{
-			name: "Python project with pyproject.toml",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(""), 0644)
-			},
-			expected: "pytest",
-		},
-		{
-			name: "Python project with setup.py",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "setup.py"), []byte(""), 0644)
-			},
-			expected: "pytest",
-		},
-		{
-			name: "Rust project",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(""), 0644)
-			},
-			expected: "cargo test && cargo build",
-		},
-		{
-			name: "Makefile project",
-			setup: func(dir string) {
-				os.WriteFile(filepath.Join(dir, "Makefile"), []byte(""), 0644)
-			},
-			expected: "make test",
-		},
-		{
-			name: "No recognized project type",
-			setup: func(dir string) {
-				// Empty directory
-			},
-			expected: "# No test runner detected",
-		},
-	}
+	t.Run("uses detected command", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644)
 
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			tmpDir := t.TempDir()
-			tc.setup(tmpDir)
+		result := detectTestCommand(tmpDir, "", "")
 
-			result := detectTestCommand(tmpDir)
+		if result != "go test ./... && go build ./..." {
+			t.Errorf("expected go test command, got: %s", result)
+		}
+	})
 
-			if !strings.Contains(result, tc.expected) {
-				t.Errorf("expected '%s' in output, got: %s", tc.expected, result)
-			}
-		})
-	}
-}
+	t.Run("override wins over detection", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644)
 
-func TestBuildCompletionRequirements(t *testing.T) {
-	t.Run("non-verbose", func(t *testing.T) {
+		result := detectTestCommand(tmpDir, "make check", "")
+
+		if result != "make check" {
+			t.Errorf("expected override, got: %s", result)
+		}
+	})
+
+	t.Run("polyglot repo runs every ecosystem", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644)
 
-		result := buildCompletionRequirements(tmpDir, false)
+		result := buildCompletionRequirements(detectTestCommand(tmpDir, "", ""), false)
+
+		if !strings.Contains(result, "go test ./...") || !strings.Contains(result, "npm test") {
+			t.Errorf("expected both go and npm test commands, got: %s", result)
+		}
+	})
+
+	t.Run("placeholder when nothing detected", func(t *testing.T) {
+		result := detectTestCommand(t.TempDir(), "", "")
+
+		if !strings.Contains(result, "# No test runner detected") {
+			t.Errorf("expected placeholder, got: %s", result)
+		}
+	})
+}
+
+func TestBuildCompletionRequirements(t *testing.T) {
+	t.Run("non-verbose", func(t *testing.T) {
+		result := buildCompletionRequirements("go test ./... && go build ./...", false)
 
 		if !strings.Contains(result, "go test") {
 			t.Errorf("expected test command, got: %s", result)
@@ -249,7 +204,7 @@ func TestSanitizeForShell(t *testing.T) {
 
 func TestBuildCheckpointProtocol(t *testing.T) {
 	t.Run("non-verbose", func(t *testing.T) {
-		result := buildCheckpointProtocol(false)
+		result := buildCheckpointProtocol(0, false)
 
 		if !strings.Contains(result, "git add -A && git commit") {
 			t.Errorf("expected git command, got: %s", result)
@@ -260,7 +215,7 @@ func TestBuildCheckpointProtocol(t *testing.T) {
 	})
 
 	t.Run("verbose includes guidelines", func(t *testing.T) {
-		result := buildCheckpointProtocol(true)
+		result := buildCheckpointProtocol(0, true)
 
 		if !strings.Contains(result, "Commit Guidelines") {
 			t.Errorf("expected guidelines header, got: %s", result)
@@ -274,6 +229,97 @@ func TestBuildCheckpointProtocol(t *testing.T) {
 	})
 }
 
+func TestDetectIteration(t *testing.T) {
+	t.Run("next after highest checkpoint", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if args[0] == "log" && args[2] == "main..HEAD" {
+					return "ralph: iteration 3 - fix validation bug\nTweak docs\nralph: iteration 2 - implement auth endpoint\nralph: iteration 1 - add user model", nil
+				}
+				return "", nil
+			},
+		}
+
+		if n := detectIteration("/test", mock); n != 4 {
+			t.Errorf("expected iteration 4, got %d", n)
+		}
+	})
+
+	t.Run("uses the upstream default branch in a fork", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				switch {
+				case args[0] == "remote":
+					return "origin\nupstream", nil
+				case args[0] == "symbolic-ref":
+					return "upstream/develop", nil
+				case args[0] == "log" && args[2] == "upstream/develop..HEAD":
+					return "ralph: iteration 1 - add user model", nil
+				case args[0] == "log":
+					t.Errorf("unexpected log range: %v", args)
+				}
+				return "feature/auth", nil
+			},
+		}
+
+		if n := detectIteration("/test", mock); n != 2 {
+			t.Errorf("expected iteration 2, got %d", n)
+		}
+	})
+
+	t.Run("scans recent commits on the base branch", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				switch {
+				case args[0] == "rev-parse" && args[1] == "--abbrev-ref":
+					return "main", nil
+				case args[0] == "log" && args[2] == "-n":
+					return "ralph: iteration 5 - add retries\nralph: iteration 4 - fix flake", nil
+				case args[0] == "log":
+					return "", nil
+				}
+				return "", nil
+			},
+		}
+
+		if n := detectIteration("/test", mock); n != 6 {
+			t.Errorf("expected iteration 6, got %d", n)
+		}
+	})
+
+	t.Run("first iteration", func(t *testing.T) {
+		if n := nextIteration("Add user model\n"); n != 1 {
+			t.Errorf("expected iteration 1, got %d", n)
+		}
+	})
+
+	t.Run("undetectable keeps placeholder", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return "", errors.New("unknown revision")
+			},
+		}
+
+		n := detectIteration("/test", mock)
+		if n != 0 {
+			t.Errorf("expected 0, got %d", n)
+		}
+		if result := buildCheckpointProtocol(n, false); !strings.Contains(result, "ralph: iteration N - ") {
+			t.Errorf("expected N placeholder, got: %s", result)
+		}
+	})
+
+	t.Run("known iteration is substituted", func(t *testing.T) {
+		result := buildCheckpointProtocol(4, true)
+		if !strings.Contains(result, "ralph: iteration 4 - ") || strings.Contains(result, "Replace N") {
+			t.Errorf("expected iteration 4 in protocol, got: %s", result)
+		}
+		if mode := buildModeSection(Options{Iteration: 4}); !strings.Contains(mode, "Current iteration: 4") {
+			t.Errorf("expected iteration in mode section, got: %s", mode)
+		}
+	})
+}
+
 func TestBuildIterationProtocol(t *testing.T) {
 	t.Run("non-verbose", func(t *testing.T) {
 		result := buildIterationProtocol(false)
@@ -316,7 +362,7 @@ func TestBuildTaskSection(t *testing.T) {
 		mock := &MockRunner{}
 
 		opts := Options{Mode: ModeSingleTask}
-		result := buildTaskSection(tmpDir, opts, mock)
+		result, _, _ := buildTaskSection(tmpDir, opts, mock)
 
 		if !strings.Contains(result, "No beads task graph found") {
 			t.Errorf("expected no beads message, got: %s", result)
@@ -337,7 +383,7 @@ func TestBuildTaskSection(t *testing.T) {
 		}
 
 		opts := Options{Mode: ModeSingleTask}
-		result := buildTaskSection(tmpDir, opts, mock)
+		result, _, _ := buildTaskSection(tmpDir, opts, mock)
 
 		if !strings.Contains(result, "Task 1: Important task") {
 			t.Errorf("expected task info, got: %s", result)
@@ -349,7 +395,7 @@ func TestBuildTaskSection(t *testing.T) {
 		mock := &MockRunner{}
 
 		opts := Options{Mode: ModeGoal, Goal: "Implement user authentication"}
-		result := buildTaskSection(tmpDir, opts, mock)
+		result, _, _ := buildTaskSection(tmpDir, opts, mock)
 
 		if !strings.Contains(result, "Implement user authentication") {
 			t.Errorf("expected goal text, got: %s", result)
@@ -373,7 +419,7 @@ func TestBuildTaskSection(t *testing.T) {
 		}
 
 		opts := Options{Mode: ModeAutopilot}
-		result := buildTaskSection(tmpDir, opts, mock)
+		result, _, _ := buildTaskSection(tmpDir, opts, mock)
 
 		if !strings.Contains(result, "task graph autonomously") {
 			t.Errorf("expected autopilot description, got: %s", result)
@@ -382,6 +428,52 @@ func TestBuildTaskSection(t *testing.T) {
 			t.Errorf("expected task overview, got: %s", result)
 		}
 	})
+
+	t.Run("returns the top open task", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, ".beads"), 0755)
+
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" && args[0] == "show" {
+					if args[1] == "bd-12" {
+						return "Status: closed", nil
+					}
+					return "Status: open", nil
+				}
+				if command == "bv" {
+					return "1. [P1] bd-12 Done already\n2. [P2] bd-13 Still open", nil
+				}
+				return "", nil
+			},
+		}
+
+		for _, mode := range []Mode{ModeSingleTask, ModeAutopilot} {
+			_, task, err := buildTaskSection(tmpDir, Options{Mode: mode}, mock)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if task != "bd-13" {
+				t.Errorf("mode %v: expected bd-13, got %q", mode, task)
+			}
+		}
+	})
+}
+
+func TestHistoryEntry(t *testing.T) {
+	t.Run("single task records top task", func(t *testing.T) {
+		entry := historyEntry(Options{Mode: ModeSingleTask}, "bd-42", "main")
+		if entry.Command != "ralph" || entry.Task != "bd-42" || entry.Mode != "single-task" || entry.Branch != "main" {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	})
+
+	t.Run("goal mode records goal", func(t *testing.T) {
+		entry := historyEntry(Options{Mode: ModeGoal, Goal: "Add auth"}, "", "feature/auth")
+		if entry.Goal != "Add auth" || entry.Mode != "goal" || entry.Task != "" {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	})
 }
 
 func TestBuildProjectContext(t *testing.T) {
@@ -526,21 +618,3 @@ func TestRun(t *testing.T) {
 		}
 	})
 }
-
-func TestFileExists(t *testing.T) {
-	t.Run("existing file", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		filePath := filepath.Join(tmpDir, "test.txt")
-		os.WriteFile(filePath, []byte("test"), 0644)
-
-		if !fileExists(filePath) {
-			t.Error("expected fileExists to return true for existing file")
-		}
-	})
-
-	t.Run("non-existing file", func(t *testing.T) {
-		if fileExists("/nonexistent/path/to/file.txt") {
-			t.Error("expected fileExists to return false for non-existing file")
-		}
-	})
-}
@@ -0,0 +1,342 @@
+// Package report summarizes recent work for end-of-day standups.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/emoji"
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/prompt"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// Options configures the report command behavior
+type Options struct {
+	Dir    string               // Target directory (defaults to cwd)
+	Since  string               // Start of the period: today, yesterday, a duration like 8h or 2d, or YYYY-MM-DD
+	Author string               // Commit author filter (defaults to git config user.email)
+	JSON   bool                 // Emit JSON instead of markdown
+	Width  int                  // Soft-wrap markdown to this many columns (0 = no wrapping)
+	Runner runner.CommandRunner // Command runner (defaults to runner.Default)
+}
+
+// Report is the collected summary. Sections whose tool is missing or failed are
+// nil (null in JSON) and the tool is listed in Unavailable. Unfiltered is set
+// when bd records no close date for some tasks, which TasksClosed then lists
+// however long ago they were closed.
+type Report struct {
+	Project     string    `json:"project"`
+	Since       time.Time `json:"since"`
+	Author      string    `json:"author,omitempty"`
+	Commits     []Commit  `json:"commits"`
+	Iterations  int       `json:"ralph_iterations"`
+	TasksClosed []Task    `json:"tasks_closed"`
+	Unfiltered  bool      `json:"tasks_closed_unfiltered,omitempty"`
+	PRsOpened   []PR      `json:"prs_opened"`
+	PRsMerged   []PR      `json:"prs_merged"`
+	Unavailable []string  `json:"unavailable,omitempty"`
+}
+
+// Commit is a commit authored in the period
+type Commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+}
+
+// Task is a beads task closed in the period
+type Task struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+}
+
+// PR is a pull request opened or merged in the period
+type PR struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	State     string     `json:"state"`
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"created_at"`
+	MergedAt  *time.Time `json:"merged_at,omitempty"`
+}
+
+// ghPR is a pull request as `gh pr list --json` reports it
+type ghPR struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	State     string     `json:"state"`
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"createdAt"`
+	MergedAt  *time.Time `json:"mergedAt"`
+}
+
+// Run executes the report command and writes the summary to stdout
+func Run(opts Options) error {
+	dir := opts.Dir
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getting current directory: %w", err)
+		}
+		dir = cwd
+	}
+
+	r := opts.Runner
+	if r == nil {
+		r = &runner.Default{}
+	}
+	r = git.NewCache(r)
+
+	since, err := ParseSince(opts.Since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rep := Collect(dir, since, opts.Author, r)
+
+	if opts.JSON {
+		out, err := json.MarshalIndent(rep, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding report: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Print(emoji.Render(prompt.Wrap(Markdown(rep), opts.Width)))
+	return nil
+}
+
+// ParseSince resolves the start of the reporting period relative to now.
+// Accepts "today" (the default), "yesterday", durations such as "8h" or "2d",
+// and dates in YYYY-MM-DD form.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	s = strings.TrimSpace(s)
+	switch s {
+	case "", "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since %q (expected today, yesterday, a duration like 8h or 2d, or YYYY-MM-DD)", s)
+}
+
+// Collect gathers the report sections. Tools that are missing or fail are
+// recorded in Unavailable rather than aborting the report.
+func Collect(dir string, since time.Time, author string, r runner.CommandRunner) Report {
+	rep := Report{
+		Project: filepath.Base(dir),
+		Since:   since,
+		Author:  author,
+	}
+
+	if rep.Author == "" {
+		if email, err := r.Run(dir, "git", "config", "user.email"); err == nil {
+			rep.Author = strings.TrimSpace(email)
+		}
+	}
+
+	if commits, err := getCommits(dir, since, rep.Author, r); err == nil {
+		rep.Commits = commits
+		rep.Iterations = countIterations(commits)
+	} else {
+		slog.Info("omitting commits from report", "err", err)
+		rep.Unavailable = append(rep.Unavailable, "git")
+	}
+
+	if beads.IsInitialized(dir) {
+		if tasks, unfiltered, err := getClosedTasks(dir, since, r); err == nil {
+			rep.TasksClosed, rep.Unfiltered = tasks, unfiltered
+		} else {
+			slog.Info("omitting closed tasks from report", "err", err)
+			rep.Unavailable = append(rep.Unavailable, "bd")
+		}
+	}
+
+	if opened, merged, err := getPRs(dir, since, r); err == nil {
+		rep.PRsOpened, rep.PRsMerged = opened, merged
+	} else {
+		slog.Info("omitting pull requests from report", "err", err)
+		rep.Unavailable = append(rep.Unavailable, "gh")
+	}
+
+	return rep
+}
+
+// getCommits lists commits on any branch by author since the given time
+func getCommits(dir string, since time.Time, author string, r runner.CommandRunner) ([]Commit, error) {
+	args := []string{"log", "--all", "--no-merges", "--since=" + since.Format(time.RFC3339), "--format=%h %s"}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+
+	output, err := r.Run(dir, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []Commit{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		hash, subject, _ := strings.Cut(line, " ")
+		commits = append(commits, Commit{Hash: hash, Subject: subject})
+	}
+	return commits, nil
+}
+
+// countIterations counts ralph checkpoint commits ("ralph: iteration N - ...")
+func countIterations(commits []Commit) int {
+	n := 0
+	for _, c := range commits {
+		if strings.HasPrefix(c.Subject, "ralph: iteration") {
+			n++
+		}
+	}
+	return n
+}
+
+// getClosedTasks lists closed beads tasks. When bd records a close date only
+// tasks closed in the period are kept; tasks without one are kept regardless,
+// which unfiltered reports.
+func getClosedTasks(dir string, since time.Time, r runner.CommandRunner) (tasks []Task, unfiltered bool, err error) {
+	output, err := r.RunWithTimeout(dir, 10*time.Second, "bd", "list", "--status", "closed", "--json")
+	if err != nil {
+		return nil, false, err
+	}
+
+	var all []Task
+	if err := json.Unmarshal([]byte(output), &all); err != nil {
+		return nil, false, fmt.Errorf("parsing bd list: %w", err)
+	}
+
+	tasks = []Task{}
+	for _, t := range all {
+		if t.ClosedAt == nil {
+			unfiltered = true
+		} else if t.ClosedAt.Before(since) {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, unfiltered, nil
+}
+
+// getPRs lists the user's pull requests opened and merged in the period
+func getPRs(dir string, since time.Time, r runner.CommandRunner) (opened, merged []PR, err error) {
+	output, err := r.RunWithTimeout(dir, 15*time.Second, "gh", "pr", "list", "--author", "@me", "--state", "all",
+		"--json", "number,title,state,url,createdAt,mergedAt", "--limit", "100")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listed []ghPR
+	if err := json.Unmarshal([]byte(output), &listed); err != nil {
+		return nil, nil, fmt.Errorf("parsing gh pr list: %w", err)
+	}
+
+	opened, merged = []PR{}, []PR{}
+	for _, p := range listed {
+		pr := PR(p)
+		if !pr.CreatedAt.Before(since) {
+			opened = append(opened, pr)
+		}
+		if pr.MergedAt != nil && !pr.MergedAt.Before(since) {
+			merged = append(merged, pr)
+		}
+	}
+	return opened, merged, nil
+}
+
+// Markdown renders the report as a standup-style summary
+func Markdown(rep Report) string {
+	doc := prompt.New(fmt.Sprintf("Work Report for %s", rep.Project))
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("- **Since**: %s\n", rep.Since.Format("2006-01-02 15:04")))
+	if rep.Author != "" {
+		summary.WriteString(fmt.Sprintf("- **Author**: %s\n", rep.Author))
+	}
+	if rep.Commits != nil {
+		summary.WriteString(fmt.Sprintf("- **Commits**: %d\n", len(rep.Commits)))
+		if rep.Iterations > 0 {
+			summary.WriteString(fmt.Sprintf("- **Ralph iterations**: %d\n", rep.Iterations))
+		}
+	}
+	if rep.TasksClosed != nil {
+		if rep.Unfiltered {
+			summary.WriteString(fmt.Sprintf("- **Tasks closed**: %d (not limited to this period)\n", len(rep.TasksClosed)))
+		} else {
+			summary.WriteString(fmt.Sprintf("- **Tasks closed**: %d\n", len(rep.TasksClosed)))
+		}
+	}
+	if rep.PRsOpened != nil {
+		summary.WriteString(fmt.Sprintf("- **PRs**: %d opened, %d merged\n", len(rep.PRsOpened), len(rep.PRsMerged)))
+	}
+	summary.WriteString("\n")
+	doc.Add(prompt.Summary, "Summary", summary.String())
+
+	if rep.Commits != nil {
+		var out strings.Builder
+		for _, c := range rep.Commits {
+			out.WriteString(fmt.Sprintf("- `%s` %s\n", c.Hash, c.Subject))
+		}
+		doc.Add(prompt.Summary, "Commits", orNone(out.String()))
+	}
+
+	if rep.TasksClosed != nil {
+		var out strings.Builder
+		if rep.Unfiltered && len(rep.TasksClosed) > 0 {
+			out.WriteString("_bd records no close date for some tasks, so they are listed whenever they were closed_\n\n")
+		}
+		for _, t := range rep.TasksClosed {
+			out.WriteString(fmt.Sprintf("- %s \"%s\"\n", t.ID, t.Title))
+		}
+		doc.Add(prompt.Summary, "Tasks Closed", orNone(out.String()))
+	}
+
+	if rep.PRsOpened != nil {
+		var out strings.Builder
+		for _, pr := range rep.PRsOpened {
+			out.WriteString(fmt.Sprintf("- Opened #%d %s (%s)\n", pr.Number, pr.Title, strings.ToLower(pr.State)))
+		}
+		for _, pr := range rep.PRsMerged {
+			out.WriteString(fmt.Sprintf("- Merged #%d %s\n", pr.Number, pr.Title))
+		}
+		doc.Add(prompt.Summary, "Pull Requests", orNone(out.String()))
+	}
+
+	return doc.Markdown()
+}
+
+// orNone terminates a list section, noting when it is empty
+func orNone(list string) string {
+	if list == "" {
+		return "None\n\n"
+	}
+	return list + "\n"
+}
@@ -0,0 +1,199 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MockRunner is a mock implementation of runner.CommandRunner for testing
+type MockRunner struct {
+	RunFunc            func(dir string, command string, args ...string) (string, error)
+	RunWithTimeoutFunc func(dir string, timeout time.Duration, command string, args ...string) (string, error)
+}
+
+func (m *MockRunner) Run(dir string, command string, args ...string) (string, error) {
+	if m.RunFunc != nil {
+		return m.RunFunc(dir, command, args...)
+	}
+	return "", nil
+}
+
+func (m *MockRunner) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+	if m.RunWithTimeoutFunc != nil {
+		return m.RunWithTimeoutFunc(dir, timeout, command, args...)
+	}
+	return "", nil
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"today", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"yesterday", time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"8h", time.Date(2026, 3, 10, 7, 30, 0, 0, time.UTC)},
+		{"2d", time.Date(2026, 3, 8, 15, 30, 0, 0, time.UTC)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSince(tt.input, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseSince(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := ParseSince("last week", now); err == nil {
+			t.Error("expected error for unrecognized value")
+		}
+	})
+}
+
+func TestCollect(t *testing.T) {
+	since := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("gathers all sections", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.Mkdir(filepath.Join(dir, ".beads"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		var logArgs []string
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				switch args[0] {
+				case "config":
+					return "dev@example.com\n", nil
+				case "log":
+					logArgs = args
+					return "abc1234 ralph: iteration 1 - add model\ndef5678 Fix parser\n", nil
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				switch command {
+				case "bd":
+					return `[{"id":"bd-1","title":"Old","closed_at":"2026-03-01T10:00:00Z"},{"id":"bd-2","title":"New","closed_at":"2026-03-10T11:00:00Z"}]`, nil
+				case "gh":
+					return `[{"number":7,"title":"Add model","state":"MERGED","createdAt":"2026-03-10T09:00:00Z","mergedAt":"2026-03-10T12:00:00Z"},` +
+						`{"number":5,"title":"Older","state":"MERGED","createdAt":"2026-03-02T09:00:00Z","mergedAt":"2026-03-10T08:00:00Z"},` +
+						`{"number":3,"title":"Stale","state":"OPEN","createdAt":"2026-02-01T09:00:00Z"}]`, nil
+				}
+				return "", nil
+			},
+		}
+
+		rep := Collect(dir, since, "", mock)
+
+		if rep.Author != "dev@example.com" {
+			t.Errorf("expected author from git config, got %q", rep.Author)
+		}
+		if !strings.Contains(strings.Join(logArgs, " "), "--author=dev@example.com") {
+			t.Errorf("expected git log filtered by author, got %v", logArgs)
+		}
+		if len(rep.Commits) != 2 || rep.Commits[1].Subject != "Fix parser" {
+			t.Errorf("unexpected commits: %+v", rep.Commits)
+		}
+		if rep.Iterations != 1 {
+			t.Errorf("expected 1 ralph iteration, got %d", rep.Iterations)
+		}
+		if len(rep.TasksClosed) != 1 || rep.TasksClosed[0].ID != "bd-2" {
+			t.Errorf("expected only bd-2 closed in period, got %+v", rep.TasksClosed)
+		}
+		if len(rep.PRsOpened) != 1 || rep.PRsOpened[0].Number != 7 {
+			t.Errorf("expected PR #7 opened, got %+v", rep.PRsOpened)
+		}
+		if len(rep.PRsMerged) != 2 {
+			t.Errorf("expected 2 PRs merged, got %+v", rep.PRsMerged)
+		}
+		if len(rep.Unavailable) != 0 {
+			t.Errorf("expected no unavailable tools, got %v", rep.Unavailable)
+		}
+		if rep.Unfiltered {
+			t.Error("expected closed tasks filtered by close date")
+		}
+
+		data, err := json.Marshal(rep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"created_at"`) || !strings.Contains(string(data), `"merged_at"`) {
+			t.Errorf("expected snake_case PR keys, got %s", data)
+		}
+		if strings.Contains(string(data), `"createdAt"`) {
+			t.Errorf("expected no camelCase PR keys, got %s", data)
+		}
+	})
+
+	t.Run("closed tasks without close dates are flagged", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.Mkdir(filepath.Join(dir, ".beads"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" {
+					return `[{"id":"bd-1","title":"Ancient"},{"id":"bd-2","title":"New","closed_at":"2026-03-10T11:00:00Z"}]`, nil
+				}
+				return "[]", nil
+			},
+		}
+
+		rep := Collect(dir, since, "dev@example.com", mock)
+
+		if len(rep.TasksClosed) != 2 || !rep.Unfiltered {
+			t.Errorf("expected both tasks kept and flagged unfiltered, got %+v (unfiltered=%v)", rep.TasksClosed, rep.Unfiltered)
+		}
+
+		md := Markdown(rep)
+		if !strings.Contains(md, "**Tasks closed**: 2 (not limited to this period)") {
+			t.Errorf("expected summary to say the count is unfiltered, got:\n%s", md)
+		}
+		if !strings.Contains(md, "bd records no close date") {
+			t.Errorf("expected tasks section to explain the missing filter, got:\n%s", md)
+		}
+	})
+
+	t.Run("missing tools omit sections", func(t *testing.T) {
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				return "", errors.New("executable file not found")
+			},
+		}
+
+		rep := Collect(t.TempDir(), since, "dev@example.com", mock)
+
+		if rep.Commits == nil {
+			t.Error("expected commits section to be present")
+		}
+		if rep.TasksClosed != nil || rep.PRsOpened != nil {
+			t.Error("expected beads and PR sections to be omitted")
+		}
+		if strings.Join(rep.Unavailable, ",") != "gh" {
+			t.Errorf("expected gh unavailable, got %v", rep.Unavailable)
+		}
+
+		md := Markdown(rep)
+		if !strings.Contains(md, "## Commits\nNone") {
+			t.Errorf("expected empty commits section, got:\n%s", md)
+		}
+		if strings.Contains(md, "Pull Requests") || strings.Contains(md, "Tasks Closed") {
+			t.Errorf("expected omitted sections, got:\n%s", md)
+		}
+	})
+}
@@ -1,24 +1,46 @@
 package resume
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/project"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/runner"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 // Options configures the resume command behavior
 type Options struct {
-	Dir     string               // Target directory (defaults to cwd)
-	Verbose bool                 // Include full protocol details
-	NoFetch bool                 // Skip fetching from remote
-	Runner  runner.CommandRunner // Command runner (defaults to runner.Default)
+	Dir            string               // Target directory (defaults to cwd)
+	Verbose        bool                 // Include full protocol details
+	NoFetch        bool                 // Skip fetching from remote
+	StaleAfter     time.Duration        // Flag the task if in progress and untouched this long (0 = beads.DefaultStaleAfter)
+	LastTest       bool                 // Show the last test result, running the tests if none is cached
+	TestCommand    string               // Test command override for LastTest (defaults to auto-detection)
+	Ecosystem      project.Ecosystem    // Only use this ecosystem's test command (defaults to all detected)
+	Task           string               // Use this bead instead of detecting the current task
+	ProjectKey     string               // Agent Mail project key for MCP snippets (defaults to the directory name)
+	ReservationTTL int                  // Seconds in the file reservation snippet (0 = prompt.DefaultReservationTTL)
+	TemplateVars   map[string]string    // Custom variables for protocol templates
+	Output         prompt.Output        // Output rendering (e.g. context only)
+	Tracker        *beads.IssueTracker  // External issue tracker for branches without a bead ID (nil = Beads only)
+	Runner         runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
+// maxTestLines caps the failing test output shown in Test Status
+const maxTestLines = 30
+
+// Sections lists every section this command can produce, in output order.
+var Sections = []string{"Current Work", "Work in Progress", "Test Status", "Recent Commits", "Pending Attention", "Protocol"}
+
 // Run executes the resume command and returns the prompt to stdout
 func Run(opts Options) error {
 	dir := opts.Dir
@@ -34,22 +56,38 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
-
-	var out strings.Builder
+	r = git.NewCache(r)
 
 	// Header
 	projectName := filepath.Base(dir)
-	out.WriteString(fmt.Sprintf("# Resume Work in %s\n\n", projectName))
+	doc := prompt.New(fmt.Sprintf("Resume Work in %s", projectName), Sections...)
 
 	// Get current branch and task context
 	branch := git.GetCurrentBranch(dir, r)
-	task := beads.DetectCurrentTask(dir, branch, r)
+	var task beads.TaskInfo
+	if opts.Task != "" {
+		var err error
+		if task, err = beads.GetTask(dir, opts.Task, branch, r); err != nil {
+			return err
+		}
+	} else {
+		task = beads.DetectCurrentTask(dir, branch, opts.Tracker, r)
+	}
 	task.ProjectName = projectName
+	if opts.ProjectKey != "" {
+		task.ProjectName = opts.ProjectKey
+	}
+	doc.Task = task.ID
+
+	// Tasks found by bd list haven't been shown yet, so their update time is unknown
+	if task.Status == "in_progress" && task.Updated.IsZero() && opts.Task == "" {
+		task.Updated, _ = beads.LastUpdated(dir, task.ID, r)
+	}
 
 	// Current work section
-	out.WriteString("## Current Work\n")
+	var current strings.Builder
 	if branch != "" {
-		out.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
+		current.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
 	}
 	if task.ID != "" {
 		if task.Title != "" {
@@ -57,61 +95,118 @@ func Run(opts Options) error {
 			if task.Status != "" {
 				statusStr = fmt.Sprintf(" [%s]", task.Status)
 			}
-			out.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"%s\n", task.ID, task.Title, statusStr))
+			current.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"%s\n", task.ID, task.Title, statusStr))
 		} else {
-			out.WriteString(fmt.Sprintf("- **Task**: %s\n", task.ID))
+			current.WriteString(fmt.Sprintf("- **Task**: %s\n", task.Ref()))
 		}
 	}
-	out.WriteString("\n")
+	current.WriteString("\n")
+	doc.Add(prompt.Summary, "Current Work", current.String())
 
 	// Work in progress section
-	out.WriteString("## Work in Progress\n")
+	var wip strings.Builder
 
 	// Uncommitted changes
 	uncommitted := git.GetWorkingTreeStatus(dir, r)
 	if uncommitted != "" {
-		out.WriteString(fmt.Sprintf("- **Uncommitted changes**: %s\n", uncommitted))
+		wip.WriteString(fmt.Sprintf("- **Uncommitted changes**: %s\n", uncommitted))
 	} else {
-		out.WriteString("- **Uncommitted changes**: None (working tree clean)\n")
+		wip.WriteString("- **Uncommitted changes**: None (working tree clean)\n")
 	}
 
 	// Recent commits on branch
 	commits := git.GetBranchCommits(dir, branch, r)
 	if commits != "" {
 		commitCount := git.CountLines(commits)
-		out.WriteString(fmt.Sprintf("- **Commits on branch**: %d\n", commitCount))
+		wip.WriteString(fmt.Sprintf("- **Commits on branch**: %d\n", commitCount))
+	}
+	wip.WriteString("\n")
+	doc.Add(prompt.Summary, "Work in Progress", wip.String())
+
+	if opts.LastTest {
+		doc.Add(prompt.Summary, "Test Status", getTestStatus(dir, opts.TestCommand, opts.Ecosystem, r))
 	}
-	out.WriteString("\n")
 
 	// Show recent commits
 	if commits != "" {
-		out.WriteString("## Recent Commits\n")
-		out.WriteString("```\n")
-		out.WriteString(commits)
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Recent Commits", "```\n"+commits+"\n```\n\n")
 	}
 
 	// Pending attention section
-	pendingItems := getPendingItems(dir, task, r, !opts.NoFetch)
+	pendingItems := getPendingItems(dir, task, r, !opts.NoFetch, opts.StaleAfter)
 	if len(pendingItems) > 0 {
-		out.WriteString("## Pending Attention\n")
+		var pending strings.Builder
 		for _, item := range pendingItems {
-			out.WriteString(fmt.Sprintf("- %s\n", item))
+			pending.WriteString(fmt.Sprintf("- %s\n", item))
 		}
-		out.WriteString("\n")
+		pending.WriteString("\n")
+		doc.Add(prompt.Summary, "Pending Attention", pending.String())
 	}
 
 	// Protocol
-	out.WriteString("## Protocol\n")
-	out.WriteString(getProtocol(task, opts.Verbose))
+	vars := templates.Merge(templates.Builtins(task.ID, projectName, branch), opts.TemplateVars)
+	protocol, err := templates.Protocol(dir, "resume", getProtocol(task, opts.Verbose, opts.ReservationTTL), vars)
+	if err != nil {
+		return err
+	}
+	doc.Add(prompt.Protocol, "Protocol", protocol)
+
+	return prompt.Emit(dir, doc, opts.Output, r)
+}
+
+// getTestStatus reports the last test result saved by `done --verify`, or
+// runs the test command when nothing is cached or the cached result is for
+// a different command.
+func getTestStatus(dir string, testCmd string, ecosystem project.Ecosystem, r runner.CommandRunner) string {
+	testCmd = project.ResolveTestCommand(dir, testCmd, ecosystem)
 
-	fmt.Print(out.String())
-	return nil
+	cached, err := project.LoadResult(dir, r)
+	switch {
+	case err == nil && testCmd != "" && cached.Command != testCmd:
+		slog.Info("ignoring cached test result", "cached", cached.Command, "command", testCmd)
+	case err == nil:
+		source := fmt.Sprintf("_From `%s` at %s", cached.Source, cached.Time.Local().Format("2006-01-02 15:04"))
+		if reason := cached.StaleReason(dir, r); reason != "" {
+			source += fmt.Sprintf(" on %s; %s, so this may be out of date", cached.ShortCommit(), reason)
+		}
+		return formatTestStatus(cached.VerifyResult, source+"_")
+	case !errors.Is(err, os.ErrNotExist):
+		slog.Warn("ignoring cached test result", "err", err)
+	}
+
+	if testCmd == "" {
+		return "⚠️ No test command detected and no cached result from `vibes done --verify`\n\n"
+	}
+	result := project.Verify(dir, testCmd, r)
+	if err := project.SaveResult(dir, result, "vibes resume --last-test", r); err != nil {
+		slog.Warn("could not cache test result", "err", err)
+	}
+	return formatTestStatus(result, "_Ran just now_")
+}
+
+// formatTestStatus renders a test result with a note on where it came from.
+func formatTestStatus(result project.VerifyResult, source string) string {
+	var out strings.Builder
+	if result.Passed {
+		out.WriteString(fmt.Sprintf("✅ Tests pass: `%s`\n", result.Command))
+	} else {
+		out.WriteString(fmt.Sprintf("❌ Tests failed: `%s`\n", result.Command))
+		if result.Output != "" {
+			out.WriteString("```\n" + prompt.TailLines(result.Output, maxTestLines) + "\n```\n")
+		}
+	}
+	out.WriteString(source + "\n\n")
+	return out.String()
 }
 
-func getPendingItems(dir string, task beads.TaskInfo, r runner.CommandRunner, fetch bool) []string {
+func getPendingItems(dir string, task beads.TaskInfo, r runner.CommandRunner, fetch bool, staleAfter time.Duration) []string {
 	var items []string
 
+	// Check for an unfinished merge, rebase, or bisect
+	if warning := git.InProgressWarning(git.GetInProgressOperation(dir, r)); warning != "" {
+		items = append(items, warning)
+	}
+
 	// Check for stashed changes
 	stashCount := git.GetStashCount(dir, r)
 	if stashCount > 0 {
@@ -120,12 +215,21 @@ func getPendingItems(dir string, task beads.TaskInfo, r runner.CommandRunner, fe
 
 	// Check if branch is behind remote
 	remoteStatus := git.CheckRemoteStatus(dir, r, fetch)
-	if remoteStatus.Behind > 0 {
+	if remoteStatus.NoRemote {
+		items = append(items, "ℹ️ No remote configured - skipping fetch and ahead/behind checks")
+	} else if remoteStatus.Behind > 0 {
 		items = append(items, fmt.Sprintf("⚠️ Branch is %s - consider pulling", remoteStatus.Info))
 	} else if remoteStatus.Ahead > 0 {
 		items = append(items, fmt.Sprintf("📤 Branch is %s - remember to push", remoteStatus.Info))
 	}
 
+	// Flag a task that has sat in progress for days
+	if task.Status == "in_progress" && !task.Updated.IsZero() {
+		if warning := beads.StaleWarning(task.ID, task.Updated, time.Now(), staleAfter); warning != "" {
+			items = append(items, warning)
+		}
+	}
+
 	// Hint about checking inbox if task has a review thread
 	if task.ID != "" {
 		items = append(items, fmt.Sprintf("💬 Check inbox for messages in %s-review thread", task.ID))
@@ -134,7 +238,7 @@ func getPendingItems(dir string, task beads.TaskInfo, r runner.CommandRunner, fe
 	return items
 }
 
-func getProtocol(task beads.TaskInfo, verbose bool) string {
+func getProtocol(task beads.TaskInfo, verbose bool, ttl int) string {
 	taskID := task.ID
 	if taskID == "" {
 		taskID = "<task-id>"
@@ -163,7 +267,7 @@ func getProtocol(task beads.TaskInfo, verbose bool) string {
        project_key="%s",
        agent_name="YourAgentIdentity",
        patterns=["<your-file-patterns>"],
-       ttl_seconds=3600,
+       ttl_seconds=%d,
        exclusive=true
    )
    `+"```"+`
@@ -176,7 +280,7 @@ func getProtocol(task beads.TaskInfo, verbose bool) string {
    `+"```"+`
 
 Continue working on the current task.
-`, taskID, projectKey)
+`, taskID, projectKey, prompt.ReservationTTL(ttl))
 	}
 
 	return `1. Check inbox for pending messages or review feedback
@@ -1,12 +1,17 @@
 package resume
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/project"
+	"github.com/vibes-project/vibes/internal/prompt"
 )
 
 // MockRunner is a mock implementation of runner.CommandRunner for testing
@@ -41,7 +46,7 @@ func TestGetPendingItems(t *testing.T) {
 		}
 
 		task := beads.TaskInfo{ID: "bd-123"}
-		items := getPendingItems("/test/dir", task, mock, false)
+		items := getPendingItems("/test/dir", task, mock, false, 0)
 
 		hasStashWarning := false
 		for _, item := range items {
@@ -55,6 +60,21 @@ func TestGetPendingItems(t *testing.T) {
 		}
 	})
 
+	t.Run("flags a stale in-progress task", func(t *testing.T) {
+		stale := beads.TaskInfo{ID: "bd-12", Status: "in_progress", Updated: time.Now().Add(-6 * 24 * time.Hour)}
+		items := getPendingItems("/test/dir", stale, &MockRunner{}, false, 0)
+		if !strings.Contains(strings.Join(items, "\n"), "bd-12 has been in progress for 6 days") {
+			t.Errorf("expected staleness warning, got %v", items)
+		}
+
+		fresh := stale
+		fresh.Updated = time.Now().Add(-time.Hour)
+		items = getPendingItems("/test/dir", fresh, &MockRunner{}, false, 0)
+		if strings.Contains(strings.Join(items, "\n"), "in progress for") {
+			t.Errorf("expected no warning for a fresh task, got %v", items)
+		}
+	})
+
 	t.Run("includes inbox hint when task has ID", func(t *testing.T) {
 		mock := &MockRunner{
 			RunFunc: func(dir string, command string, args ...string) (string, error) {
@@ -63,7 +83,7 @@ func TestGetPendingItems(t *testing.T) {
 		}
 
 		task := beads.TaskInfo{ID: "bd-123"}
-		items := getPendingItems("/test/dir", task, mock, false)
+		items := getPendingItems("/test/dir", task, mock, false, 0)
 
 		hasInboxHint := false
 		for _, item := range items {
@@ -80,6 +100,9 @@ func TestGetPendingItems(t *testing.T) {
 	t.Run("detects behind remote", func(t *testing.T) {
 		mock := &MockRunner{
 			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && len(args) >= 1 && args[0] == "remote" {
+					return "origin", nil
+				}
 				if command == "git" && len(args) >= 1 && args[0] == "status" {
 					return "## feature/test...origin/feature/test [behind 3]", nil
 				}
@@ -88,7 +111,7 @@ func TestGetPendingItems(t *testing.T) {
 		}
 
 		task := beads.TaskInfo{}
-		items := getPendingItems("/test/dir", task, mock, false)
+		items := getPendingItems("/test/dir", task, mock, false, 0)
 
 		hasBehindWarning := false
 		for _, item := range items {
@@ -102,9 +125,27 @@ func TestGetPendingItems(t *testing.T) {
 		}
 	})
 
+	t.Run("notes missing remote", func(t *testing.T) {
+		items := getPendingItems("/test/dir", beads.TaskInfo{}, &MockRunner{}, true, 0)
+
+		hasNote := false
+		for _, item := range items {
+			if strings.Contains(item, "No remote configured") {
+				hasNote = true
+				break
+			}
+		}
+		if !hasNote {
+			t.Errorf("expected no-remote note in pending items, got %v", items)
+		}
+	})
+
 	t.Run("detects ahead of remote", func(t *testing.T) {
 		mock := &MockRunner{
 			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && len(args) >= 1 && args[0] == "remote" {
+					return "origin", nil
+				}
 				if command == "git" && len(args) >= 1 && args[0] == "status" {
 					return "## feature/test...origin/feature/test [ahead 2]", nil
 				}
@@ -113,7 +154,7 @@ func TestGetPendingItems(t *testing.T) {
 		}
 
 		task := beads.TaskInfo{}
-		items := getPendingItems("/test/dir", task, mock, false)
+		items := getPendingItems("/test/dir", task, mock, false, 0)
 
 		hasAheadNotice := false
 		for _, item := range items {
@@ -132,7 +173,7 @@ func TestGetProtocol(t *testing.T) {
 	task := beads.TaskInfo{ID: "bd-123", Title: "Test task", Branch: "feature/test", ProjectName: "my-project"}
 
 	t.Run("non-verbose protocol", func(t *testing.T) {
-		result := getProtocol(task, false)
+		result := getProtocol(task, false, 0)
 
 		if !strings.Contains(result, "vibes done") {
 			t.Error("expected vibes done reference")
@@ -143,7 +184,7 @@ func TestGetProtocol(t *testing.T) {
 	})
 
 	t.Run("verbose protocol", func(t *testing.T) {
-		result := getProtocol(task, true)
+		result := getProtocol(task, true, 0)
 
 		if !strings.Contains(result, "**Check for updates**") {
 			t.Error("expected bold headers in verbose mode")
@@ -167,15 +208,57 @@ func TestGetProtocol(t *testing.T) {
 
 	t.Run("uses placeholder when no task ID", func(t *testing.T) {
 		emptyTask := beads.TaskInfo{}
-		result := getProtocol(emptyTask, true)
+		result := getProtocol(emptyTask, true, 0)
 
 		if !strings.Contains(result, "<task-id>") {
 			t.Error("expected placeholder when no task ID")
 		}
 	})
+
+	t.Run("reservation TTL", func(t *testing.T) {
+		if result := getProtocol(task, true, 0); !strings.Contains(result, "ttl_seconds=3600,") {
+			t.Errorf("expected default TTL, got:\n%s", result)
+		}
+		if result := getProtocol(task, true, 7200); !strings.Contains(result, "ttl_seconds=7200,") {
+			t.Errorf("expected TTL override, got:\n%s", result)
+		}
+	})
 }
 
 func TestRun(t *testing.T) {
+	t.Run("task override", func(t *testing.T) {
+		var shown []string
+		listed := false
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "bd" && args[0] == "list" {
+					listed = true
+					return "bd-1  Other task  [in_progress]", nil
+				}
+				if command == "bd" && args[0] == "show" {
+					shown = append(shown, args[1])
+					if args[1] == "bd-7" {
+						return "Title: Chosen task\nStatus: in_progress", nil
+					}
+					return "", errors.New("no issue found")
+				}
+				return "", nil
+			},
+		}
+
+		if err := Run(Options{Dir: t.TempDir(), Task: "bd-7", Runner: mock}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if listed || len(shown) != 1 || shown[0] != "bd-7" {
+			t.Errorf("expected only bd show bd-7, got listed=%v shown=%v", listed, shown)
+		}
+
+		err := Run(Options{Dir: t.TempDir(), Task: "bd-404", Runner: mock})
+		if err == nil || !strings.Contains(err.Error(), "bd-404 not found") {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
 	t.Run("with specified directory", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -292,3 +375,118 @@ func TestSharedPackageIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestGetTestStatus(t *testing.T) {
+	// testMock reports head as HEAD and records test runs
+	testMock := func(head string, ran *[]string) *MockRunner {
+		return &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if args[0] == "rev-parse" && args[1] == "HEAD" {
+					return head, nil
+				}
+				return "", errors.New("not a git repository")
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				*ran = append(*ran, args[len(args)-1])
+				return "ok", nil
+			},
+		}
+	}
+
+	t.Run("prefers the cached result", func(t *testing.T) {
+		dir := t.TempDir()
+		var ran []string
+		failed := project.VerifyResult{Command: "make check", Output: "FAIL: TestLogin"}
+		if err := project.SaveResult(dir, failed, "vibes done --verify", testMock("abc123", &ran)); err != nil {
+			t.Fatal(err)
+		}
+
+		status := getTestStatus(dir, "make check", "", testMock("abc123", &ran))
+		if len(ran) != 0 {
+			t.Errorf("expected no test run, got %v", ran)
+		}
+		if !strings.Contains(status, "❌ Tests failed: `make check`") || !strings.Contains(status, "FAIL: TestLogin") {
+			t.Errorf("expected cached failure, got:\n%s", status)
+		}
+		if strings.Contains(status, "out of date") {
+			t.Errorf("expected no staleness note at the same HEAD, got:\n%s", status)
+		}
+
+		status = getTestStatus(dir, "make check", "", testMock("def456", &ran))
+		if !strings.Contains(status, "HEAD has moved since") {
+			t.Errorf("expected staleness note, got:\n%s", status)
+		}
+	})
+
+	t.Run("reruns when the cached result is for another command", func(t *testing.T) {
+		dir := t.TempDir()
+		var ran []string
+		mock := testMock("abc123", &ran)
+		if err := project.SaveResult(dir, project.VerifyResult{Command: "npm test"}, "vibes done --verify", mock); err != nil {
+			t.Fatal(err)
+		}
+
+		status := getTestStatus(dir, "make check", "", mock)
+		if len(ran) != 1 || ran[0] != "make check" {
+			t.Errorf("expected one test run, got %v", ran)
+		}
+		if !strings.Contains(status, "✅ Tests pass: `make check`") || !strings.Contains(status, "Ran just now") {
+			t.Errorf("expected fresh pass, got:\n%s", status)
+		}
+	})
+
+	t.Run("runs and caches the tests without a cached result", func(t *testing.T) {
+		dir := t.TempDir()
+		var ran []string
+		mock := testMock("abc123", &ran)
+
+		status := getTestStatus(dir, "make check", "", mock)
+		if len(ran) != 1 || ran[0] != "make check" {
+			t.Errorf("expected one test run, got %v", ran)
+		}
+		if !strings.Contains(status, "✅ Tests pass: `make check`") || !strings.Contains(status, "Ran just now") {
+			t.Errorf("expected fresh pass, got:\n%s", status)
+		}
+		if cached, err := project.LoadResult(dir, mock); err != nil || !cached.Passed {
+			t.Errorf("expected result cached, got %+v (err %v)", cached, err)
+		}
+
+		status = getTestStatus(dir, "make check", "", mock)
+		if len(ran) != 1 || !strings.Contains(status, "From `vibes resume --last-test`") {
+			t.Errorf("expected the cached run labelled with resume, got %v:\n%s", ran, status)
+		}
+	})
+
+	t.Run("no test command", func(t *testing.T) {
+		var ran []string
+		status := getTestStatus(t.TempDir(), "", "", testMock("abc123", &ran))
+		if !strings.Contains(status, "No test command detected") || len(ran) != 0 {
+			t.Errorf("expected no-command note, got:\n%s", status)
+		}
+	})
+}
+
+func TestRunProjectKey(t *testing.T) {
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			if command == "git" && len(args) >= 2 && args[0] == "rev-parse" && args[1] == "--abbrev-ref" {
+				return "feature/bd-123-test", nil
+			}
+			return "", nil
+		},
+	}
+
+	out := t.TempDir()
+	opts := Options{Dir: t.TempDir(), Verbose: true, ProjectKey: "acme-api", Output: prompt.Output{OutputDir: out}, Runner: mock}
+	if err := Run(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	system, err := os.ReadFile(filepath.Join(out, prompt.SystemFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(system), `project_key="acme-api"`) {
+		t.Errorf("expected project key override in MCP snippets, got:\n%s", system)
+	}
+}
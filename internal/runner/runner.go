@@ -3,6 +3,10 @@ package runner
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -14,28 +18,99 @@ type CommandRunner interface {
 	RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error)
 }
 
+// ErrTimeout is wrapped by errors from RunWithTimeout when the command hits its deadline.
+var ErrTimeout = errors.New("command timed out")
+
+// ErrToolMissing is wrapped by errors about a required tool that isn't installed
+var ErrToolMissing = errors.New("not installed")
+
+// ErrAuthFailed is wrapped by errors about a tool that isn't logged in
+var ErrAuthFailed = errors.New("authentication failed")
+
+// CommandError is returned when a command exits unsuccessfully.
+// It carries the combined stdout and stderr so callers can explain the failure.
+type CommandError struct {
+	Err    error
+	Output string
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorOutput returns the captured output of a failed command, or empty string
+// if err does not carry any.
+func ErrorOutput(err error) string {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Output
+	}
+	return ""
+}
+
+// IsTimeout reports whether err was caused by a command exceeding its timeout.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsNotFound reports whether err was caused by the command not being installed.
+func IsNotFound(err error) bool {
+	return errors.Is(err, exec.ErrNotFound)
+}
+
+// Require runs a cheap probe such as `gh auth status` and returns an error
+// explaining why the tool is unusable: not installed, or the probe failed.
+func Require(dir string, r CommandRunner, tool string, args ...string) error {
+	_, err := r.RunWithTimeout(dir, 10*time.Second, tool, args...)
+	switch {
+	case err == nil:
+		return nil
+	case IsNotFound(err):
+		return fmt.Errorf("%s is %w", tool, ErrToolMissing)
+	}
+
+	probe := strings.TrimSpace(tool + " " + strings.Join(args, " "))
+	if output := ErrorOutput(err); output != "" {
+		return fmt.Errorf("`%s` failed: %w\n%s", probe, err, output)
+	}
+	return fmt.Errorf("`%s` failed: %w", probe, err)
+}
+
+// RequireAuth is Require for login probes such as `gh auth status`, where a
+// failing probe means the tool isn't authenticated.
+func RequireAuth(dir string, r CommandRunner, tool string, args ...string) error {
+	err := Require(dir, r, tool, args...)
+	if err == nil || errors.Is(err, ErrToolMissing) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+}
+
 // Default is the default command runner that executes real commands
-type Default struct{}
+type Default struct {
+	Logger *slog.Logger // Diagnostic logger (defaults to slog.Default())
+}
 
 // Run executes a command and returns stdout
 func (r *Default) Run(dir string, command string, args ...string) (string, error) {
 	cmd := exec.Command(command, args...)
 	cmd.Dir = dir
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = nil
 
-	if err := cmd.Run(); err != nil {
-		return "", err
-	}
-
-	return strings.TrimSpace(stdout.String()), nil
+	start := time.Now()
+	output, err := run(cmd)
+	r.log(dir, command, args, time.Since(start), err)
+	return output, err
 }
 
 // RunWithTimeout executes a command with a timeout
 func (r *Default) RunWithTimeout(dir string, timeout time.Duration, command string, args ...string) (string, error) {
 	path, err := exec.LookPath(command)
 	if err != nil {
+		r.log(dir, command, args, 0, err)
 		return "", err
 	}
 
@@ -44,12 +119,51 @@ func (r *Default) RunWithTimeout(dir string, timeout time.Duration, command stri
 
 	cmd := exec.CommandContext(ctx, path, args...)
 	cmd.Dir = dir
-	var stdout bytes.Buffer
+
+	start := time.Now()
+	output, err := run(cmd)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = &CommandError{Err: fmt.Errorf("%s: %w after %s", command, ErrTimeout, timeout), Output: ErrorOutput(err)}
+	}
+	r.log(dir, command, args, time.Since(start), err)
+	return output, err
+}
+
+// log records a finished subprocess at debug level
+func (r *Default) log(dir, command string, args []string, elapsed time.Duration, err error) {
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	attrs := []any{"cmd", strings.TrimSpace(command + " " + strings.Join(args, " ")), "dir", dir, "duration", elapsed.Round(time.Millisecond)}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	logger.Debug("ran command", attrs...)
+}
+
+// run executes cmd, returning trimmed stdout on success. On failure the output
+// is withheld from the return value and attached to a *CommandError instead.
+//
+// Stdin is the null device, so a subprocess that unexpectedly prompts (a bd
+// confirmation, say) reads EOF and fails fast instead of hanging until its
+// timeout. os/exec does the same for a nil Stdin; opening it here makes the
+// guarantee explicit rather than incidental.
+func run(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+	cmd.Stdin = devNull
 	cmd.Stdout = &stdout
-	cmd.Stderr = nil
+	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", err
+		output := strings.TrimSpace(stdout.String() + "\n" + stderr.String())
+		return "", &CommandError{Err: err, Output: output}
 	}
 
 	return strings.TrimSpace(stdout.String()), nil
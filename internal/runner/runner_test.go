@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultRunWithTimeout(t *testing.T) {
+	t.Run("deadline is reported as ErrTimeout", func(t *testing.T) {
+		r := &Default{}
+		_, err := r.RunWithTimeout(t.TempDir(), 50*time.Millisecond, "sleep", "5")
+
+		if !errors.Is(err, ErrTimeout) || !IsTimeout(err) {
+			t.Fatalf("expected timeout error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "sleep") {
+			t.Errorf("expected command name in error, got %v", err)
+		}
+	})
+
+	t.Run("ordinary failure is not a timeout", func(t *testing.T) {
+		r := &Default{}
+		_, err := r.RunWithTimeout(t.TempDir(), 5*time.Second, "sh", "-c", "echo boom >&2; exit 3")
+
+		if err == nil || IsTimeout(err) {
+			t.Fatalf("expected non-timeout error, got %v", err)
+		}
+		if ErrorOutput(err) != "boom" {
+			t.Errorf("expected captured output, got %q", ErrorOutput(err))
+		}
+	})
+}
+
+func TestDefaultStdin(t *testing.T) {
+	// A command waiting for input must see EOF rather than block, reading
+	// from the null device rather than a pipe
+	script := "[ -c /dev/stdin ] && ! read answer && echo eof"
+	r := &Default{}
+
+	t.Run("Run", func(t *testing.T) {
+		output, err := r.Run(t.TempDir(), "sh", "-c", script)
+		if err != nil || output != "eof" {
+			t.Errorf("expected eof, got %q (err %v)", output, err)
+		}
+	})
+
+	t.Run("RunWithTimeout", func(t *testing.T) {
+		output, err := r.RunWithTimeout(t.TempDir(), 5*time.Second, "sh", "-c", script)
+		if err != nil || output != "eof" {
+			t.Errorf("expected eof, got %q (err %v)", output, err)
+		}
+	})
+}
+
+func TestDefaultLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := &Default{Logger: logger}
+
+	if _, err := r.Run(t.TempDir(), "sh", "-c", "exit 0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Run(t.TempDir(), "sh", "-c", "exit 1")
+
+	logs := buf.String()
+	if strings.Count(logs, `msg="ran command"`) != 2 {
+		t.Errorf("expected two command logs, got:\n%s", logs)
+	}
+	if !strings.Contains(logs, `cmd="sh -c exit 0"`) || !strings.Contains(logs, "duration=") {
+		t.Errorf("expected command and duration, got:\n%s", logs)
+	}
+	if !strings.Contains(logs, `err="exit status 1"`) {
+		t.Errorf("expected failure to be logged, got:\n%s", logs)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	r := &Default{}
+
+	t.Run("available tool", func(t *testing.T) {
+		if err := Require(t.TempDir(), r, "sh", "-c", "exit 0"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("missing tool", func(t *testing.T) {
+		err := Require(t.TempDir(), r, "vibes-no-such-tool", "--version")
+		if err == nil || err.Error() != "vibes-no-such-tool is not installed" || !errors.Is(err, ErrToolMissing) {
+			t.Errorf("expected not installed error, got %v", err)
+		}
+	})
+
+	t.Run("failing probe includes output", func(t *testing.T) {
+		err := Require(t.TempDir(), r, "sh", "-c", "echo not logged in >&2; exit 1")
+		if err == nil || !strings.Contains(err.Error(), "not logged in") {
+			t.Errorf("expected probe output in error, got %v", err)
+		}
+	})
+}
+
+func TestRequireAuth(t *testing.T) {
+	r := &Default{}
+
+	t.Run("failing probe is an auth failure", func(t *testing.T) {
+		err := RequireAuth(t.TempDir(), r, "sh", "-c", "echo not logged in >&2; exit 1")
+		if !errors.Is(err, ErrAuthFailed) || !strings.Contains(err.Error(), "not logged in") {
+			t.Errorf("expected ErrAuthFailed with probe output, got %v", err)
+		}
+	})
+
+	t.Run("missing tool stays a missing tool", func(t *testing.T) {
+		err := RequireAuth(t.TempDir(), r, "vibes-no-such-tool", "auth", "status")
+		if !errors.Is(err, ErrToolMissing) || errors.Is(err, ErrAuthFailed) {
+			t.Errorf("expected only ErrToolMissing, got %v", err)
+		}
+	})
+}
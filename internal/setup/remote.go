@@ -0,0 +1,146 @@
+package setup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// coreProompts are the files a proompts source must provide.
+var coreProompts = []string{
+	"initial-prompt.md",
+	"start-task.md",
+	"request-review.md",
+	"act-on-review.md",
+}
+
+// remoteTimeout bounds how long cloning or fetching a proompts repo may take.
+const remoteTimeout = 60 * time.Second
+
+// ParseRepoSpec splits a --proompts-repo value of the form <url>[@ref].
+// The ref is whatever follows the first "@" in the repository path, so it
+// may contain slashes (release/v2). An "@" in the user or host part (as in
+// git@github.com:org/repo) is not a ref.
+func ParseRepoSpec(spec string) (url, ref string) {
+	path := repoPathStart(spec)
+	if at := strings.Index(spec[path:], "@"); at >= 0 {
+		return spec[:path+at], spec[path+at+1:]
+	}
+	return spec, ""
+}
+
+// repoPathStart returns the index where the repository path begins: after
+// the host of scheme://host/path URLs, after "host:" in scp-style
+// [user@]host:path addresses, and at 0 for local paths.
+func repoPathStart(spec string) int {
+	if scheme := strings.Index(spec, "://"); scheme >= 0 {
+		host := scheme + len("://")
+		if slash := strings.Index(spec[host:], "/"); slash >= 0 {
+			return host + slash
+		}
+		return len(spec)
+	}
+	colon := strings.Index(spec, ":")
+	if colon > 0 && !strings.Contains(spec[:colon], "/") {
+		return colon + 1
+	}
+	return 0
+}
+
+// remoteCacheDir returns where clones of url at ref are cached.
+func remoteCacheDir(url, ref string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(url + "@" + ref))
+	return filepath.Join(configDir, "vibes", "proompts", hex.EncodeToString(sum[:8])), nil
+}
+
+// fetchRemoteProompts makes a shallow clone of the proompts repo available in
+// the cache, refreshing it if already present, and returns the directory
+// holding the proompt files.
+func fetchRemoteProompts(spec string) (string, error) {
+	url, ref := ParseRepoSpec(spec)
+	if url == "" {
+		return "", fmt.Errorf("empty repository URL")
+	}
+
+	cacheDir, err := remoteCacheDir(url, ref)
+	if err != nil {
+		return "", err
+	}
+
+	if IsGitRepo(cacheDir) {
+		target := ref
+		if target == "" {
+			target = "HEAD"
+		}
+		if err := runGit(cacheDir, "fetch", "--depth", "1", "origin", target); err != nil {
+			return "", err
+		}
+		if err := runGit(cacheDir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	} else {
+		if err := os.RemoveAll(cacheDir); err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(cacheDir), 0755); err != nil {
+			return "", err
+		}
+		args := []string{"clone", "--depth", "1"}
+		if ref != "" {
+			args = append(args, "--branch", ref)
+		}
+		args = append(args, url, cacheDir)
+		if err := runGit("", args...); err != nil {
+			return "", err
+		}
+	}
+
+	// Accept either a dedicated repo or one with a proompts/ subdirectory
+	src := cacheDir
+	if info, err := os.Stat(filepath.Join(cacheDir, "proompts")); err == nil && info.IsDir() {
+		src = filepath.Join(cacheDir, "proompts")
+	}
+
+	if err := validateProompts(src); err != nil {
+		return "", err
+	}
+	return src, nil
+}
+
+// validateProompts checks that dir contains the core proompt files.
+func validateProompts(dir string) error {
+	var missing []string
+	for _, name := range coreProompts {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing core proompts: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// runGit runs a git command without prompting for credentials.
+func runGit(dir string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w\n%s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package setup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRepoSpec(t *testing.T) {
+	testCases := []struct {
+		spec string
+		url  string
+		ref  string
+	}{
+		{"https://github.com/acme/proompts", "https://github.com/acme/proompts", ""},
+		{"https://github.com/acme/proompts@v1.2", "https://github.com/acme/proompts", "v1.2"},
+		{"git@github.com:acme/proompts.git", "git@github.com:acme/proompts.git", ""},
+		{"git@github.com:acme/proompts.git@main", "git@github.com:acme/proompts.git", "main"},
+		{"https://github.com/acme/proompts@release/v2", "https://github.com/acme/proompts", "release/v2"},
+		{"https://bot@github.com/acme/proompts", "https://bot@github.com/acme/proompts", ""},
+		{"https://bot@github.com/acme/proompts@v1.2", "https://bot@github.com/acme/proompts", "v1.2"},
+		{"git@host:org/repo@feature/x", "git@host:org/repo", "feature/x"},
+		{"/srv/proompts", "/srv/proompts", ""},
+		{"/srv/proompts@release/2024", "/srv/proompts", "release/2024"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			url, ref := ParseRepoSpec(tc.spec)
+			if url != tc.url || ref != tc.ref {
+				t.Errorf("expected (%q, %q), got (%q, %q)", tc.url, tc.ref, url, ref)
+			}
+		})
+	}
+}
+
+func TestValidateProompts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range coreProompts[1:] {
+		os.WriteFile(filepath.Join(dir, name), []byte("# prompt"), 0644)
+	}
+
+	err := validateProompts(dir)
+	if err == nil || !strings.Contains(err.Error(), coreProompts[0]) {
+		t.Errorf("expected missing %s, got %v", coreProompts[0], err)
+	}
+
+	os.WriteFile(filepath.Join(dir, coreProompts[0]), []byte("# prompt"), 0644)
+	if err := validateProompts(dir); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFetchRemoteProompts(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	repo := t.TempDir()
+	git(repo, "init", "-q")
+	os.MkdirAll(filepath.Join(repo, "proompts"), 0755)
+	for _, name := range coreProompts {
+		os.WriteFile(filepath.Join(repo, "proompts", name), []byte("# team prompt"), 0644)
+	}
+	git(repo, "add", "-A")
+	git(repo, "commit", "-q", "-m", "initial")
+	git(repo, "tag", "v1")
+
+	t.Run("clones and finds proompts subdirectory", func(t *testing.T) {
+		src, err := fetchRemoteProompts(repo + "@v1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if filepath.Base(src) != "proompts" {
+			t.Errorf("expected proompts subdirectory, got %s", src)
+		}
+		if err := validateProompts(src); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("refreshes cached clone", func(t *testing.T) {
+		src, err := fetchRemoteProompts(repo)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		os.WriteFile(filepath.Join(repo, "proompts", "extra.md"), []byte("# new"), 0644)
+		git(repo, "add", "-A")
+		git(repo, "commit", "-q", "-m", "add extra")
+
+		if _, err := fetchRemoteProompts(repo); err != nil {
+			t.Fatalf("unexpected error on refresh: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(src, "extra.md")); err != nil {
+			t.Errorf("expected latest commit, got %v", err)
+		}
+	})
+
+	t.Run("copy skips git metadata", func(t *testing.T) {
+		dst := t.TempDir()
+		if err := CopyDir(repo, dst); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, ".git")); !os.IsNotExist(err) {
+			t.Error("expected .git to be skipped")
+		}
+		if _, err := os.Stat(filepath.Join(dst, "proompts", "start-task.md")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("invalid source is rejected", func(t *testing.T) {
+		if _, err := fetchRemoteProompts(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("expected error for missing repository")
+		}
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/huh"
+	"github.com/vibes-project/vibes/internal/git"
 	"github.com/vibes-project/vibes/internal/styles"
 )
 
@@ -21,6 +22,8 @@ type Options struct {
 	TargetDir    string
 	MigrateTasks bool
 	SkipProompts bool
+	ProomptsRepo string // Remote proompts source as <url>[@ref]; embedded if empty
+	Force        bool   // Allow setup in the home directory or a filesystem root
 	SourceFS     embed.FS
 }
 
@@ -43,7 +46,7 @@ func Run(opts Options) (*Result, error) {
 	}
 
 	// Validate target
-	if err := validateTarget(targetDir); err != nil {
+	if err := validateTarget(targetDir, opts.Force); err != nil {
 		return nil, err
 	}
 
@@ -53,7 +56,7 @@ func Run(opts Options) (*Result, error) {
 
 	// Step 1: Copy proompts
 	if !opts.SkipProompts {
-		copied, err := copyProompts(opts.SourceFS, targetDir)
+		copied, err := copyProompts(opts.SourceFS, opts.ProomptsRepo, targetDir)
 		if err != nil {
 			return result, fmt.Errorf("copying proompts: %w", err)
 		}
@@ -122,7 +125,7 @@ func IsGitRepo(dir string) bool {
 	return err == nil && info.IsDir()
 }
 
-func validateTarget(targetDir string) error {
+func validateTarget(targetDir string, force bool) error {
 	info, err := os.Stat(targetDir)
 	if os.IsNotExist(err) {
 		return fmt.Errorf("directory '%s' does not exist", targetDir)
@@ -133,13 +136,40 @@ func validateTarget(targetDir string) error {
 	if !info.IsDir() {
 		return fmt.Errorf("'%s' is not a directory", targetDir)
 	}
+	if place := unsafeTarget(targetDir); place != "" {
+		if !force {
+			return fmt.Errorf("refusing to set up in %s '%s' (use --force to override)", place, targetDir)
+		}
+		fmt.Println(styles.Error(fmt.Sprintf("Warning: setting up in %s '%s' (--force)", place, targetDir)))
+	}
 	if !IsGitRepo(targetDir) {
-		return fmt.Errorf("directory '%s' is not a git repository", targetDir)
+		return fmt.Errorf("directory '%s' is %w", targetDir, git.ErrNotGitRepo)
 	}
 	return nil
 }
 
-func copyProompts(sourceFS embed.FS, targetDir string) (bool, error) {
+// unsafeTarget describes targetDir if it is the user's home directory or a
+// filesystem root, where setup would scatter files; empty string otherwise.
+func unsafeTarget(targetDir string) string {
+	resolved := targetDir
+	if p, err := filepath.EvalSymlinks(targetDir); err == nil {
+		resolved = p
+	}
+	if filepath.Dir(resolved) == resolved {
+		return "the filesystem root"
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if h, err := filepath.EvalSymlinks(home); err == nil {
+			home = h
+		}
+		if resolved == filepath.Clean(home) {
+			return "your home directory"
+		}
+	}
+	return ""
+}
+
+func copyProompts(sourceFS embed.FS, repo string, targetDir string) (bool, error) {
 	fmt.Println(styles.Header("Step 1: Proompts Directory"))
 
 	targetProompts := filepath.Join(targetDir, "proompts")
@@ -171,6 +201,20 @@ func copyProompts(sourceFS embed.FS, targetDir string) (bool, error) {
 		}
 	}
 
+	// Copy from the remote source if given, falling back to embedded
+	if repo != "" {
+		src, err := fetchRemoteProompts(repo)
+		if err == nil {
+			if err := CopyDir(src, targetProompts); err != nil {
+				return false, err
+			}
+			fmt.Println(styles.Success("Created proompts directory from " + repo))
+			return true, nil
+		}
+		fmt.Println(styles.Error("Could not use proompts from " + repo + ": " + err.Error()))
+		fmt.Println(styles.Info("Falling back to embedded proompts"))
+	}
+
 	// Copy files from embedded FS
 	err := fs.WalkDir(sourceFS, "proompts", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -361,7 +405,7 @@ func updateGitignore(targetDir string) (bool, error) {
 		lineSet[strings.TrimSpace(line)] = true
 	}
 
-	entries := []string{".beads/.cache/"}
+	entries := []string{".beads/.cache/", ".vibes/history.jsonl", ".vibes/last-test.json"}
 	added := false
 
 	for _, entry := range entries {
@@ -483,13 +527,17 @@ func CopyFile(src, dst string) error {
 	return err
 }
 
-// CopyDir recursively copies a directory from src to dst
+// CopyDir recursively copies a directory from src to dst, skipping .git directories
 func CopyDir(src, dst string) error {
 	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
 		relPath, err := filepath.Rel(src, path)
 		if err != nil {
 			return err
@@ -0,0 +1,55 @@
+package setup
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/vibes-project/vibes/internal/git"
+)
+
+func TestValidateTarget(t *testing.T) {
+	t.Run("refuses home directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		if err := exec.Command("git", "init", "-q", home).Run(); err != nil {
+			t.Skipf("git not available: %v", err)
+		}
+
+		err := validateTarget(home, false)
+		if err == nil || !strings.Contains(err.Error(), "home directory") {
+			t.Errorf("expected home directory error, got %v", err)
+		}
+
+		if err := validateTarget(home, true); err != nil {
+			t.Errorf("expected --force to allow home directory, got %v", err)
+		}
+	})
+
+	t.Run("refuses filesystem root", func(t *testing.T) {
+		err := validateTarget("/", false)
+		if err == nil || !strings.Contains(err.Error(), "filesystem root") {
+			t.Errorf("expected filesystem root error, got %v", err)
+		}
+	})
+
+	t.Run("refuses a directory outside git", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		if err := validateTarget(t.TempDir(), false); !errors.Is(err, git.ErrNotGitRepo) {
+			t.Errorf("expected ErrNotGitRepo, got %v", err)
+		}
+	})
+
+	t.Run("allows a project directory", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		dir := t.TempDir()
+		if err := exec.Command("git", "init", "-q", dir).Run(); err != nil {
+			t.Skipf("git not available: %v", err)
+		}
+
+		if err := validateTarget(dir, false); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package stuck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/runner"
+)
+
+// maxRangeCommits caps the commits listed for a --range
+const maxRangeCommits = 30
+
+// commitRange is a good..bad range whose bad end is checked out in a
+// temporary worktree for error detection.
+type commitRange struct {
+	Spec     string // The range as given, e.g. v1.2..HEAD
+	Good     string // Full hash of the last known-good commit
+	Bad      string // Full hash of the first known-bad commit
+	Worktree string // Temporary checkout of Bad
+	cleanup  func()
+}
+
+// openRange resolves spec and checks out its bad end in a temporary
+// worktree. Callers must Close the range.
+func openRange(dir string, spec string, r runner.CommandRunner) (*commitRange, error) {
+	goodRef, badRef, err := git.ParseRange(spec)
+	if err != nil {
+		return nil, err
+	}
+	good, err := git.ResolveCommit(dir, goodRef, r)
+	if err != nil {
+		return nil, err
+	}
+	bad, err := git.ResolveCommit(dir, badRef, r)
+	if err != nil {
+		return nil, err
+	}
+
+	worktree, cleanup, err := git.AddWorktree(dir, bad, r)
+	if err != nil {
+		return nil, err
+	}
+	return &commitRange{Spec: spec, Good: good, Bad: bad, Worktree: worktree, cleanup: cleanup}, nil
+}
+
+// Close removes the range's worktree.
+func (c *commitRange) Close() {
+	if c != nil && c.cleanup != nil {
+		c.cleanup()
+	}
+}
+
+// revs returns the range's ends as git diff arguments, or nil when
+// checking the working tree.
+func (c *commitRange) revs() []string {
+	if c == nil {
+		return nil
+	}
+	return []string{c.Good, c.Bad}
+}
+
+// getRangeDiff returns the stat and diff between the range's ends.
+func getRangeDiff(dir string, c *commitRange, contextLines *int, paths git.PathRewriter, r runner.CommandRunner) string {
+	stat, _ := r.Run(dir, "git", "diff", "--stat", c.Good, c.Bad)
+	stat = paths.RewriteStat(stat)
+
+	args := []string{"diff"}
+	if contextLines != nil {
+		args = append(args, fmt.Sprintf("-U%d", *contextLines))
+	}
+	diffContent, _ := r.Run(dir, "git", append(args, c.Good, c.Bad)...)
+
+	var parts []string
+	if stat != "" {
+		parts = append(parts, stat)
+	}
+	if diffContent != "" {
+		parts = append(parts, diffContent)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// getRangeCommits lists the commits in the range, newest first.
+func getRangeCommits(dir string, c *commitRange, r runner.CommandRunner) string {
+	output, err := r.Run(dir, "git", "log", "--oneline", fmt.Sprintf("--max-count=%d", maxRangeCommits), c.Good+".."+c.Bad)
+	if err != nil {
+		return ""
+	}
+	return output
+}
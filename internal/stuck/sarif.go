@@ -0,0 +1,175 @@
+package stuck
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Output formats for the stuck command.
+const (
+	FormatMarkdown = "markdown"
+	FormatSARIF    = "sarif"
+)
+
+// sarifSchema is the JSON schema URI for SARIF 2.1.0 logs.
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// locationRe matches compiler diagnostics of the form file:line[:col]: message,
+// as printed by go build and go vet.
+var locationRe = regexp.MustCompile(`^(?:vet: )?([^\s:][^:]*\.[A-Za-z0-9]+):(\d+)(?::(\d+))?: (.+)$`)
+
+// warningRules are checks whose findings are reported as warnings rather than errors.
+var warningRules = map[string]bool{
+	"go-vet": true,
+}
+
+// finding is a located diagnostic parsed from check output.
+type finding struct {
+	Rule    string
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// parseFindings extracts located diagnostics from check output. Indented lines
+// continue the previous message; lines without a location are skipped.
+func parseFindings(rule, output string) []finding {
+	var findings []finding
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "\t") && len(findings) > 0 {
+			last := &findings[len(findings)-1]
+			last.Message += "\n" + strings.TrimSpace(line)
+			continue
+		}
+
+		m := locationRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		f := finding{
+			Rule:    rule,
+			File:    strings.TrimPrefix(m[1], "./"),
+			Message: m[4],
+		}
+		f.Line, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			f.Column, _ = strconv.Atoi(m[3])
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// formatSARIF renders failed checks as a SARIF 2.1.0 log. Only diagnostics
+// with a parseable location are included.
+func formatSARIF(failures []checkFailure) (string, error) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "vibes", Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+
+	seen := map[string]bool{}
+	for _, failure := range failures {
+		rule := failure.Check.Rule
+		if rule == "" {
+			rule = failure.Check.Name
+		}
+
+		findings := parseFindings(rule, failure.Output)
+		if len(findings) == 0 {
+			continue
+		}
+
+		if !seen[rule] {
+			seen[rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               rule,
+				ShortDescription: sarifMessage{Text: failure.Check.Label},
+			})
+		}
+
+		level := "error"
+		if warningRules[rule] {
+			level = "warning"
+		}
+		for _, f := range findings {
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  f.Rule,
+				Level:   level,
+				Message: sarifMessage{Text: f.Message},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: f.File, URIBaseID: "%SRCROOT%"},
+						Region:           sarifRegion{StartLine: f.Line, StartColumn: f.Column},
+					},
+				}},
+			})
+		}
+	}
+
+	log := sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding SARIF: %w", err)
+	}
+	return string(data) + "\n", nil
+}
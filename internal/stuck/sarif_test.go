@@ -0,0 +1,110 @@
+package stuck
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vibes-project/vibes/internal/project"
+)
+
+func TestParseFindings(t *testing.T) {
+	t.Run("go build errors", func(t *testing.T) {
+		output := "# example.com/app\n./main.go:6:17: undefined: foo\ninternal/x/x.go:12:2: missing return"
+
+		findings := parseFindings("go-build", output)
+
+		if len(findings) != 2 {
+			t.Fatalf("expected 2 findings, got %d: %+v", len(findings), findings)
+		}
+		expected := finding{Rule: "go-build", File: "main.go", Line: 6, Column: 17, Message: "undefined: foo"}
+		if findings[0] != expected {
+			t.Errorf("expected %+v, got %+v", expected, findings[0])
+		}
+		if findings[1].File != "internal/x/x.go" || findings[1].Line != 12 {
+			t.Errorf("unexpected second finding: %+v", findings[1])
+		}
+	})
+
+	t.Run("vet prefix and continuation lines", func(t *testing.T) {
+		output := "vet: ./main.go:3:2: wrong argument count\n\thave (string)\n\twant ()"
+
+		findings := parseFindings("go-vet", output)
+
+		if len(findings) != 1 {
+			t.Fatalf("expected 1 finding, got %d", len(findings))
+		}
+		if findings[0].Message != "wrong argument count\nhave (string)\nwant ()" {
+			t.Errorf("unexpected message: %q", findings[0].Message)
+		}
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		findings := parseFindings("go-build", "main.go:9: syntax error")
+		if len(findings) != 1 || findings[0].Line != 9 || findings[0].Column != 0 {
+			t.Errorf("unexpected findings: %+v", findings)
+		}
+	})
+
+	t.Run("unlocated output is skipped", func(t *testing.T) {
+		findings := parseFindings("py-compile", "SyntaxError: invalid syntax")
+		if len(findings) != 0 {
+			t.Errorf("expected no findings, got %+v", findings)
+		}
+	})
+}
+
+func TestFormatSARIF(t *testing.T) {
+	failures := []checkFailure{
+		{Check: project.Cmd{Label: "Go build errors", Rule: "go-build"}, Output: "main.go:6:17: undefined: foo"},
+		{Check: project.Cmd{Label: "Go vet issues", Rule: "go-vet"}, Output: "main.go:8:2: unreachable code"},
+		{Check: project.Cmd{Label: "Python syntax error", Rule: "py-compile"}, File: "app.py", Output: "SyntaxError: invalid syntax"},
+	}
+
+	result, err := formatSARIF(failures)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(result), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+
+	t.Run("rules per tool", func(t *testing.T) {
+		if len(run.Tool.Driver.Rules) != 2 {
+			t.Fatalf("expected 2 rules, got %+v", run.Tool.Driver.Rules)
+		}
+		if run.Tool.Driver.Rules[0].ID != "go-build" || run.Tool.Driver.Rules[1].ID != "go-vet" {
+			t.Errorf("unexpected rules: %+v", run.Tool.Driver.Rules)
+		}
+	})
+
+	t.Run("results are located", func(t *testing.T) {
+		if len(run.Results) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(run.Results))
+		}
+		loc := run.Results[0].Locations[0].PhysicalLocation
+		if loc.ArtifactLocation.URI != "main.go" || loc.Region.StartLine != 6 || loc.Region.StartColumn != 17 {
+			t.Errorf("unexpected location: %+v", loc)
+		}
+		if run.Results[0].Level != "error" || run.Results[1].Level != "warning" {
+			t.Errorf("unexpected levels: %s, %s", run.Results[0].Level, run.Results[1].Level)
+		}
+	})
+
+	t.Run("no failures produces empty results", func(t *testing.T) {
+		result, err := formatSARIF(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, `"results": []`) {
+			t.Errorf("expected empty results array, got: %s", result)
+		}
+	})
+}
@@ -5,21 +5,33 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
 	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/project"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/runner"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 // Options configures the stuck command behavior
 type Options struct {
-	Dir         string               // Target directory (defaults to cwd)
-	Verbose     bool                 // Include full protocol details
-	Description string               // Optional problem description from user
-	Runner      runner.CommandRunner // Command runner (defaults to runner.Default)
+	Dir          string               // Target directory (defaults to cwd)
+	Verbose      bool                 // Include full protocol details
+	Description  string               // Optional problem description from user
+	Format       string               // Output format: markdown (default) or sarif
+	DiffContext  *int                 // Lines of context around diff changes (nil = git's default of 3)
+	Range        string               // good..bad commits to diagnose instead of the working tree
+	Paths        git.PathMode         // How file paths in diff stats are displayed (defaults to repo-root)
+	TemplateVars map[string]string    // Custom variables for protocol templates
+	Output       prompt.Output        // Output rendering (e.g. context only)
+	Tracker      *beads.IssueTracker  // External issue tracker for branches without a bead ID (nil = Beads only)
+	Runner       runner.CommandRunner // Command runner (defaults to runner.Default)
 }
 
+// Sections lists every section this command can produce, in output order.
+var Sections = []string{"Current Context", "Recent Changes", "Recent Commits", "Detected Errors", "Problem", "Debugging Protocol"}
+
 // Run executes the stuck command and returns the prompt to stdout
 func Run(opts Options) error {
 	dir := opts.Dir
@@ -35,22 +47,53 @@ func Run(opts Options) error {
 	if r == nil {
 		r = &runner.Default{}
 	}
+	r = git.NewCache(r)
+
+	if opts.DiffContext != nil && *opts.DiffContext < 0 {
+		return fmt.Errorf("invalid --diff-context %d (must be 0 or more)", *opts.DiffContext)
+	}
 
-	var out strings.Builder
+	switch opts.Format {
+	case "", FormatMarkdown, FormatSARIF:
+	default:
+		return fmt.Errorf("unknown format %q (expected %s or %s)", opts.Format, FormatMarkdown, FormatSARIF)
+	}
+
+	// Checks run against the bad end of a range, in its own worktree
+	checkDir := dir
+	var rng *commitRange
+	if opts.Range != "" {
+		var err error
+		if rng, err = openRange(dir, opts.Range, r); err != nil {
+			return err
+		}
+		defer rng.Close()
+		checkDir = rng.Worktree
+	}
+
+	if opts.Format == FormatSARIF {
+		sarif, err := formatSARIF(runChecks(checkDir, rng.revs(), r))
+		if err != nil {
+			return err
+		}
+		fmt.Print(sarif)
+		return nil
+	}
 
 	// Header
 	projectName := filepath.Base(dir)
-	out.WriteString(fmt.Sprintf("# Help Debugging in %s\n\n", projectName))
+	doc := prompt.New(fmt.Sprintf("Help Debugging in %s", projectName), Sections...)
 
 	// Get current branch and task context
 	branch := git.GetCurrentBranch(dir, r)
-	task := beads.DetectCurrentTask(dir, branch, r)
+	task := beads.DetectCurrentTask(dir, branch, opts.Tracker, r)
 	task.ProjectName = projectName
+	doc.Task = task.ID
 
 	// Current context section
-	out.WriteString("## Current Context\n")
+	var context strings.Builder
 	if branch != "" {
-		out.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
+		context.WriteString(fmt.Sprintf("- **Branch**: %s\n", branch))
 	}
 	if task.ID != "" {
 		if task.Title != "" {
@@ -58,72 +101,95 @@ func Run(opts Options) error {
 			if task.Status != "" {
 				statusStr = fmt.Sprintf(" [%s]", task.Status)
 			}
-			out.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"%s\n", task.ID, task.Title, statusStr))
+			context.WriteString(fmt.Sprintf("- **Task**: %s \"%s\"%s\n", task.ID, task.Title, statusStr))
 		} else {
-			out.WriteString(fmt.Sprintf("- **Task**: %s\n", task.ID))
+			context.WriteString(fmt.Sprintf("- **Task**: %s\n", task.Ref()))
 		}
 	}
 
-	// Working tree status
-	status := git.GetWorkingTreeStatus(dir, r)
-	if status != "" {
-		out.WriteString(fmt.Sprintf("- **Working tree**: %s\n", status))
+	if rng != nil {
+		context.WriteString(fmt.Sprintf("- **Range**: %s (worked at %s, broken by %s)\n", rng.Spec, shortHash(rng.Good), shortHash(rng.Bad)))
 	} else {
-		out.WriteString("- **Working tree**: Clean\n")
+		// Working tree status
+		status := git.GetWorkingTreeStatus(dir, r)
+		if status != "" {
+			context.WriteString(fmt.Sprintf("- **Working tree**: %s\n", status))
+		} else {
+			context.WriteString("- **Working tree**: Clean\n")
+		}
+		if warning := git.InProgressWarning(git.GetInProgressOperation(dir, r)); warning != "" {
+			context.WriteString(fmt.Sprintf("- %s\n", warning))
+		}
 	}
-	out.WriteString("\n")
+	context.WriteString("\n")
+	doc.Add(prompt.Summary, "Current Context", context.String())
 
 	// Recent changes section
-	diff := getDiff(dir, r)
+	paths := git.NewPathRewriter(dir, opts.Paths, r)
+	var diff string
+	if rng != nil {
+		diff = getRangeDiff(dir, rng, opts.DiffContext, paths, r)
+	} else {
+		diff = getDiff(dir, opts.DiffContext, paths, r)
+	}
 	if diff != "" {
-		out.WriteString("## Recent Changes\n")
-		out.WriteString("```diff\n")
-		out.WriteString(truncateOutput(diff, 100))
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Recent Changes", "```diff\n"+truncateDiff(diff, 100)+"\n```\n\n")
 	}
 
 	// Recent commits
-	commits := git.GetBranchCommits(dir, branch, r)
+	var commits string
+	if rng != nil {
+		commits = getRangeCommits(dir, rng, r)
+	} else {
+		commits = git.GetBranchCommits(dir, branch, r)
+	}
 	if commits != "" {
-		out.WriteString("## Recent Commits\n")
-		out.WriteString("```\n")
-		out.WriteString(commits)
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Recent Commits", "```\n"+commits+"\n```\n\n")
 	}
 
 	// Try to detect errors
-	errorOutput := detectErrors(dir, r)
+	errorOutput := detectErrors(checkDir, rng.revs(), r)
+	if rng != nil {
+		errorOutput = strings.ReplaceAll(errorOutput, rng.Worktree+string(filepath.Separator), "")
+	}
 	if errorOutput != "" {
-		out.WriteString("## Detected Errors\n")
-		out.WriteString("```\n")
-		out.WriteString(truncateOutput(errorOutput, 50))
-		out.WriteString("\n```\n\n")
+		doc.Add(prompt.Context, "Detected Errors", "```\n"+prompt.TruncateLines(errorOutput, 50)+"\n```\n\n")
 	}
 
 	// Problem description
 	if opts.Description != "" {
-		out.WriteString("## Problem\n")
-		out.WriteString(fmt.Sprintf("%s\n\n", opts.Description))
+		doc.Add(prompt.Summary, "Problem", fmt.Sprintf("%s\n\n", opts.Description))
 	}
 
 	// Protocol
-	out.WriteString("## Debugging Protocol\n")
-	out.WriteString(getProtocol(opts.Verbose))
+	vars := templates.Merge(templates.Builtins(task.ID, projectName, branch), opts.TemplateVars)
+	protocol, err := templates.Protocol(dir, "stuck", getProtocol(opts.Verbose), vars)
+	if err != nil {
+		return err
+	}
+	doc.Add(prompt.Protocol, "Debugging Protocol", protocol)
 
-	fmt.Print(out.String())
-	return nil
+	return prompt.Emit(dir, doc, opts.Output, r)
 }
 
-// getDiff returns the combined staged and unstaged diff, limited to recent changes
-func getDiff(dir string, r runner.CommandRunner) string {
+// getDiff returns the combined staged and unstaged diff, limited to recent changes.
+// contextLines sets the lines of context around each change when non-nil, and
+// paths rewrites the file names in the stat listings.
+func getDiff(dir string, contextLines *int, paths git.PathRewriter, r runner.CommandRunner) string {
 	// Get staged diff
 	staged, _ := r.Run(dir, "git", "diff", "--cached", "--stat")
+	staged = paths.RewriteStat(staged)
 
 	// Get unstaged diff
 	unstaged, _ := r.Run(dir, "git", "diff", "--stat")
+	unstaged = paths.RewriteStat(unstaged)
 
 	// Get actual diff content (limited)
-	diffContent, _ := r.Run(dir, "git", "diff", "HEAD")
+	args := []string{"diff"}
+	if contextLines != nil {
+		args = append(args, fmt.Sprintf("-U%d", *contextLines))
+	}
+	diffContent, _ := r.Run(dir, "git", append(args, "HEAD")...)
 
 	var parts []string
 	if staged != "" {
@@ -139,68 +205,106 @@ func getDiff(dir string, r runner.CommandRunner) string {
 	return strings.Join(parts, "\n\n")
 }
 
-// detectErrors attempts to find recent errors by running common test/build commands
-func detectErrors(dir string, r runner.CommandRunner) string {
-	var errors []string
-
-	// Check for Go projects
-	if fileExists(filepath.Join(dir, "go.mod")) {
-		// Try go build
-		output, err := r.RunWithTimeout(dir, 30*time.Second, "go", "build", "./...")
-		if err != nil && output != "" {
-			errors = append(errors, "Go build errors:\n"+output)
-		}
+// checkFailure is the output of an error detection command that failed.
+type checkFailure struct {
+	Check  project.Cmd
+	File   string // Changed file the check ran against, if any
+	Output string
+}
 
-		// Try go vet
-		output, err = r.RunWithTimeout(dir, 30*time.Second, "go", "vet", "./...")
-		if err != nil && output != "" {
-			errors = append(errors, "Go vet issues:\n"+output)
+// detectErrors attempts to find recent errors by running common test/build commands.
+// revs limits changed-file checks to files changed between two commits
+// instead of in the working tree.
+func detectErrors(dir string, revs []string, r runner.CommandRunner) string {
+	var errors []string
+	for _, f := range runChecks(dir, revs, r) {
+		label := f.Check.Label
+		if f.File != "" {
+			label += " in " + f.File
 		}
+		errors = append(errors, label+":\n"+f.Output)
 	}
+	return strings.Join(errors, "\n\n")
+}
 
-	// Check for Node.js projects
-	if fileExists(filepath.Join(dir, "package.json")) {
-		// Check for TypeScript errors
-		if fileExists(filepath.Join(dir, "tsconfig.json")) {
-			output, err := r.RunWithTimeout(dir, 30*time.Second, "npx", "tsc", "--noEmit")
-			if err != nil && output != "" {
-				errors = append(errors, "TypeScript errors:\n"+output)
+// runChecks runs the project's error detection commands and returns those that failed
+func runChecks(dir string, revs []string, r runner.CommandRunner) []checkFailure {
+	var failures []checkFailure
+
+	for _, c := range project.ErrorDetectionCommands(dir) {
+		if c.ChangedFiles == "" {
+			if output := failureOutput(r.RunWithTimeout(dir, c.Timeout, c.Name, c.Args...)); output != "" {
+				failures = append(failures, checkFailure{Check: c, Output: output})
 			}
+			continue
 		}
-	}
 
-	// Check for Python projects
-	if fileExists(filepath.Join(dir, "pyproject.toml")) || fileExists(filepath.Join(dir, "setup.py")) {
-		// Try python syntax check on changed files
-		changedPy, _ := r.Run(dir, "git", "diff", "--name-only", "--diff-filter=M", "*.py")
-		if changedPy != "" {
-			for _, f := range strings.Split(changedPy, "\n") {
-				if f != "" {
-					output, err := r.RunWithTimeout(dir, 10*time.Second, "python", "-m", "py_compile", f)
-					if err != nil && output != "" {
-						errors = append(errors, "Python syntax error in "+f+":\n"+output)
-					}
-				}
+		// Check only files changed in the working tree, or in the range
+		args := append(append([]string{"diff", "--name-only", "--diff-filter=M"}, revs...), "--", c.ChangedFiles)
+		changed, err := r.Run(dir, "git", args...)
+		if err != nil || changed == "" {
+			continue
+		}
+		for _, f := range strings.Split(changed, "\n") {
+			if f == "" {
+				continue
+			}
+			args := append(append([]string{}, c.Args...), f)
+			if output := failureOutput(r.RunWithTimeout(dir, c.Timeout, c.Name, args...)); output != "" {
+				failures = append(failures, checkFailure{Check: c, File: f, Output: output})
 			}
 		}
 	}
 
-	return strings.Join(errors, "\n\n")
+	return failures
 }
 
-// fileExists checks if a file exists
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
+// shortHash abbreviates a full commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// failureOutput returns the output of a command that failed, or empty string if it succeeded
+func failureOutput(output string, err error) string {
+	if err == nil {
+		return ""
+	}
+	if output != "" {
+		return output
+	}
+	return runner.ErrorOutput(err)
 }
 
-// truncateOutput limits output to a certain number of lines
-func truncateOutput(s string, maxLines int) string {
+// truncateDiff limits a diff to maxLines like prompt.TruncateLines, but cuts at the
+// last hunk or file boundary within the limit so no change is shown half-way.
+// Falls back to a plain cut if the first hunk alone exceeds the limit.
+func truncateDiff(s string, maxLines int) string {
 	lines := strings.Split(s, "\n")
 	if len(lines) <= maxLines {
 		return s
 	}
-	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-maxLines)
+
+	cut := 0
+	hunkInFile := false
+	for i := 0; i <= maxLines; i++ {
+		switch {
+		case strings.HasPrefix(lines[i], "diff --git "):
+			cut, hunkInFile = i, false
+		case strings.HasPrefix(lines[i], "@@"):
+			// Cutting before a file's first hunk would strand its header
+			if hunkInFile {
+				cut = i
+			}
+			hunkInFile = true
+		}
+	}
+	if cut == 0 {
+		return prompt.TruncateLines(s, maxLines)
+	}
+	return strings.Join(lines[:cut], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-cut)
 }
 
 func getProtocol(verbose bool) string {
@@ -1,11 +1,17 @@
 package stuck
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/prompt"
+	"github.com/vibes-project/vibes/internal/runner"
 )
 
 // MockRunner is a mock implementation of runner.CommandRunner for testing
@@ -58,29 +64,53 @@ func TestGetProtocol(t *testing.T) {
 	})
 }
 
-func TestTruncateOutput(t *testing.T) {
-	t.Run("short output unchanged", func(t *testing.T) {
-		input := "line1\nline2\nline3"
-		result := truncateOutput(input, 10)
-		if result != input {
-			t.Errorf("expected unchanged output, got %s", result)
+func TestTruncateDiff(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/a.go b/a.go", // 0
+		"--- a/a.go",
+		"+++ b/a.go",
+		"@@ -1,2 +1,2 @@", // 3
+		"-old",
+		"+new",
+		"@@ -10,3 +10,3 @@", // 6
+		" context",
+		"-old",
+		"+new",
+		"diff --git a/b.go b/b.go", // 10
+		"--- a/b.go",
+		"+++ b/b.go",
+		"@@ -1 +1 @@", // 13
+		"-old",
+		"+new",
+	}, "\n")
+
+	t.Run("cuts at last hunk boundary", func(t *testing.T) {
+		result := truncateDiff(diff, 8)
+		if !strings.HasSuffix(result, "+new\n... (10 more lines)") {
+			t.Errorf("expected cut before the second hunk, got:\n%s", result)
+		}
+		if strings.Contains(result, "-10,3") {
+			t.Errorf("expected partial hunk to be dropped, got:\n%s", result)
 		}
 	})
 
-	t.Run("long output truncated", func(t *testing.T) {
-		lines := make([]string, 20)
-		for i := range lines {
-			lines[i] = "line"
+	t.Run("doesn't strand a file header", func(t *testing.T) {
+		result := truncateDiff(diff, 14)
+		if strings.Contains(result, "b.go") || !strings.Contains(result, "... (6 more lines)") {
+			t.Errorf("expected cut before b.go, got:\n%s", result)
 		}
-		input := strings.Join(lines, "\n")
-		result := truncateOutput(input, 5)
+	})
 
-		if !strings.Contains(result, "... (15 more lines)") {
-			t.Error("expected truncation message")
+	t.Run("first hunk over limit falls back to line cut", func(t *testing.T) {
+		result := truncateDiff(diff, 2)
+		if result != "diff --git a/a.go b/a.go\n--- a/a.go\n... (14 more lines)" {
+			t.Errorf("expected plain truncation, got:\n%s", result)
 		}
-		outputLines := strings.Split(result, "\n")
-		if len(outputLines) != 6 { // 5 lines + truncation message
-			t.Errorf("expected 6 lines, got %d", len(outputLines))
+	})
+
+	t.Run("short diff unchanged", func(t *testing.T) {
+		if result := truncateDiff(diff, 100); result != diff {
+			t.Errorf("expected unchanged diff, got:\n%s", result)
 		}
 	})
 }
@@ -176,20 +206,198 @@ func TestRun(t *testing.T) {
 	})
 }
 
-func TestFileExists(t *testing.T) {
-	t.Run("existing file", func(t *testing.T) {
+func TestGetDiff(t *testing.T) {
+	var diffArgs []string
+	mock := &MockRunner{
+		RunFunc: func(dir string, command string, args ...string) (string, error) {
+			if args[len(args)-1] == "HEAD" {
+				diffArgs = args
+			}
+			return "", nil
+		},
+	}
+
+	t.Run("default context", func(t *testing.T) {
+		getDiff("/test", nil, git.PathRewriter{}, mock)
+		if strings.Join(diffArgs, " ") != "diff HEAD" {
+			t.Errorf("expected git's default context, got %v", diffArgs)
+		}
+	})
+
+	t.Run("custom context", func(t *testing.T) {
+		n := 10
+		getDiff("/test", &n, git.PathRewriter{}, mock)
+		if strings.Join(diffArgs, " ") != "diff -U10 HEAD" {
+			t.Errorf("expected -U10, got %v", diffArgs)
+		}
+	})
+
+	t.Run("negative context is rejected", func(t *testing.T) {
+		n := -1
+		if err := Run(Options{Dir: t.TempDir(), DiffContext: &n, Runner: mock}); err == nil {
+			t.Error("expected error for negative context")
+		}
+	})
+
+	t.Run("stat paths are rewritten", func(t *testing.T) {
+		statMock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if args[len(args)-1] == "--stat" {
+					return " internal/app.go | 2 +-\n 1 file changed, 1 insertion(+), 1 deletion(-)", nil
+				}
+				return "", nil
+			},
+		}
+		paths := git.PathRewriter{Mode: git.PathsAbsolute, Root: "/repo", Dir: "/repo/internal"}
+		result := getDiff("/repo/internal", nil, paths, statMock)
+		if !strings.Contains(result, "Staged:\n "+filepath.Join("/repo", "internal", "app.go")+" | 2 +-") {
+			t.Errorf("expected absolute path in stat, got:\n%s", result)
+		}
+	})
+}
+
+func TestDetectErrors(t *testing.T) {
+	t.Run("Go build failure output is reported", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module test"), 0644)
+
+		mock := &MockRunner{
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "go" && args[0] == "build" {
+					return "", &runner.CommandError{Err: errors.New("exit status 1"), Output: "main.go:3:2: undefined: foo"}
+				}
+				return "", nil
+			},
+		}
+
+		result := detectErrors(tmpDir, nil, mock)
+
+		if !strings.Contains(result, "Go build errors:\nmain.go:3:2: undefined: foo") {
+			t.Errorf("expected go build errors, got: %s", result)
+		}
+		if strings.Contains(result, "Go vet") {
+			t.Errorf("expected passing vet to be omitted, got: %s", result)
+		}
+	})
+
+	t.Run("Python checks changed files", func(t *testing.T) {
 		tmpDir := t.TempDir()
-		if fileExists(tmpDir) != true {
-			t.Error("expected directory to exist")
+		os.WriteFile(filepath.Join(tmpDir, "setup.py"), []byte(""), 0644)
+
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command == "git" && args[0] == "diff" {
+					return "app.py", nil
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				if command == "python" && args[len(args)-1] == "app.py" {
+					return "SyntaxError: invalid syntax", errors.New("exit status 1")
+				}
+				return "", nil
+			},
+		}
+
+		result := detectErrors(tmpDir, nil, mock)
+
+		if !strings.Contains(result, "Python syntax error in app.py") {
+			t.Errorf("expected python error for changed file, got: %s", result)
 		}
 	})
 
-	t.Run("non-existing file", func(t *testing.T) {
-		if fileExists("/nonexistent/path/to/file") != false {
-			t.Error("expected file to not exist")
+	t.Run("no ecosystem detected", func(t *testing.T) {
+		if result := detectErrors(t.TempDir(), nil, &MockRunner{}); result != "" {
+			t.Errorf("expected no errors, got: %s", result)
 		}
 	})
 }
 
 // Verify TaskInfo is used correctly (compile-time check)
 var _ = beads.TaskInfo{}
+
+func TestRunRange(t *testing.T) {
+	t.Run("diagnoses the range in a worktree", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		out := t.TempDir()
+
+		var worktree string
+		var removed, built bool
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				if command != "git" {
+					return "", nil
+				}
+				switch args[0] {
+				case "rev-parse":
+					switch args[len(args)-1] {
+					case "v1^{commit}":
+						return "1111111aaaa", nil
+					case "HEAD^{commit}":
+						return "2222222bbbb", nil
+					}
+					return "feature/bd-1", nil
+				case "diff":
+					if args[len(args)-1] == "2222222bbbb" {
+						return "+broken line", nil
+					}
+					return "", nil
+				case "log":
+					if args[len(args)-1] == "1111111aaaa..2222222bbbb" {
+						return "2222222 Break login", nil
+					}
+				case "status":
+					t.Error("expected the working tree to be ignored")
+				}
+				return "", nil
+			},
+			RunWithTimeoutFunc: func(dir string, timeout time.Duration, command string, args ...string) (string, error) {
+				switch {
+				case command == "git" && args[1] == "add":
+					worktree = args[len(args)-2]
+					os.WriteFile(filepath.Join(worktree, "go.mod"), []byte("module test"), 0644)
+				case command == "git" && args[1] == "remove":
+					removed = true
+				case command == "go" && args[0] == "build":
+					if dir != worktree {
+						t.Errorf("expected checks to run in the worktree, got %s", dir)
+					}
+					built = true
+					return "", &runner.CommandError{Err: errors.New("exit status 1"), Output: worktree + "/main.go:3:2: undefined: foo"}
+				}
+				return "", nil
+			},
+		}
+
+		err := Run(Options{Dir: tmpDir, Range: "v1..HEAD", Output: prompt.Output{OutputDir: out}, Runner: mock})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !built || !removed {
+			t.Errorf("expected build in worktree and cleanup (built %v, removed %v)", built, removed)
+		}
+
+		data, err := os.ReadFile(filepath.Join(out, prompt.UserFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := string(data)
+		for _, want := range []string{"**Range**: v1..HEAD (worked at 1111111, broken by 2222222)", "+broken line", "2222222 Break login", "Go build errors:\nmain.go:3:2: undefined: foo"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("expected %q in:\n%s", want, result)
+			}
+		}
+	})
+
+	t.Run("unknown commit", func(t *testing.T) {
+		mock := &MockRunner{
+			RunFunc: func(dir string, command string, args ...string) (string, error) {
+				return "", errors.New("exit status 1")
+			},
+		}
+		err := Run(Options{Dir: t.TempDir(), Range: "nope..HEAD", Runner: mock})
+		if err == nil || !strings.Contains(err.Error(), `"nope"`) {
+			t.Errorf("expected unknown commit error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+// Package templates renders user-customizable protocol templates for vibes commands.
+package templates
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Dir is the project-relative directory holding protocol template overrides.
+const Dir = ".vibes/templates"
+
+// Built-in variables that are always available to protocol templates.
+const (
+	VarTaskID      = "task_id"
+	VarProjectName = "project_name"
+	VarBranch      = "branch"
+)
+
+// Vars holds the variables available to protocol templates.
+type Vars map[string]string
+
+// Builtins returns the built-in variables for a command invocation.
+// Unknown values are filled with the same placeholders the default protocols use.
+func Builtins(taskID, projectName, branch string) Vars {
+	if taskID == "" {
+		taskID = "<task-id>"
+	}
+	if projectName == "" {
+		projectName = "project-name"
+	}
+	return Vars{
+		VarTaskID:      taskID,
+		VarProjectName: projectName,
+		VarBranch:      branch,
+	}
+}
+
+// ParseVars parses key=value pairs as given to --template-var.
+func ParseVars(pairs []string) (Vars, error) {
+	vars := Vars{}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid template variable %q (expected key=value)", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// Merge combines variable sets. Later sets override earlier ones.
+func Merge(sets ...Vars) Vars {
+	merged := Vars{}
+	for _, set := range sets {
+		for k, v := range set {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// Path returns the location of the template override for a command.
+func Path(dir, name string) string {
+	return filepath.Join(dir, Dir, name+".md")
+}
+
+// Protocol returns the protocol text for the named command. If the project has
+// a template at .vibes/templates/<name>.md it is rendered with vars; otherwise
+// fallback is returned unchanged.
+func Protocol(dir, name, fallback string, vars Vars) (string, error) {
+	content, err := os.ReadFile(Path(dir, name))
+	if os.IsNotExist(err) {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s template: %w", name, err)
+	}
+
+	slog.Info("using protocol template", "path", Path(dir, name))
+	return Render(name, string(content), vars)
+}
+
+// Render executes a template body with the given variables.
+// Referencing an undefined variable is an error so typos surface immediately.
+func Render(name, body string, vars Vars) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s template: %w", name, err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, map[string]string(vars)); err != nil {
+		return "", fmt.Errorf("rendering %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,121 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVars(t *testing.T) {
+	t.Run("parses key=value pairs", func(t *testing.T) {
+		vars, err := ParseVars([]string{"project_key=acme", "ttl=7200", "url=https://x.io/?a=b"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vars["project_key"] != "acme" {
+			t.Errorf("expected 'acme', got %q", vars["project_key"])
+		}
+		if vars["ttl"] != "7200" {
+			t.Errorf("expected '7200', got %q", vars["ttl"])
+		}
+		if vars["url"] != "https://x.io/?a=b" {
+			t.Errorf("expected value to keep '=' characters, got %q", vars["url"])
+		}
+	})
+
+	t.Run("rejects missing separator", func(t *testing.T) {
+		if _, err := ParseVars([]string{"novalue"}); err == nil {
+			t.Error("expected error for pair without '='")
+		}
+	})
+
+	t.Run("rejects empty key", func(t *testing.T) {
+		if _, err := ParseVars([]string{"=value"}); err == nil {
+			t.Error("expected error for empty key")
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	config := Vars{"project_key": "from-config", "ttl": "3600"}
+	cli := Vars{"project_key": "from-cli"}
+
+	merged := Merge(config, cli)
+
+	if merged["project_key"] != "from-cli" {
+		t.Errorf("expected CLI value to win, got %q", merged["project_key"])
+	}
+	if merged["ttl"] != "3600" {
+		t.Errorf("expected config value to be kept, got %q", merged["ttl"])
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	t.Run("uses provided values", func(t *testing.T) {
+		vars := Builtins("bd-123", "my-project", "feature/bd-123-x")
+		if vars[VarTaskID] != "bd-123" || vars[VarProjectName] != "my-project" || vars[VarBranch] != "feature/bd-123-x" {
+			t.Errorf("unexpected builtins: %v", vars)
+		}
+	})
+
+	t.Run("uses placeholders when unknown", func(t *testing.T) {
+		vars := Builtins("", "", "")
+		if vars[VarTaskID] != "<task-id>" {
+			t.Errorf("expected task placeholder, got %q", vars[VarTaskID])
+		}
+		if vars[VarProjectName] != "project-name" {
+			t.Errorf("expected project placeholder, got %q", vars[VarProjectName])
+		}
+	})
+}
+
+func TestProtocol(t *testing.T) {
+	vars := Vars{"task_id": "bd-42", "project_key": "acme"}
+
+	t.Run("returns fallback without template", func(t *testing.T) {
+		result, err := Protocol(t.TempDir(), "done", "default protocol\n", vars)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != "default protocol\n" {
+			t.Errorf("expected fallback, got %q", result)
+		}
+	})
+
+	t.Run("renders template override", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		path := Path(tmpDir, "done")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		body := "Close {{.task_id}} in project {{.project_key}}\n"
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Protocol(tmpDir, "done", "default protocol\n", vars)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != "Close bd-42 in project acme\n" {
+			t.Errorf("unexpected render: %q", result)
+		}
+	})
+
+	t.Run("errors on undefined variable", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		path := Path(tmpDir, "next")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{{.typo_var}}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := Protocol(tmpDir, "next", "fallback", vars)
+		if err == nil || !strings.Contains(err.Error(), "next") {
+			t.Errorf("expected rendering error naming the template, got %v", err)
+		}
+	})
+}
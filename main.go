@@ -3,19 +3,34 @@ package main
 import (
 	"embed"
 	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/vibes-project/vibes/internal/beads"
+	"github.com/vibes-project/vibes/internal/config"
 	"github.com/vibes-project/vibes/internal/done"
+	"github.com/vibes-project/vibes/internal/emoji"
+	"github.com/vibes-project/vibes/internal/exitcode"
+	"github.com/vibes-project/vibes/internal/explain"
 	"github.com/vibes-project/vibes/internal/feedback"
+	"github.com/vibes-project/vibes/internal/git"
+	"github.com/vibes-project/vibes/internal/inittask"
 	"github.com/vibes-project/vibes/internal/next"
+	"github.com/vibes-project/vibes/internal/notify"
 	"github.com/vibes-project/vibes/internal/pr"
 	"github.com/vibes-project/vibes/internal/prfix"
+	"github.com/vibes-project/vibes/internal/project"
+	"github.com/vibes-project/vibes/internal/prompt"
 	"github.com/vibes-project/vibes/internal/ralph"
+	"github.com/vibes-project/vibes/internal/report"
 	"github.com/vibes-project/vibes/internal/resume"
 	"github.com/vibes-project/vibes/internal/setup"
 	"github.com/vibes-project/vibes/internal/stuck"
 	"github.com/vibes-project/vibes/internal/styles"
+	"github.com/vibes-project/vibes/internal/templates"
 )
 
 //go:embed proompts
@@ -24,20 +39,83 @@ var proomptFS embed.FS
 var (
 	version = "dev"
 
-	migrateTasks    bool
-	skipProompts    bool
-	nextVerbose     bool
-	doneVerbose     bool
-	resumeVerbose   bool
-	resumeNoFetch   bool
-	prVerbose       bool
-	prfixVerbose    bool
-	feedbackVerbose bool
-	stuckVerbose    bool
-	ralphVerbose    bool
-	ralphGoal       string
-	ralphAutopilot  bool
-	ralphMaxIter    int
+	migrateTasks     bool
+	skipProompts     bool
+	proomptsRepo     string
+	setupForce       bool
+	nextVerbose      bool
+	nextFullTask     bool
+	nextRecord       bool
+	nextClosed       bool
+	nextRefresh      bool
+	ralphRecord      bool
+	doneVerbose      bool
+	doneTask         string
+	resumeTask       string
+	doneVerify       bool
+	doneEcosystem    string
+	doneFetch        bool
+	doneNoFetch      bool
+	resumeVerbose    bool
+	resumeNoFetch    bool
+	resumeFetch      bool
+	resumeLastTest   bool
+	prVerbose        bool
+	prCheckID        bool
+	prParent         string
+	prPaths          string
+	doneCheckID      bool
+	doneDeps         bool
+	doneCheckWIP     bool
+	donePaths        string
+	doneClose        bool
+	doneForce        bool
+	prfixVerbose     bool
+	prfixNotify      bool
+	prfixResolve     bool
+	prfixOnlyFailing bool
+	feedbackVerbose  bool
+	feedbackThread   string
+	stuckVerbose     bool
+	stuckFormat      string
+	stuckDiffCtx     int
+	stuckRange       string
+	stuckPaths       string
+	ralphVerbose     bool
+	ralphGoal        string
+	ralphAutopilot   bool
+	ralphMaxIter     int
+	ralphIteration   int
+	nextMaxTasks     int
+	ralphMaxTasks    int
+	ralphEcosystem   string
+	reportSince      string
+	reportAuthor     string
+	reportJSON       bool
+	initTaskPrio     int
+	initTaskYes      bool
+	initTaskVerbose  bool
+	templateVarArgs  []string
+	projectKeyFlag   string
+	ttlFlag          int
+	configPath       string
+	outputWidth      int
+	noEmoji          bool
+	logLevel         string
+	modelName        string
+	strict           bool
+	nextOutput       prompt.Output
+	resumeOutput     prompt.Output
+	stuckOutput      prompt.Output
+	doneOutput       prompt.Output
+	prOutput         prompt.Output
+	feedbackOutput   prompt.Output
+	initTaskOutput   prompt.Output
+
+	// cfg holds settings from .vibes.yaml, loaded before any subcommand runs
+	cfg = &config.Config{}
+	// issueTracker is built from cfg's issue_pattern; nil means Beads only
+	issueTracker *beads.IssueTracker
 )
 
 func main() {
@@ -60,6 +138,51 @@ Examples:
 
 	rootCmd.Flags().BoolVar(&migrateTasks, "migrate", false, "Migrate existing tasks.yaml to Beads")
 	rootCmd.Flags().BoolVar(&skipProompts, "skip-proompts", false, "Don't copy proompts directory")
+	rootCmd.Flags().StringVar(&proomptsRepo, "proompts-repo", "", "Copy proompts from a git repo (<url>[@ref]) instead of the built-in set")
+	rootCmd.Flags().BoolVar(&setupForce, "force", false, "Allow setup in your home directory or a filesystem root")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(logLevel); err != nil {
+			return err
+		}
+		loaded, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+		tracker, err := beads.NewIssueTracker(cfg.IssuePattern, cfg.IssueURL)
+		if err != nil {
+			return err
+		}
+		issueTracker = tracker
+		if err := applyModel(cmd); err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("width") {
+			outputWidth = prompt.TerminalWidth()
+		}
+		emoji.Disabled = noEmoji || emoji.Unsupported()
+		if cmd.Flags().Changed("post-comment") {
+			if n, _ := cmd.Flags().GetInt("post-comment"); n <= 0 {
+				return fmt.Errorf("invalid --post-comment %d (must be a positive PR number)", n)
+			}
+		}
+		for _, o := range []*prompt.Output{&nextOutput, &resumeOutput, &stuckOutput, &doneOutput, &prOutput, &feedbackOutput, &initTaskOutput} {
+			if o.SectionOrder == "" {
+				o.SectionOrder = cfg.SectionOrder
+			}
+			o.Width = outputWidth
+		}
+		return nil
+	}
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Load settings only from this file instead of .vibes.yaml in the current directory")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "Diagnostic logging to stderr: debug, info, warn, or error")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail when bd/bv or gh are missing or fail, instead of emitting a degraded prompt (next, ralph, pr, pr-fix)")
+	rootCmd.PersistentFlags().StringVar(&modelName, "model", "", "Tune prompt defaults for a model profile (haiku, sonnet, opus, gpt-4, or models in .vibes.yaml)")
+	rootCmd.PersistentFlags().StringVar(&projectKeyFlag, "project-key", "", "Agent Mail project key for MCP snippets in next, resume, done, and feedback (default: directory name)")
+	rootCmd.PersistentFlags().IntVar(&ttlFlag, "ttl", prompt.DefaultReservationTTL, "Seconds to hold Agent Mail file reservations in the snippets in next, init-task, resume, and feedback")
+	rootCmd.PersistentFlags().IntVar(&outputWidth, "width", 0, "Soft-wrap prose in printed prompts and reports to this many columns, leaving code intact (default: terminal width on a TTY, no wrapping when piped; 0 disables)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "Print ASCII markers like [warn] and [ok] instead of emoji (default when NO_COLOR is set or the locale isn't UTF-8)")
+	rootCmd.PersistentFlags().StringArrayVar(&templateVarArgs, "template-var", nil, "Set a protocol template variable as key=value (repeatable)")
 
 	// Next command - outputs prompt for claude
 	nextCmd := &cobra.Command{
@@ -77,6 +200,15 @@ copying output, and combining with start-task.md.`,
 		RunE: runNext,
 	}
 	nextCmd.Flags().BoolVarP(&nextVerbose, "verbose", "v", false, "Include full protocol details")
+	nextCmd.Flags().BoolVar(&nextFullTask, "full-task", false, "Include the top task's full description from bd show")
+	nextCmd.Flags().BoolVar(&nextRecord, "record", false, "Append the recommended task to .vibes/history.jsonl")
+	nextCmd.Flags().BoolVar(&nextRefresh, "refresh", false, "Re-import the beads database (bd sync --import-only) before triage, in case its cache is stale")
+	nextCmd.Flags().BoolVar(&nextClosed, "recent-closed", false, fmt.Sprintf("List the %d most recently closed tasks for continuity", beads.MaxRecentClosed))
+	nextCmd.Flags().IntVar(&nextMaxTasks, "max-tasks", beads.DefaultMaxTasks, "Max tasks to include from triage output (0 = unlimited)")
+	addNoProtocolFlag(nextCmd, &nextOutput)
+	addSectionOrderFlag(nextCmd, &nextOutput)
+	addSplitFlags(nextCmd, &nextOutput)
+	addShowCommandFlag(nextCmd, &nextOutput)
 	rootCmd.AddCommand(nextCmd)
 
 	// Done command - outputs completion prompt for claude
@@ -92,11 +224,32 @@ Usage with Claude:
 This helps you wrap up work by:
 - Detecting the current task from branch name or in-progress beads
 - Showing recent commits on the branch
-- Providing the completion protocol (release reservations, update status, etc.)`,
+- Providing the completion protocol (release reservations, update status, etc.)
+
+With --verify, the project's test command is run first and the completion
+protocol is withheld if tests fail.`,
 		Args: cobra.NoArgs,
 		RunE: runDone,
 	}
 	doneCmd.Flags().BoolVarP(&doneVerbose, "verbose", "v", false, "Include full protocol details")
+	doneCmd.Flags().StringVar(&doneTask, "task", "", "Use this bead instead of detecting the current task")
+	doneCmd.Flags().BoolVar(&doneVerify, "verify", false, "Run the project's test command and block completion if it fails")
+	doneCmd.Flags().BoolVar(&doneFetch, "fetch", false, "Fetch from remote before checking ahead/behind (slower, but accurate)")
+	doneCmd.Flags().BoolVar(&doneNoFetch, "no-fetch", false, "Skip fetching from remote; ahead/behind reflects the last fetch (default)")
+	doneCmd.MarkFlagsMutuallyExclusive("fetch", "no-fetch")
+	doneCmd.Flags().StringVar(&doneEcosystem, "primary-ecosystem", "", "Only verify this ecosystem in polyglot repos (go, node, python, rust, make)")
+	doneCmd.Flags().BoolVar(&doneClose, "close", false, "Close the task in beads (refused while open tasks block it)")
+	doneCmd.Flags().BoolVar(&doneForce, "force", false, "With --close, close even if open tasks block it")
+	doneCmd.Flags().BoolVar(&doneDeps, "dependencies", false, "Show which tasks closing this one will unblock")
+	doneCmd.Flags().BoolVar(&doneCheckWIP, "check-wip", false, "Flag TODO/FIXME/debug markers added on this branch")
+	doneCmd.Flags().StringVar(&donePaths, "paths", string(git.PathsRepoRoot), "How to show --check-wip file paths: repo-root, relative (to the current directory), or absolute")
+	doneCmd.Flags().BoolVar(&doneCheckID, "check-identity", false, "Warn if git user.email doesn't belong to the authenticated gh account")
+	addCommentFlags(doneCmd, &doneOutput)
+	addNoProtocolFlag(doneCmd, &doneOutput)
+	addSectionOrderFlag(doneCmd, &doneOutput)
+	addSplitFlags(doneCmd, &doneOutput)
+	addShowCommandFlag(doneCmd, &doneOutput)
+	addTaskCommentFlag(doneCmd, &doneOutput)
 	rootCmd.AddCommand(doneCmd)
 
 	// Resume command - outputs prompt to continue work
@@ -118,7 +271,16 @@ This helps you continue seamlessly by:
 		RunE: runResume,
 	}
 	resumeCmd.Flags().BoolVarP(&resumeVerbose, "verbose", "v", false, "Include full protocol details")
+	resumeCmd.Flags().StringVar(&resumeTask, "task", "", "Use this bead instead of detecting the current task")
+	resumeCmd.Flags().BoolVar(&resumeFetch, "fetch", false, "Fetch from remote before checking ahead/behind (default)")
 	resumeCmd.Flags().BoolVar(&resumeNoFetch, "no-fetch", false, "Skip fetching from remote (faster, but may miss remote changes)")
+	resumeCmd.Flags().BoolVar(&resumeLastTest, "last-test", false, "Show the last test result from done --verify, running the tests if none is cached")
+	resumeCmd.MarkFlagsMutuallyExclusive("fetch", "no-fetch")
+	addNoProtocolFlag(resumeCmd, &resumeOutput)
+	addSectionOrderFlag(resumeCmd, &resumeOutput)
+	addSplitFlags(resumeCmd, &resumeOutput)
+	addShowCommandFlag(resumeCmd, &resumeOutput)
+	addTaskCommentFlag(resumeCmd, &resumeOutput)
 	rootCmd.AddCommand(resumeCmd)
 
 	// PR command - outputs prompt for creating a pull request
@@ -139,6 +301,14 @@ This helps you create well-crafted pull requests by:
 		RunE: runPr,
 	}
 	prCmd.Flags().BoolVarP(&prVerbose, "verbose", "v", false, "Include full protocol details")
+	prCmd.Flags().StringVar(&prPaths, "paths", string(git.PathsRepoRoot), "How to show file paths: repo-root, relative (to the current directory), or absolute")
+	prCmd.Flags().BoolVar(&prCheckID, "check-identity", false, "Warn if git user.email doesn't belong to the authenticated gh account")
+	prCmd.Flags().StringVar(&prParent, "parent", "", "Branch this one is stacked on; diff against it and target it with gh pr create --base")
+	addCommentFlags(prCmd, &prOutput)
+	addNoProtocolFlag(prCmd, &prOutput)
+	addSectionOrderFlag(prCmd, &prOutput)
+	addSplitFlags(prCmd, &prOutput)
+	addShowCommandFlag(prCmd, &prOutput)
 	rootCmd.AddCommand(prCmd)
 
 	// PR Fix command - outputs prompt to fix PR issues
@@ -161,6 +331,9 @@ This helps you iterate on a PR until it's mergeable by:
 		RunE: runPrFix,
 	}
 	prfixCmd.Flags().BoolVarP(&prfixVerbose, "verbose", "v", false, "Include full protocol details")
+	prfixCmd.Flags().BoolVar(&prfixResolve, "resolve-comments", false, "Pick addressed review threads and mark them resolved (asks before resolving)")
+	prfixCmd.Flags().BoolVar(&prfixOnlyFailing, "only-failing", false, "Show only failing and pending checks, changes-requested reviews, and unresolved review threads")
+	prfixCmd.Flags().BoolVar(&prfixNotify, "notify", false, "Send the PR's check status to notify_command/notify_webhook from .vibes.yaml when done")
 	rootCmd.AddCommand(prfixCmd)
 
 	// Feedback command - outputs prompt to act on review feedback
@@ -183,6 +356,11 @@ This helps you address review feedback by:
 		RunE: runFeedback,
 	}
 	feedbackCmd.Flags().BoolVarP(&feedbackVerbose, "verbose", "v", false, "Include full protocol details")
+	feedbackCmd.Flags().StringVar(&feedbackThread, "thread", "", "Review thread ID (default: <task-id>-review)")
+	addCommentFlags(feedbackCmd, &feedbackOutput)
+	addSectionOrderFlag(feedbackCmd, &feedbackOutput)
+	addSplitFlags(feedbackCmd, &feedbackOutput)
+	addShowCommandFlag(feedbackCmd, &feedbackOutput)
 	rootCmd.AddCommand(feedbackCmd)
 
 	// Stuck command - outputs prompt to help debug issues
@@ -196,6 +374,7 @@ to help diagnose and fix the issue.
 Usage with Claude:
   claude "$(vibes stuck)"
   claude "$(vibes stuck 'tests fail but I dont understand why')"
+  claude "$(vibes stuck --range v1.4.0..HEAD 'login broke')"
 
 This helps you get unstuck by:
 - Showing recent changes and commits
@@ -206,6 +385,15 @@ This helps you get unstuck by:
 		RunE: runStuck,
 	}
 	stuckCmd.Flags().BoolVarP(&stuckVerbose, "verbose", "v", false, "Include full protocol details")
+	stuckCmd.Flags().StringVar(&stuckFormat, "format", stuck.FormatMarkdown, "Output format: markdown or sarif (detected errors only)")
+	stuckCmd.Flags().IntVar(&stuckDiffCtx, "diff-context", 3, "Lines of context around each change in the included diff")
+	stuckCmd.Flags().StringVar(&stuckPaths, "paths", string(git.PathsRepoRoot), "How to show file paths in diff stats: repo-root, relative (to the current directory), or absolute")
+	stuckCmd.Flags().StringVar(&stuckRange, "range", "", "Diagnose a regression between <good>..<bad> commits: show that range's diff and commits, and detect errors in a temporary checkout of <bad>")
+	addNoProtocolFlag(stuckCmd, &stuckOutput)
+	addSectionOrderFlag(stuckCmd, &stuckOutput)
+	addSplitFlags(stuckCmd, &stuckOutput)
+	addShowCommandFlag(stuckCmd, &stuckOutput)
+	addTaskCommentFlag(stuckCmd, &stuckOutput)
 	rootCmd.AddCommand(stuckCmd)
 
 	// Ralph command - outputs prompt for autonomous Ralph loop development
@@ -234,10 +422,80 @@ The prompt ensures dual completion signals:
 	ralphCmd.Flags().StringVarP(&ralphGoal, "goal", "g", "", "Work toward a specific goal")
 	ralphCmd.Flags().BoolVarP(&ralphAutopilot, "autopilot", "a", false, "Work through entire task graph")
 	ralphCmd.Flags().IntVarP(&ralphMaxIter, "max-iterations", "n", 0, "Suggest max iterations (0 = unlimited)")
+	ralphCmd.Flags().IntVar(&ralphIteration, "iteration", 0, "Current iteration number for checkpoint commits (0 = detect from ralph: iteration commits)")
+	ralphCmd.Flags().BoolVar(&ralphRecord, "record", false, "Append the targeted task to .vibes/history.jsonl")
+	ralphCmd.Flags().IntVar(&ralphMaxTasks, "max-tasks", beads.DefaultMaxTasks, "Max tasks to include from triage output (0 = unlimited)")
+	ralphCmd.Flags().StringVar(&ralphEcosystem, "primary-ecosystem", "", "Only use this ecosystem's test command in polyglot repos (go, node, python, rust, make)")
 	rootCmd.AddCommand(ralphCmd)
 
+	// Report command - summarizes recent work
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize recent work for a standup or end-of-day update",
+		Long: `Summarizes work done since a point in time: your commits (including Ralph
+checkpoint iterations), Beads tasks closed, and pull requests opened or merged.
+
+Examples:
+  vibes report                    # Today's work
+  vibes report --since yesterday  # Since midnight yesterday
+  vibes report --since 2d --json  # Last two days, as JSON for tooling
+
+Sections whose tool (bd, gh) is missing or fails are omitted.`,
+		Args: cobra.NoArgs,
+		RunE: runReport,
+	}
+	reportCmd.Flags().StringVar(&reportSince, "since", "today", "Start of the period: today, yesterday, a duration like 8h or 2d, or YYYY-MM-DD")
+	reportCmd.Flags().StringVar(&reportAuthor, "author", "", "Commit author to report on (defaults to git config user.email)")
+	reportCmd.Flags().BoolVar(&reportJSON, "json", false, "Output the report as JSON")
+	rootCmd.AddCommand(reportCmd)
+
+	// Init-task command - creates a bead and branch, then outputs the next prompt for it
+	initTaskCmd := &cobra.Command{
+		Use:   "init-task [title]",
+		Short: "Create a bead and feature branch for new work, then output its prompt",
+		Long: `Creates a Beads task with bd create, checks out a feature/<id>-<slug> branch
+for it, and outputs the next prompt for the new task.
+
+Prompts for the title and priority when no title is given, and asks for
+confirmation before creating anything (skip with --yes).
+
+Examples:
+  vibes init-task                               # Prompt for title and priority
+  claude "$(vibes init-task 'Add login page' -p 1 --yes)"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runInitTask,
+	}
+	initTaskCmd.Flags().IntVarP(&initTaskPrio, "priority", "p", inittask.DefaultPriority, "Bead priority, 0 (highest) to 4")
+	initTaskCmd.Flags().BoolVarP(&initTaskYes, "yes", "y", false, "Create without asking for confirmation")
+	initTaskCmd.Flags().BoolVarP(&initTaskVerbose, "verbose", "v", false, "Include full protocol details")
+	addNoProtocolFlag(initTaskCmd, &initTaskOutput)
+	addSectionOrderFlag(initTaskCmd, &initTaskOutput)
+	addSplitFlags(initTaskCmd, &initTaskOutput)
+	addShowCommandFlag(initTaskCmd, &initTaskOutput)
+	rootCmd.AddCommand(initTaskCmd)
+
+	// Explain command - describes what another command gathers, without running it
+	explainCmd := &cobra.Command{
+		Use:   "explain [command]",
+		Short: "Describe what a command gathers and runs, without running it",
+		Long: `Describes, without running anything, which git, bd, bv, and gh commands a
+vibes command runs, which prompt sections they fill, and which flags make it
+change anything.
+
+Examples:
+  vibes explain          # List the commands that can be explained
+  vibes explain next     # What vibes next is about to run in your repo`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: explain.Names(),
+		RunE:      runExplain,
+	}
+	rootCmd.AddCommand(explainCmd)
+
+	// Errors are printed here rather than by cobra so --no-emoji applies to them
+	rootCmd.SilenceErrors = true
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error:", emoji.Render(err.Error()))
+		os.Exit(exitcode.For(err))
 	}
 }
 
@@ -259,7 +517,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	if !setup.IsGitRepo(targetDir) {
 		fmt.Println(styles.Error("Directory is not a git repository"))
 		fmt.Println("Run this command in a git repository or specify a target directory.")
-		return fmt.Errorf("not a git repository")
+		return git.ErrNotGitRepo
 	}
 
 	// Check if vibes is already set up (when no args provided)
@@ -278,6 +536,8 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		TargetDir:    targetDir,
 		MigrateTasks: migrateTasks,
 		SkipProompts: skipProompts,
+		ProomptsRepo: proomptsRepo,
+		Force:        setupForce,
 		SourceFS:     proomptFS,
 	}
 
@@ -286,44 +546,156 @@ func runSetup(cmd *cobra.Command, args []string) error {
 }
 
 func runNext(cmd *cobra.Command, args []string) error {
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	ttl, err := reservationTTL(cmd)
+	if err != nil {
+		return err
+	}
 	opts := next.Options{
-		Verbose: nextVerbose,
+		Verbose:        nextVerbose,
+		FullTask:       nextFullTask,
+		Record:         nextRecord || cfg.Record,
+		RecentClosed:   nextClosed,
+		Refresh:        nextRefresh,
+		StaleAfter:     staleAfter(),
+		Strict:         strict,
+		MaxTasks:       maxTasks(cmd, nextMaxTasks),
+		ProjectKey:     projectKey(),
+		ReservationTTL: ttl,
+		TemplateVars:   vars,
+		Output:         nextOutput,
 	}
 	return next.Run(opts)
 }
 
 func runDone(cmd *cobra.Command, args []string) error {
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	ecosystem, err := primaryEcosystem(doneEcosystem)
+	if err != nil {
+		return err
+	}
+	paths, err := git.ParsePathMode(donePaths)
+	if err != nil {
+		return err
+	}
 	opts := done.Options{
-		Verbose: doneVerbose,
+		Verbose:      doneVerbose,
+		Fetch:        doneFetch && !doneNoFetch,
+		Task:         doneTask,
+		Verify:       doneVerify,
+		CheckID:      doneCheckID,
+		Dependencies: doneDeps,
+		CheckWIP:     doneCheckWIP,
+		WIPPatterns:  cfg.WIPPatterns,
+		Paths:        paths,
+		Close:        doneClose,
+		Force:        doneForce,
+		TestCommand:  cfg.TestCommand,
+		Ecosystem:    ecosystem,
+		ProjectKey:   projectKey(),
+		TemplateVars: vars,
+		Output:       doneOutput,
+		Tracker:      issueTracker,
 	}
 	return done.Run(opts)
 }
 
 func runResume(cmd *cobra.Command, args []string) error {
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	ttl, err := reservationTTL(cmd)
+	if err != nil {
+		return err
+	}
+	ecosystem, err := primaryEcosystem("")
+	if err != nil {
+		return err
+	}
 	opts := resume.Options{
-		Verbose: resumeVerbose,
-		NoFetch: resumeNoFetch,
+		Verbose:        resumeVerbose,
+		NoFetch:        resumeNoFetch && !resumeFetch,
+		StaleAfter:     staleAfter(),
+		LastTest:       resumeLastTest,
+		TestCommand:    cfg.TestCommand,
+		Ecosystem:      ecosystem,
+		Task:           resumeTask,
+		ProjectKey:     projectKey(),
+		ReservationTTL: ttl,
+		TemplateVars:   vars,
+		Output:         resumeOutput,
+		Tracker:        issueTracker,
 	}
 	return resume.Run(opts)
 }
 
 func runPr(cmd *cobra.Command, args []string) error {
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	paths, err := git.ParsePathMode(prPaths)
+	if err != nil {
+		return err
+	}
 	opts := pr.Options{
-		Verbose: prVerbose,
+		Verbose:      prVerbose,
+		CheckID:      prCheckID,
+		Parent:       prParent,
+		Paths:        paths,
+		Strict:       strict,
+		TemplateVars: vars,
+		Output:       prOutput,
+		Tracker:      issueTracker,
 	}
 	return pr.Run(opts)
 }
 
 func runPrFix(cmd *cobra.Command, args []string) error {
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	notifier, err := notifyOptions(prfixNotify)
+	if err != nil {
+		return err
+	}
 	opts := prfix.Options{
-		Verbose: prfixVerbose,
+		Verbose:      prfixVerbose,
+		Strict:       strict,
+		TemplateVars: vars,
+		Notify:       notifier,
+		Resolve:      prfixResolve,
+		OnlyFailing:  prfixOnlyFailing,
+		Tracker:      issueTracker,
 	}
 	return prfix.Run(opts)
 }
 
 func runFeedback(cmd *cobra.Command, args []string) error {
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	ttl, err := reservationTTL(cmd)
+	if err != nil {
+		return err
+	}
 	opts := feedback.Options{
-		Verbose: feedbackVerbose,
+		Verbose:        feedbackVerbose,
+		Thread:         feedbackThread,
+		ProjectKey:     projectKey(),
+		ReservationTTL: ttl,
+		TemplateVars:   vars,
+		Output:         feedbackOutput,
+		Tracker:        issueTracker,
 	}
 	return feedback.Run(opts)
 }
@@ -333,14 +705,35 @@ func runStuck(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		description = args[0]
 	}
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	paths, err := git.ParsePathMode(stuckPaths)
+	if err != nil {
+		return err
+	}
 	opts := stuck.Options{
-		Verbose:     stuckVerbose,
-		Description: description,
+		Verbose:      stuckVerbose,
+		Description:  description,
+		Format:       stuckFormat,
+		Range:        stuckRange,
+		Paths:        paths,
+		TemplateVars: vars,
+		Output:       stuckOutput,
+		Tracker:      issueTracker,
+	}
+	if cmd.Flags().Changed("diff-context") {
+		opts.DiffContext = &stuckDiffCtx
 	}
 	return stuck.Run(opts)
 }
 
 func runRalph(cmd *cobra.Command, args []string) error {
+	ecosystem, err := primaryEcosystem(ralphEcosystem)
+	if err != nil {
+		return err
+	}
 	mode := ralph.ModeSingleTask
 	if ralphGoal != "" {
 		mode = ralph.ModeGoal
@@ -353,6 +746,232 @@ func runRalph(cmd *cobra.Command, args []string) error {
 		Mode:          mode,
 		Goal:          ralphGoal,
 		MaxIterations: ralphMaxIter,
+		Iteration:     ralphIteration,
+		Record:        ralphRecord || cfg.Record,
+		Strict:        strict,
+		MaxTasks:      maxTasks(cmd, ralphMaxTasks),
+		TestCommand:   cfg.TestCommand,
+		Ecosystem:     ecosystem,
 	}
 	return ralph.Run(opts)
 }
+
+func runReport(cmd *cobra.Command, args []string) error {
+	opts := report.Options{
+		Since:  reportSince,
+		Author: reportAuthor,
+		JSON:   reportJSON,
+		Width:  outputWidth,
+	}
+	return report.Run(opts)
+}
+
+func runInitTask(cmd *cobra.Command, args []string) error {
+	vars, err := templateVars()
+	if err != nil {
+		return err
+	}
+	ttl, err := reservationTTL(cmd)
+	if err != nil {
+		return err
+	}
+	opts := inittask.Options{
+		Priority:       initTaskPrio,
+		Yes:            initTaskYes,
+		Verbose:        initTaskVerbose,
+		ProjectKey:     projectKey(),
+		ReservationTTL: ttl,
+		TemplateVars:   vars,
+		Output:         initTaskOutput,
+	}
+	if len(args) > 0 {
+		opts.Title = args[0]
+	}
+	return inittask.Run(opts)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		fmt.Print(prompt.Wrap(explain.List(), outputWidth))
+		return nil
+	}
+	c, err := explain.Lookup(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(prompt.Wrap(explain.Render(c), outputWidth))
+	return nil
+}
+
+// setupLogging sends diagnostic logs at or above level to stderr.
+// info shows detection decisions; debug adds every subprocess and its duration.
+func setupLogging(level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid --log-level %q (expected debug, info, warn, or error)", level)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l})))
+	return nil
+}
+
+// applyModel layers the --model (or config model) profile over the config and the
+// command's --verbose default. Explicit flags still win.
+func applyModel(cmd *cobra.Command) error {
+	name := modelName
+	if name == "" {
+		name = cfg.Model
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		return err
+	}
+	cfg.ApplyModel(profile)
+	if f := cmd.Flags().Lookup("verbose"); f != nil && !f.Changed && profile.Verbose != nil {
+		if err := f.Value.Set(strconv.FormatBool(*profile.Verbose)); err != nil {
+			return err
+		}
+	}
+	slog.Info("model profile", "model", name)
+	return nil
+}
+
+// loadConfig reads the --config file when given, otherwise .vibes.yaml from
+// the current directory.
+func loadConfig() (*config.Config, error) {
+	if configPath != "" {
+		return config.LoadFile(configPath)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("getting current directory: %w", err)
+	}
+	return config.Load(cwd)
+}
+
+// templateVars merges template variables from .vibes.yaml with --template-var flags.
+// Flag values take precedence over config values.
+func templateVars() (map[string]string, error) {
+	cliVars, err := templates.ParseVars(templateVarArgs)
+	if err != nil {
+		return nil, err
+	}
+	return templates.Merge(cfg.TemplateVars, cliVars), nil
+}
+
+// notifyOptions returns the notification targets from config when --notify is set.
+func notifyOptions(enabled bool) (notify.Options, error) {
+	if !enabled {
+		return notify.Options{}, nil
+	}
+	opts := notify.Options{Command: cfg.NotifyCommand, Webhook: cfg.NotifyWebhook}
+	if !opts.Enabled() {
+		return opts, fmt.Errorf("--notify needs notify_command or notify_webhook in %s", config.FileName)
+	}
+	return opts, nil
+}
+
+// projectKey resolves the Agent Mail project key: --project-key, then
+// VIBES_PROJECT_KEY, then project_key in config. Empty means the directory name.
+func projectKey() string {
+	if projectKeyFlag != "" {
+		return projectKeyFlag
+	}
+	if key := os.Getenv("VIBES_PROJECT_KEY"); key != "" {
+		return key
+	}
+	return cfg.ProjectKey
+}
+
+// reservationTTL resolves the file reservation lifetime: --ttl, then
+// reservation_ttl in config, then prompt.DefaultReservationTTL.
+func reservationTTL(cmd *cobra.Command) (int, error) {
+	if cmd.Flags().Changed("ttl") {
+		if ttlFlag <= 0 {
+			return 0, fmt.Errorf("invalid --ttl %d (must be a positive number of seconds)", ttlFlag)
+		}
+		return ttlFlag, nil
+	}
+	if cfg.ReservationTTL < 0 {
+		return 0, fmt.Errorf("invalid reservation_ttl %d in %s (must be a positive number of seconds)", cfg.ReservationTTL, config.FileName)
+	}
+	return prompt.ReservationTTL(cfg.ReservationTTL), nil
+}
+
+// staleAfter converts stale_days from config into a duration. Zero means the default.
+func staleAfter() time.Duration {
+	return time.Duration(cfg.StaleDays) * 24 * time.Hour
+}
+
+// maxTasks resolves the triage task limit. An explicit --max-tasks flag wins over config.
+func maxTasks(cmd *cobra.Command, flagValue int) int {
+	if !cmd.Flags().Changed("max-tasks") && cfg.MaxTasks != nil {
+		return *cfg.MaxTasks
+	}
+	return flagValue
+}
+
+// primaryEcosystem resolves --primary-ecosystem, falling back to config.
+// Empty means all detected ecosystems are used.
+func primaryEcosystem(flagValue string) (project.Ecosystem, error) {
+	name := flagValue
+	if name == "" {
+		name = cfg.Ecosystem
+	}
+	if name == "" {
+		return "", nil
+	}
+	return project.ParseEcosystem(name)
+}
+
+// addCommentFlags registers --as-comment and --post-comment on commands that
+// can report status to a pull request.
+func addCommentFlags(cmd *cobra.Command, o *prompt.Output) {
+	cmd.Flags().BoolVar(&o.AsComment, "as-comment", false, "Format output as a PR/issue comment with collapsible sections")
+	cmd.Flags().IntVar(&o.PostComment, "post-comment", 0, "Post the comment to this PR number via gh (implies --as-comment)")
+}
+
+// addNoProtocolFlag registers --no-protocol, which limits output to gathered context.
+func addNoProtocolFlag(cmd *cobra.Command, o *prompt.Output) {
+	cmd.Flags().BoolVar(&o.NoProtocol, "no-protocol", false, "Output only the gathered context, without the protocol")
+}
+
+// addSplitFlags registers --split and --output-dir, which emit the prompt as
+// separate system and user messages for chat APIs.
+func addSplitFlags(cmd *cobra.Command, o *prompt.Output) {
+	cmd.Flags().BoolVar(&o.Split, "split", false, "Output protocol (system) and context (user) messages as JSON {system, user}")
+	cmd.Flags().StringVar(&o.OutputDir, "output-dir", "", "Write split messages to system.md and user.md in this directory (implies --split)")
+	if cmd.Flags().Lookup("as-comment") != nil {
+		for _, split := range []string{"split", "output-dir"} {
+			for _, comment := range []string{"as-comment", "post-comment"} {
+				cmd.MarkFlagsMutuallyExclusive(split, comment)
+			}
+		}
+	}
+}
+
+// addTaskCommentFlag registers --comment, which also records the prompt's
+// summary on the current bead.
+func addTaskCommentFlag(cmd *cobra.Command, o *prompt.Output) {
+	cmd.Flags().BoolVar(&o.TaskComment, "comment", false, "Also post the summary sections as a comment on the current bead via bd (asks first)")
+}
+
+// addShowCommandFlag registers --show-command, which explains how to run the
+// prompt with claude. The hint only fits a markdown prompt on stdout, so it
+// excludes the split and comment flags; register those first.
+func addShowCommandFlag(cmd *cobra.Command, o *prompt.Output) {
+	cmd.Flags().BoolVar(&o.ShowCommand, "show-command", false, "After the prompt, print the command to run it with claude (to stderr)")
+	for _, other := range []string{"split", "output-dir", "as-comment", "post-comment"} {
+		if cmd.Flags().Lookup(other) != nil {
+			cmd.MarkFlagsMutuallyExclusive("show-command", other)
+		}
+	}
+}
+
+// addSectionOrderFlag registers --section-order, which rearranges prompt sections.
+func addSectionOrderFlag(cmd *cobra.Command, o *prompt.Output) {
+	cmd.Flags().StringVar(&o.SectionOrder, "section-order", "", "Section order: context-first (default), protocol-first, or a comma-separated list of section names (* = the rest)")
+}